@@ -6,9 +6,13 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/user/k8v/internal/app"
+	"github.com/user/k8v/internal/audit"
 	"github.com/user/k8v/internal/k8s"
 	"github.com/user/k8v/internal/server"
 )
@@ -18,8 +22,42 @@ var Version = "dev"
 
 func main() {
 	// Parse flags
-	port := flag.Int("port", 8080, "HTTP server port")
+	port := flag.Int("port", 8080, "HTTP server port (0 for any free port)")
+	portRange := flag.String("find-port-in-range", "", "Listen on the first free port of a range, e.g. 8080-8099, instead of -port")
 	versionFlag := flag.Bool("version", false, "Print version and exit")
+	authToken := flag.String("auth-token", "", "Bearer token required for admin endpoints (e.g. /api/v1/audit/events)")
+	adminKey := flag.String("admin-key", "", "X-Admin-Key header value required to broadcast to exec sessions (empty to disable)")
+	auditLogPath := flag.String("audit-log", "logs/audit.log", "Audit log file for exec and delete operations (empty to disable)")
+	jwksURL := flag.String("jwks-url", "", "JWKS URL for validating JWTs that scope API access to a namespace (empty to disable)")
+	namespaceClaim := flag.String("namespace-claim", "namespace", "JWT claim holding the namespace a token is restricted to")
+	dryRun := flag.Bool("dry-run", false, "Run mutating API operations with server-side dry-run (nothing is persisted)")
+	readOnly := flag.Bool("read-only", false, "Disable every mutating operation: context switches, label edits and pod and node shells")
+	enforceNetworkPolicyCoverage := flag.Bool("enforce-network-policy-coverage", false, "Mark Pods not selected by any NetworkPolicy as warnings")
+	maxCachedResources := flag.Int("max-cached-resources", 0, "Maximum number of resources kept in memory, evicting least recently used (0 = unlimited)")
+	indexRelationships := flag.Bool("index-relationships", false, "Keep a reverse ownership index so Deployment and ReplicaSet updates don't scan the whole cache (uses more memory)")
+	requiredLabels := flag.String("required-labels", "", "Comma-separated labels every pod template must carry; Deployments missing them are marked as warnings")
+	excludeNamespaces := flag.String("exclude-namespaces", strings.Join(k8s.SystemNamespaces, ","), "Comma-separated namespaces hidden from the UI")
+	includeSystemNamespaces := flag.Bool("include-system-namespaces", false, "Show all namespaces, ignoring -exclude-namespaces")
+	resourceTypes := flag.String("resource-types", strings.Join(k8s.DefaultWatchResourceTypes, ","), "Comma-separated resource types to watch; analysis endpoints relying on unwatched types return no results")
+	logBufferLines := flag.Int("log-buffer-lines", k8s.DefaultLogBufferLines, "Recent log lines per container replayed to clients that join an active log stream")
+	namespace := flag.String("namespace", "", "Only watch resources in this namespace, for namespace-scoped credentials (remove Node and StorageClass from -resource-types)")
+	watchLabelSelector := flag.String("watch-label-selector", "", "Only watch resources matching this label selector (e.g. monitored=true), filtered by the API server: unmatched owners, Services and Nodes are hidden too")
+	watchAllAPIs := flag.Bool("watch-all-apis", false, "Also watch every listable resource found by API discovery (CRDs and built-ins without a dedicated view)")
+	cacheFile := flag.String("cache-file", "", "File the resource cache is periodically saved to and restored from on startup, one per context (empty to disable)")
+	cacheTTL := flag.Duration("cache-ttl", 5*time.Minute, "Maximum age of a -cache-file that is still restored on startup")
+	nodeDebugImage := flag.String("node-debug-image", k8s.DefaultNodeDebugPodOptions().Image, "Image of the debug pods used for node shells")
+	nodeDebugRegistry := flag.String("node-debug-registry", "", "Registry prepended to -node-debug-image, for air-gapped clusters (e.g. my-registry.corp)")
+	nodeShell := flag.String("node-shell", "", "Shell started on nodes, e.g. /bin/zsh (empty to try /bin/bash, then /bin/sh)")
+	nodeDebugPullSecrets := flag.String("node-debug-pull-secrets", "", "Comma-separated image pull secrets for node debug pods, in the debug pod namespace")
+	recordExecSessions := flag.Bool("record-exec-sessions", false, "Record pod and node shell sessions as asciinema v2 casts in -recording-dir")
+	recordingDir := flag.String("recording-dir", "logs/recordings", "Directory for exec session recordings")
+	execIdleTTL := flag.Duration("exec-idle-ttl", server.DefaultExecIdleTTL, "Close pod shell sessions that receive no input for this long")
+	ignoreSystemNamespaceEvents := flag.Bool("ignore-system-namespace-events", false, "Don't stream live add and update events for resources in kube-system, kube-node-lease and kube-public")
+	ignoreEventTypes := flag.String("ignore-event-types", "", "Comma-separated resource types whose live add and update events aren't streamed (they still appear in snapshots, and deletes are streamed)")
+	watchResourceQuotas := flag.Bool("watch-resource-quotas", false, "Watch ResourceQuotas for /api/v1/quota/pressure and QUOTA_PRESSURE events")
+	staticDir := flag.String("static-dir", "", "Serve the UI from this directory instead of the embedded assets (for frontend development)")
+	apiVersion := flag.String("api-version", server.APIVersionV1, `API paths documented by /api/v1/openapi.json: "v1", or "legacy" for the unversioned /api and /ws paths (no deprecation warnings)`)
+	enableHelmGrouping := flag.Bool("enable-helm-grouping", false, "Group resources of each Helm release under a synthetic HelmRelease resource")
 	flag.Parse()
 
 	if *versionFlag {
@@ -36,14 +74,35 @@ func main() {
 	}
 	defer logger.Close()
 
+	// Create audit logger (nil when disabled)
+	var auditLogger *audit.AuditLogger
+	if *auditLogPath != "" {
+		auditLogger, err = audit.NewAuditLogger(*auditLogPath)
+		if err != nil {
+			log.Fatalf("Failed to create audit logger: %v", err)
+		}
+		defer auditLogger.Close()
+	}
+
+	var excludedNamespaces []string
+	if !*includeSystemNamespaces {
+		excludedNamespaces = k8s.ParseList(*excludeNamespaces)
+	}
+
 	// Create hubs for WebSocket broadcasting
-	hub := server.NewHub(logger)
+	hub := server.NewHubWithOptions(logger, server.HubOptions{
+		ExcludeNamespaces: excludedNamespaces,
+	})
 	go hub.Run()
 
-	logHub := server.NewLogHub(logger)
+	logHub := server.NewLogHubWithOptions(logger, server.LogHubOptions{
+		BufferLines: *logBufferLines,
+	})
 	go logHub.Run()
 
-	execHub := server.NewExecHub(logger)
+	execHub := server.NewExecHubWithOptions(logger, server.ExecHubOptions{
+		IdleTTL: *execIdleTTL,
+	})
 	go execHub.Run()
 
 	nodeExecHub := server.NewNodeExecHub(logger)
@@ -55,7 +114,35 @@ func main() {
 		log.Fatalf("Failed to get current context: %v", err)
 	}
 
-	k8vApp := app.NewApp(logger, hub, logHub)
+	var eventFilters []k8s.EventFilter
+	if *ignoreSystemNamespaceEvents {
+		eventFilters = append(eventFilters, k8s.IgnoreSystemNamespacesFilter())
+	}
+	if ignored := k8s.ParseList(*ignoreEventTypes); len(ignored) > 0 {
+		eventFilters = append(eventFilters, k8s.IgnoreResourceTypeFilter(ignored...))
+	}
+
+	k8vApp := app.NewAppWithOptions(logger, hub, logHub, app.Options{
+		Cache: k8s.CacheOptions{
+			MaxResources:       *maxCachedResources,
+			IndexRelationships: *indexRelationships,
+		},
+		Watcher: k8s.WatcherOptions{
+			EnforceNetworkPolicyCoverage: *enforceNetworkPolicyCoverage,
+			RequiredLabels:               k8s.ParseList(*requiredLabels),
+			ExcludeNamespaces:            excludedNamespaces,
+			WatchResourceTypes:           k8s.ParseList(*resourceTypes),
+			WatchAllAPIs:                 *watchAllAPIs,
+			EnableHelmGrouping:           *enableHelmGrouping,
+			WatchResourceQuotas:          *watchResourceQuotas,
+		},
+		CacheFile:          *cacheFile,
+		CacheTTL:           *cacheTTL,
+		EventFilters:       eventFilters,
+		Namespace:          *namespace,
+		WatchLabelSelector: *watchLabelSelector,
+		ReadOnly:           *readOnly,
+	})
 	if err := k8vApp.Start(currentContext); err != nil {
 		log.Fatalf("Failed to start app: %v", err)
 	}
@@ -66,6 +153,57 @@ func main() {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 	defer srv.Close()
+	srv.SetAuditLogger(auditLogger)
+	if *portRange != "" {
+		first, last, err := parsePortRange(*portRange)
+		if err != nil {
+			log.Fatalf("Invalid -find-port-in-range: %v", err)
+		}
+		srv.SetPortRange(first, last)
+	}
+	srv.SetAuthToken(*authToken)
+	srv.SetAdminKey(*adminKey)
+	srv.SetDryRun(*dryRun)
+	srv.SetReadOnly(*readOnly)
+	if err := srv.SetAPIVersion(*apiVersion); err != nil {
+		log.Fatalf("Invalid -api-version: %v", err)
+	}
+	if *staticDir != "" {
+		srv.SetStaticDir(*staticDir)
+		logger.Printf("Warning: serving UI assets from %s instead of the embedded ones (-static-dir is meant for development)", *staticDir)
+	}
+	nodeDebugOptions := k8s.DefaultNodeDebugPodOptions()
+	nodeDebugOptions.Image = *nodeDebugImage
+	nodeDebugOptions.ImageRegistry = *nodeDebugRegistry
+	nodeDebugOptions.ImagePullSecrets = k8s.ParseList(*nodeDebugPullSecrets)
+	nodeDebugOptions.Shell = *nodeShell
+	srv.SetNodeDebugPodOptions(nodeDebugOptions)
+	srv.SetExecSessionOptions(server.ExecSessionOptions{
+		RecordingEnabled: *recordExecSessions,
+		RecordingDir:     *recordingDir,
+	})
+	if *dryRun {
+		logger.Printf("Dry-run mode enabled: mutating operations will not be persisted")
+	}
+	if *readOnly {
+		logger.Printf("Running in read-only mode")
+	}
+	if *jwksURL != "" {
+		srv.SetNamespaceScoper(server.NewJWTNamespaceScoper(*jwksURL, *namespaceClaim))
+	}
+
+	// Bind before announcing the port, as it may be chosen dynamically
+	boundPort, err := srv.Listen()
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	var portFile string
+	if *port == 0 || *portRange != "" {
+		portFile, err = app.WritePortFile(currentContext, boundPort)
+		if err != nil {
+			logger.Printf("Warning: %v", err)
+		}
+	}
 
 	// Handle shutdown gracefully
 	go func() {
@@ -76,14 +214,41 @@ func main() {
 		logger.Printf("\nShutting down...")
 		k8vApp.Stop()
 		srv.Close()
+		if portFile != "" {
+			app.RemovePortFile(portFile)
+		}
 		os.Exit(0)
 	}()
 
 	// Start server (blocking)
-	logger.Printf("✓ Server starting on http://localhost:%d", *port)
-	fmt.Printf("\n🚀 K8V is running! Open http://localhost:%d in your browser\n\n", *port)
+	logger.Printf("✓ Server starting on http://localhost:%d", boundPort)
+	fmt.Printf("\n🚀 K8V is running! Open http://localhost:%d in your browser\n\n", boundPort)
 
 	if err := srv.Start(); err != nil {
+		// log.Fatalf skips deferred calls, so the port file is removed first
+		if portFile != "" {
+			app.RemovePortFile(portFile)
+		}
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+// parsePortRange parses a "first-last" port range
+func parsePortRange(value string) (int, int, error) {
+	firstStr, lastStr, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("%q is not a first-last range", value)
+	}
+	first, err := strconv.Atoi(firstStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid first port %q", firstStr)
+	}
+	last, err := strconv.Atoi(lastStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid last port %q", lastStr)
+	}
+	if first < 1 || last > 65535 || first > last {
+		return 0, 0, fmt.Errorf("%q is not a range of ports from 1 to 65535", value)
+	}
+	return first, last, nil
+}
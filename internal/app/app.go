@@ -1,11 +1,19 @@
 package app
 
 import (
+	gocontext "context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/user/k8v/internal/k8s"
 	"github.com/user/k8v/internal/server"
+	"github.com/user/k8v/internal/types"
 )
 
 // Logger interface for logging
@@ -21,75 +29,159 @@ type SyncStatus struct {
 	Context string `json:"context"`
 }
 
+// Options configures optional app behavior, applied on every (re)start
+type Options struct {
+	Cache   k8s.CacheOptions
+	Watcher k8s.WatcherOptions
+
+	// CacheFile is where the resource cache is dumped while running and restored from on
+	// start, with the context name inserted before the extension. Empty disables it.
+	CacheFile string
+	// CacheTTL is the maximum age of a cache file that is still restored
+	CacheTTL time.Duration
+
+	// EventFilters are added to every watcher, see k8s.Watcher.AddEventFilter
+	EventFilters []k8s.EventFilter
+
+	// Namespace restricts the watched resources to one namespace ("" for all), for
+	// namespace-scoped credentials
+	Namespace string
+	// WatchLabelSelector restricts the watched resources to those matching a label
+	// selector, applied by the API server; see k8s.ClientOptions.WatchLabelSelector
+	WatchLabelSelector string
+
+	// ReadOnly skips startup work that modifies the cluster (deleting orphaned debug pods)
+	ReadOnly bool
+}
+
+// orphanedDebugPodAge is the age after which a node debug pod found at startup is
+// assumed to be abandoned by a crashed k8v
+const orphanedDebugPodAge = 10 * time.Minute
+
+// relationshipCheckInterval is how often a synced cache's relationships are validated
+const relationshipCheckInterval = 5 * time.Minute
+
+// relationshipCheckLogLimit is the number of dangling relationships logged per check
+const relationshipCheckLogLimit = 20
+
+// cacheDumpInterval is how often a synced cache is written to Options.CacheFile
+const cacheDumpInterval = time.Minute
+
+// unsafeFileChars matches characters replaced when a context name becomes part of a file name
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
 // App manages the Kubernetes client, watcher, and server lifecycle
 type App struct {
 	logger  Logger
+	options Options
 	hub     *server.Hub
 	logHub  *server.LogHub
 	context string
 
+	switchMu sync.Mutex // held for the duration of SwitchContext
+
 	mu         sync.RWMutex
 	client     *k8s.Client
 	cache      *k8s.ResourceCache
 	watcher    *k8s.Watcher
-	stopCh     chan struct{}
+	stop       gocontext.CancelFunc // stops the informers of the current Start
 	isRunning  bool
 	syncStatus SyncStatus
+	syncDone   chan struct{} // closed when the background sync of the current Start ends
 }
 
 // NewApp creates a new app instance
 func NewApp(logger Logger, hub *server.Hub, logHub *server.LogHub) *App {
+	return NewAppWithOptions(logger, hub, logHub, Options{})
+}
+
+// NewAppWithOptions creates a new app instance with the given options
+func NewAppWithOptions(logger Logger, hub *server.Hub, logHub *server.LogHub, options Options) *App {
 	return &App{
-		logger: logger,
-		hub:    hub,
-		logHub: logHub,
+		logger:  logger,
+		options: options,
+		hub:     hub,
+		logHub:  logHub,
 	}
 }
 
 // Start initializes and starts the Kubernetes client and watcher
-// It returns immediately and syncs informers in the background
+// It returns immediately and syncs informers in the background. The client and cache are
+// prepared without a.mu, as restoring the cache file and cleaning up debug pods can take
+// seconds that GetWatcher callers (every HTTP handler) mustn't wait for.
 func (a *App) Start(context string) error {
-	a.mu.Lock()
-
-	if a.isRunning {
-		a.mu.Unlock()
+	a.mu.RLock()
+	running := a.isRunning
+	a.mu.RUnlock()
+	if running {
 		return fmt.Errorf("app is already running")
 	}
 
 	a.logger.Printf("Connecting to Kubernetes cluster (context: %s)...", context)
 
 	// Create Kubernetes client
-	client, err := k8s.NewClientWithContext(context)
+	client, err := k8s.NewClientWithOptions(context, k8s.ClientOptions{
+		Namespace:          a.options.Namespace,
+		WatchLabelSelector: a.options.WatchLabelSelector,
+	})
 	if err != nil {
-		a.mu.Unlock()
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 	client.SetLogger(a.logger)
 	a.logger.Printf("✓ Connected to Kubernetes cluster")
 
 	// Create resource cache
-	cache := k8s.NewResourceCache()
+	cache := k8s.NewResourceCacheWithOptions(a.options.Cache)
+	// Buffered log history is useless once its Pod is gone
+	cache.Subscribe(k8s.SubscribeAll, func(resource *types.Resource, op k8s.CacheOp) {
+		if op == k8s.CacheOpDelete && resource.Type == "Pod" {
+			a.logHub.DiscardPodBuffers(resource.Namespace, resource.Name)
+		}
+	})
 	a.logger.Printf("✓ Resource cache initialized")
 
+	// Serve the previous run's resources until informers catch up
+	cacheFile := a.cacheFileFor(context)
+	if cacheFile != "" {
+		a.restoreCache(cache, cacheFile)
+	}
+
+	// Remove debug pods left by a crash before their informer events reach the UI
+	if !a.options.ReadOnly {
+		a.cleanupOrphanedDebugPods(client)
+	}
+
+	a.mu.Lock()
+	if a.isRunning {
+		// Another Start finished while this one was preparing
+		a.mu.Unlock()
+		cache.Close()
+		return fmt.Errorf("app is already running")
+	}
+
 	// Create watcher with event handler that broadcasts to hub
-	watcher := k8s.NewWatcher(client, cache, a.hub.Broadcast)
-	err = watcher.Start()
+	watcher := k8s.NewWatcherWithOptions(client, cache, a.hub.Broadcast, a.options.Watcher)
+	for _, filter := range a.options.EventFilters {
+		watcher.AddEventFilter(filter)
+	}
+	watcher.SetWatchErrorHandler(func(resource string, err error) {
+		a.handleWatchError(watcher, resource, err)
+	})
+	ctx, stop := gocontext.WithCancel(gocontext.Background())
+	syncResult, err := watcher.StartAsync(ctx)
 	if err != nil {
+		stop()
+		cache.Close() // Stops its sweep goroutine
 		a.mu.Unlock()
 		return fmt.Errorf("failed to start watcher: %w", err)
 	}
-	a.logger.Printf("✓ Watcher initialized")
-
-	// Start informers
-	stopCh := make(chan struct{})
-	client.Start(stopCh)
-	a.logger.Printf("✓ Informers started")
+	a.logger.Printf("✓ Watcher initialized, informers started")
 
 	// Update app state
 	a.client = client
 	a.cache = cache
 	a.watcher = watcher
-	a.stopCh = stopCh
+	a.stop = stop
 	a.context = context
 	a.isRunning = true
 	a.syncStatus = SyncStatus{
@@ -97,6 +189,8 @@ func (a *App) Start(context string) error {
 		Synced:  false,
 		Context: context,
 	}
+	syncDone := make(chan struct{})
+	a.syncDone = syncDone
 
 	a.mu.Unlock()
 
@@ -111,10 +205,16 @@ func (a *App) Start(context string) error {
 	// Wait for informer caches to sync in background
 	go func() {
 		a.logger.Printf("Starting background sync for informer caches...")
-		synced := client.WaitForCacheSync(stopCh)
+		synced := <-syncResult == nil
+		if synced {
+			// Restored resources not seen during the sync were deleted while we were down.
+			// Pruned outside a.mu: each one is broadcast to the hub as a DELETED event.
+			watcher.PruneRestored()
+		}
 
 		a.mu.Lock()
 		defer a.mu.Unlock()
+		defer close(syncDone) // After the status update, for WaitForSync
 
 		if synced {
 			a.syncStatus = SyncStatus{
@@ -124,6 +224,11 @@ func (a *App) Start(context string) error {
 			}
 			a.logger.Printf("✓ App synced successfully with context: %s", context)
 
+			if cacheFile != "" {
+				go a.dumpCachePeriodically(cache, cacheFile, ctx.Done())
+			}
+			go a.validateRelationshipsPeriodically(watcher, ctx.Done())
+
 			// Broadcast synced state
 			a.hub.BroadcastSyncStatus(k8s.SyncStatusEvent{
 				Type:    k8s.EventSyncStatus,
@@ -155,6 +260,32 @@ func (a *App) Start(context string) error {
 	return nil
 }
 
+// handleWatchError reports a failing watch as syncing ("reconnecting" in the UI) while
+// keeping Synced, and restores the synced state once every watch has recovered
+// Failures during the initial sync are already covered by its syncing state.
+func (a *App) handleWatchError(watcher *k8s.Watcher, resource string, err error) {
+	a.mu.Lock()
+	if a.watcher != watcher || !a.syncStatus.Synced {
+		a.mu.Unlock()
+		return
+	}
+	status := SyncStatus{Synced: true, Context: a.context}
+	if err != nil {
+		status.Syncing = true
+		status.Error = fmt.Sprintf("%s watch failed, reconnecting: %v", resource, err)
+	}
+	a.syncStatus = status
+	a.mu.Unlock()
+
+	a.hub.BroadcastSyncStatus(k8s.SyncStatusEvent{
+		Type:    k8s.EventSyncStatus,
+		Syncing: status.Syncing,
+		Synced:  status.Synced,
+		Error:   status.Error,
+		Context: status.Context,
+	})
+}
+
 // Stop gracefully stops the app
 func (a *App) Stop() {
 	a.mu.Lock()
@@ -165,13 +296,166 @@ func (a *App) Stop() {
 	}
 
 	a.logger.Printf("Stopping app...")
-	close(a.stopCh)
+	if cacheFile := a.cacheFileFor(a.context); cacheFile != "" && a.syncStatus.Synced {
+		a.dumpCache(a.cache, cacheFile)
+	}
+	a.stop()
+	a.cache.Close()
 	a.isRunning = false
 	a.logger.Printf("✓ App stopped")
 }
 
+// AddEventFilter adds a filter to the current watcher and to the watchers of later
+// context switches
+func (a *App) AddEventFilter(filter k8s.EventFilter) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.options.EventFilters = append(a.options.EventFilters, filter)
+	if a.watcher != nil {
+		a.watcher.AddEventFilter(filter)
+	}
+}
+
+// cleanupOrphanedDebugPods deletes node debug pods older than orphanedDebugPodAge left
+// behind by exited k8v processes on this machine
+// Failures are logged and don't prevent startup
+func (a *App) cleanupOrphanedDebugPods(client *k8s.Client) {
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 10*time.Second)
+	defer cancel()
+
+	namespace := k8s.DefaultNodeDebugPodOptions().Namespace
+	if err := client.DeleteOrphanedDebugPods(ctx, namespace, orphanedDebugPodAge); err != nil {
+		a.logger.Printf("Warning: failed to clean up orphaned debug pods: %v", err)
+	}
+}
+
+// cacheFileFor returns the cache file for a context, or "" when cache files are disabled
+// Each context gets its own file so switching never restores another cluster's resources
+func (a *App) cacheFileFor(context string) string {
+	if a.options.CacheFile == "" {
+		return ""
+	}
+	dir, base := filepath.Split(a.options.CacheFile)
+	name, ext := base, ""
+	if i := strings.Index(base, "."); i > 0 {
+		name, ext = base[:i], base[i:]
+	}
+	return filepath.Join(dir, name+"-"+unsafeFileChars.ReplaceAllString(context, "_")+ext)
+}
+
+// WritePortFile records the port an instance listens on in ~/.k8v/<context>.pid, so
+// scripts can find instances started with a dynamic port. The file holds the port on its
+// first line and the instance's PID on the second. It returns the file's path.
+func WritePortFile(context string, port int) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return writePortFile(filepath.Join(home, ".k8v"), context, port)
+}
+
+// writePortFile writes the port file of a context in dir
+func writePortFile(dir, context string, port int) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, unsafeFileChars.ReplaceAllString(context, "_")+".pid")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n%d\n", port, os.Getpid())), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write port file: %w", err)
+	}
+	return path, nil
+}
+
+// RemovePortFile removes a port file written by WritePortFile, unless another instance
+// for the same context has since replaced it with its own
+func RemovePortFile(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || strings.TrimSpace(lines[1]) != strconv.Itoa(os.Getpid()) {
+		return
+	}
+	os.Remove(path)
+}
+
+// restoreCache loads a cache file into the cache if it is younger than the cache TTL
+func (a *App) restoreCache(cache *k8s.ResourceCache, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			a.logger.Printf("Warning: failed to read cache file: %v", err)
+		}
+		return
+	}
+	if age := time.Since(info.ModTime()); age > a.options.CacheTTL {
+		a.logger.Printf("Cache file %s is %s old (ttl %s), not restoring", path, age.Round(time.Second), a.options.CacheTTL)
+		return
+	}
+	if err := cache.Restore(path); err != nil {
+		a.logger.Printf("Warning: failed to restore cache: %v", err)
+		return
+	}
+	a.logger.Printf("✓ Restored %d resources from %s", cache.Count(), path)
+}
+
+// dumpCache writes the cache to a cache file, logging failures
+func (a *App) dumpCache(cache *k8s.ResourceCache, path string) {
+	if err := cache.Dump(path); err != nil {
+		a.logger.Printf("Warning: failed to dump cache: %v", err)
+	}
+}
+
+// dumpCachePeriodically dumps the cache every cacheDumpInterval until done is closed,
+// so a crash loses at most one interval of changes
+func (a *App) dumpCachePeriodically(cache *k8s.ResourceCache, path string, done <-chan struct{}) {
+	ticker := time.NewTicker(cacheDumpInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.dumpCache(cache, path)
+		case <-done:
+			return
+		}
+	}
+}
+
+// validateRelationshipsPeriodically logs dangling relationships every
+// relationshipCheckInterval until done is closed
+func (a *App) validateRelationshipsPeriodically(watcher *k8s.Watcher, done <-chan struct{}) {
+	ticker := time.NewTicker(relationshipCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			errs := watcher.ValidateRelationships()
+			if len(errs) == 0 {
+				continue
+			}
+			a.logger.Printf("Warning: %d relationships point at uncached resources", len(errs))
+			for i, e := range errs {
+				if i == relationshipCheckLogLimit {
+					a.logger.Printf("  ... and %d more (see /api/v1/debug/relationships/validate)", len(errs)-i)
+					break
+				}
+				a.logger.Printf("  %s %s %s: %s", e.SourceID, e.RelType, e.TargetID, e.Reason)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
 // SwitchContext switches to a different Kubernetes context
+// It returns server.ErrContextSwitchInProgress right away while another switch runs.
 func (a *App) SwitchContext(newContext string) error {
+	if !a.switchMu.TryLock() {
+		return server.ErrContextSwitchInProgress
+	}
+	defer a.switchMu.Unlock()
+
 	a.logger.Printf("Switching context from '%s' to '%s'...", a.context, newContext)
 
 	// Broadcast syncing state immediately (clients stay connected)
@@ -186,6 +470,9 @@ func (a *App) SwitchContext(newContext string) error {
 	a.logHub.DisconnectAll()
 	a.logger.Printf("✓ Log clients disconnected")
 
+	// Events from the old context must not be replayed to reconnecting clients
+	a.hub.ResetHistory()
+
 	// Stop current app
 	a.Stop()
 	a.logger.Printf("✓ Previous context stopped")
@@ -221,9 +508,58 @@ func (a *App) GetCurrentContext() string {
 	return a.context
 }
 
+// GetCurrentContextInfo returns the kubeconfig entry of the current context
+// It returns server.ErrContextNotFound if the kubeconfig has no such context.
+func (a *App) GetCurrentContextInfo() (k8s.Context, error) {
+	current := a.GetCurrentContext()
+	contexts, err := k8s.ListContexts()
+	if err != nil {
+		return k8s.Context{}, err
+	}
+	for _, c := range contexts {
+		if c.Name == current {
+			return c, nil
+		}
+	}
+	return k8s.Context{}, server.ErrContextNotFound
+}
+
 // GetSyncStatus returns the current sync status
 func (a *App) GetSyncStatus() interface{} {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 	return a.syncStatus
 }
+
+// WaitForSync blocks until the informer caches of the current context have synced, for
+// callers using k8v as a library. It returns nil once synced, an error if the sync
+// failed or the app isn't started, and ctx.Err() when ctx is done first:
+//
+//	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+//	defer cancel()
+//	if err := app.WaitForSync(ctx); err != nil {
+//		...
+//	}
+func (a *App) WaitForSync(ctx gocontext.Context) error {
+	for {
+		a.mu.RLock()
+		status, done := a.syncStatus, a.syncDone
+		a.mu.RUnlock()
+
+		switch {
+		case status.Synced:
+			return nil
+		case status.Error != "":
+			return fmt.Errorf("sync failed for context %s: %s", status.Context, status.Error)
+		case done == nil:
+			return fmt.Errorf("app is not started")
+		}
+
+		// Re-check after the sync ends: a context switch may have started a new one
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestPortFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		replaceWith string // Contents another instance wrote over the file, "" for none
+		wantRemoved bool
+	}{
+		{"own file", "", true},
+		{"replaced by another instance", fmt.Sprintf("8081\n%d\n", os.Getpid()+1), false},
+		{"replaced by an older format", "8081\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path, err := writePortFile(t.TempDir(), "kind/dev", 8080)
+			if err != nil {
+				t.Fatalf("writePortFile() error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading the port file: %v", err)
+			}
+			if want := fmt.Sprintf("8080\n%d\n", os.Getpid()); string(data) != want {
+				t.Errorf("port file = %q, want %q", data, want)
+			}
+			if tt.replaceWith != "" {
+				if err := os.WriteFile(path, []byte(tt.replaceWith), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			RemovePortFile(path)
+			if _, err := os.Stat(path); os.IsNotExist(err) != tt.wantRemoved {
+				t.Errorf("port file removed = %v, want %v", os.IsNotExist(err), tt.wantRemoved)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Result values recorded for audited operations
+const (
+	ResultAllowed = "allowed"
+	ResultDenied  = "denied"
+)
+
+// ResultFor returns the result to record for an operation that returned err: a
+// refused or failed operation is recorded as denied
+func ResultFor(err error) string {
+	if err != nil {
+		return ResultDenied
+	}
+	return ResultAllowed
+}
+
+// maxRecentRecords is the number of records kept in memory for the audit API
+const maxRecentRecords = 100
+
+// ResourceInfo identifies the Kubernetes resource an operation targeted
+type ResourceInfo struct {
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Record is a single audit log entry (one JSON line in the audit log file)
+type Record struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Operation string       `json:"operation"` // e.g. "exec.pod", "exec.node", "delete.pod"
+	Actor     string       `json:"actor"`     // IP address of the requesting client
+	Resource  ResourceInfo `json:"resource"`
+	Result    string       `json:"result"`              // "allowed" or "denied"
+	SessionID string       `json:"sessionId,omitempty"` // Set for exec sessions
+	Command   []string     `json:"command,omitempty"`   // Set for pod exec sessions with a ?cmd override
+}
+
+// AuditLogger writes audit records as JSON lines to a dedicated file and keeps
+// the most recent records in memory. A nil *AuditLogger is valid and discards
+// all records, so callers don't need to check whether auditing is enabled.
+type AuditLogger struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	recent  []Record // ring buffer of the last maxRecentRecords records
+	next    int      // next write position in recent
+}
+
+// NewAuditLogger creates an audit logger appending to the file at path
+// (typically logs/audit.log). Unlike the server log, the audit log is never truncated.
+func NewAuditLogger(path string) (*AuditLogger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log file: %w", err)
+	}
+
+	return &AuditLogger{
+		file:    file,
+		encoder: json.NewEncoder(file),
+		recent:  make([]Record, 0, maxRecentRecords),
+	}, nil
+}
+
+// Log records an audit event. The timestamp is filled in if not set.
+func (a *AuditLogger) Log(record Record) {
+	if a == nil {
+		return
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now().UTC()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Encoder appends a newline after each record, producing JSON lines
+	a.encoder.Encode(record)
+
+	if len(a.recent) < maxRecentRecords {
+		a.recent = append(a.recent, record)
+	} else {
+		a.recent[a.next] = record
+	}
+	a.next = (a.next + 1) % maxRecentRecords
+}
+
+// Recent returns the most recent audit records, oldest first
+func (a *AuditLogger) Recent() []Record {
+	if a == nil {
+		return []Record{}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	records := make([]Record, 0, len(a.recent))
+	if len(a.recent) < maxRecentRecords {
+		return append(records, a.recent...)
+	}
+	records = append(records, a.recent[a.next:]...)
+	return append(records, a.recent[:a.next]...)
+}
+
+// Close closes the audit log file
+func (a *AuditLogger) Close() error {
+	if a == nil || a.file == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// ActorFromRequest returns the client IP address of an HTTP request
+func ActorFromRequest(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
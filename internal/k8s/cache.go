@@ -1,45 +1,536 @@
 package k8s
 
 import (
+	"container/list"
+	"context"
+	"log"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/user/k8v/internal/metrics"
 	"github.com/user/k8v/internal/types"
 )
 
+// cacheEvictions counts resources evicted because the cache reached MaxResources
+var cacheEvictions = metrics.NewCounter("k8v_cache_evictions_total", "Resources evicted from the cache because it reached its size limit")
+
+// cacheStaleUpdates counts Set calls ignored because the cached resource is newer
+var cacheStaleUpdates = metrics.NewCounter("k8v_cache_stale_updates_total", "Cache updates skipped because they carried an older resourceVersion than the cached resource")
+
+// cacheWatchDropped counts changes a Watch channel missed because it was full
+var cacheWatchDropped = metrics.NewCounter("k8v_cache_watch_dropped_total", "Cache changes dropped because a ResourceCache.Watch consumer fell behind")
+
+// cacheWatchBuffer is the channel capacity of ResourceCache.Watch
+const cacheWatchBuffer = 256
+
+// cacheWarnThreshold is the fill ratio at which a size warning is logged
+const cacheWarnThreshold = 0.8
+
+// cacheSweepInterval is how often resources stored with SetWithTTL are checked for expiry
+const cacheSweepInterval = 30 * time.Second
+
+// CacheOptions configures optional cache behavior
+type CacheOptions struct {
+	// MaxResources bounds the number of cached resources, evicting the least recently
+	// accessed one when full. 0 means unlimited.
+	MaxResources int
+
+	// IndexRelationships maintains a reverse ownership index (owner ID -> owned IDs), so
+	// ListByOwner and Owns lookups don't scan the whole cache, at the cost of memory
+	IndexRelationships bool
+}
+
+// CacheOp identifies the kind of cache mutation reported to subscribers
+type CacheOp string
+
+const (
+	CacheOpSet    CacheOp = "SET"
+	CacheOpDelete CacheOp = "DELETE" // Also reported for LRU evictions
+)
+
+// SubscribeAll is the resource ID filter matching every resource
+const SubscribeAll = "*"
+
+// CacheChangeHandler is invoked after a resource is stored in or removed from the cache
+type CacheChangeHandler func(resource *types.Resource, op CacheOp)
+
+// SubscriptionID identifies a cache subscription for Unsubscribe
+type SubscriptionID uint64
+
+// CacheChangeEvent is a cache mutation delivered by Watch
+type CacheChangeEvent struct {
+	Op          CacheOp
+	OldResource *types.Resource // nil when a resource is added
+	NewResource *types.Resource // nil for CacheOpDelete
+}
+
+// cacheSubscription is a registered handler and the resource ID it is filtered to
+// Watch subscriptions set watch instead of handler.
+type cacheSubscription struct {
+	resourceID string
+	handler    CacheChangeHandler
+	watch      func(event CacheChangeEvent)
+}
+
 // ResourceCache maintains an in-memory cache of all Kubernetes resources
 // with thread-safe access for concurrent read/write operations
 type ResourceCache struct {
 	mu        sync.RWMutex
 	resources map[string]*types.Resource // ID -> Resource
+
+	// generation is incremented on every Set; generations records the generation at
+	// which each resource was last set, so callers can compute deltas with ChangedSince
+	generation  atomic.Int64
+	generations map[string]int64 // ID -> generation
+
+	// LRU bookkeeping, only used when maxResources > 0
+	maxResources int
+	lru          *list.List               // front = most recently accessed, values are IDs
+	elements     map[string]*list.Element // ID -> element in lru
+	warned       bool                     // whether the 80% warning has been logged
+
+	subscriptions      map[SubscriptionID]cacheSubscription
+	nextSubscriptionID SubscriptionID
+
+	// restored holds the IDs loaded by Restore that no informer has confirmed yet
+	restored map[string]bool
+
+	// annotations indexes resource IDs by annotation, for ListByAnnotation. Keys and values
+	// share the resources' strings rather than copying them into a "key=value" string.
+	annotations map[string]map[string]map[string]bool // key -> value -> IDs
+	// owners indexes OwnedBy in reverse, nil unless CacheOptions.IndexRelationships
+	owners map[string]map[string]bool // owner ID -> IDs
+
+	// expiresAt holds the expiry of resources stored with SetWithTTL
+	expiresAt map[string]time.Time // ID -> expiry
+	stopSweep chan struct{}
+	closeOnce sync.Once
+
+	// Counters reported by Metrics, since creation or the last ResetMetrics
+	hits    atomic.Int64
+	misses  atomic.Int64
+	sets    atomic.Int64
+	deletes atomic.Int64
+}
+
+// CacheMetrics are cache statistics, for diagnosing cache pressure without a metrics scrape
+type CacheMetrics struct {
+	TotalResources int            `json:"totalResources"`
+	ByType         map[string]int `json:"byType"`
+	HitCount       int64          `json:"hitCount"`    // Get calls finding the resource
+	MissCount      int64          `json:"missCount"`   // Get calls not finding it
+	SetCount       int64          `json:"setCount"`    // Resources stored, stale updates excluded
+	DeleteCount    int64          `json:"deleteCount"` // Resources deleted, evictions excluded
+	HitRate        float64        `json:"hitRate"`     // HitCount / (HitCount + MissCount), 0 without Get calls
 }
 
 // NewResourceCache creates a new empty resource cache
 func NewResourceCache() *ResourceCache {
-	return &ResourceCache{
-		resources: make(map[string]*types.Resource),
+	return NewResourceCacheWithOptions(CacheOptions{})
+}
+
+// NewResourceCacheWithOptions creates a new empty resource cache with the given options
+func NewResourceCacheWithOptions(options CacheOptions) *ResourceCache {
+	c := &ResourceCache{
+		resources:     make(map[string]*types.Resource),
+		generations:   make(map[string]int64),
+		maxResources:  options.MaxResources,
+		subscriptions: make(map[SubscriptionID]cacheSubscription),
+		restored:      make(map[string]bool),
+		annotations:   make(map[string]map[string]map[string]bool),
+		expiresAt:     make(map[string]time.Time),
+		stopSweep:     make(chan struct{}),
+	}
+	if options.IndexRelationships {
+		c.owners = make(map[string]map[string]bool)
 	}
+	if c.maxResources > 0 {
+		c.lru = list.New()
+		c.elements = make(map[string]*list.Element)
+	}
+	go c.sweepExpired()
+	return c
+}
+
+// Close stops the expiry sweep. The cache stays usable, but SetWithTTL entries no longer expire.
+func (c *ResourceCache) Close() {
+	c.closeOnce.Do(func() { close(c.stopSweep) })
 }
 
-// Get retrieves a resource by ID
+// Get retrieves a copy of a resource by ID
+// Modifying the copy doesn't affect the cache; Set it to store the changes
 func (c *ResourceCache) Get(id string) (*types.Resource, bool) {
+	if c.lru != nil {
+		// Recording the access mutates the LRU list, so a write lock is needed
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		r, ok := c.resources[id]
+		if !ok {
+			c.misses.Add(1)
+			return nil, false
+		}
+		c.hits.Add(1)
+		c.lru.MoveToFront(c.elements[id])
+		return r.Clone(), true
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	r, ok := c.resources[id]
-	return r, ok
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return r.Clone(), true
+}
+
+// Contains reports whether a resource is cached, without copying it
+// Unlike Get it doesn't count as an access for LRU eviction.
+func (c *ResourceCache) Contains(id string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.resources[id]
+	return ok
+}
+
+// update stores a modified copy of a cached resource under a single write lock, so
+// concurrent updates of the same resource aren't lost. fn returns false to leave it as is.
+// Unlike Set it doesn't confirm a restored resource or refresh a SetWithTTL expiry, as
+// relationship updates (its only use) say nothing about whether the resource still exists.
+func (c *ResourceCache) update(id string, fn func(r *types.Resource) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.resources[id]
+	if !ok {
+		return
+	}
+	clone := r.Clone()
+	if !fn(clone) {
+		return
+	}
+	restored := c.restored[id]
+	expiresAt, expires := c.expiresAt[id]
+	c.setLocked(clone)
+	if restored {
+		c.restored[id] = true
+	}
+	if expires {
+		c.expiresAt[id] = expiresAt
+	}
+}
+
+// Set stores or updates a resource in the cache, reporting whether it was stored
+// Updates older than the cached resource (by ResourceVersion) are skipped.
+// When the cache is bounded and full, the least recently accessed resource is evicted
+func (c *ResourceCache) Set(r *types.Resource) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.setLocked(r)
+}
+
+// SetWithTTL stores or updates a resource like Set, deleting it once ttl elapses unless
+// it's stored again first. Deletion is reported to subscribers like any other, within
+// cacheSweepInterval of expiry.
+func (c *ResourceCache) SetWithTTL(r *types.Resource, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.setLocked(r) {
+		c.expiresAt[r.ID] = time.Now().Add(ttl)
+	}
+}
+
+// sweepExpired deletes expired SetWithTTL resources every cacheSweepInterval until Close
+func (c *ResourceCache) sweepExpired() {
+	ticker := time.NewTicker(cacheSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopSweep:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for id, expiresAt := range c.expiresAt {
+				if now.After(expiresAt) {
+					c.deleteLocked(id)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
 }
 
-// Set stores or updates a resource in the cache
-func (c *ResourceCache) Set(r *types.Resource) {
+// BulkSet stores or updates many resources under a single write lock, with the same
+// semantics as calling Set for each one. Used for the initial load, where thousands of
+// resources arrive at once.
+func (c *ResourceCache) BulkSet(resources []*types.Resource) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	for _, r := range resources {
+		c.setLocked(r)
+	}
+}
+
+// setLocked stores a resource, reporting whether it was stored (false for a stale update)
+// Callers must hold the write lock
+func (c *ResourceCache) setLocked(r *types.Resource) bool {
+	existing, ok := c.resources[r.ID]
+	// The resource still exists, so it must survive PruneRestored (relationship updates
+	// go through update and don't count). Restore's copy is replaced whatever its
+	// ResourceVersion: a cluster recreated under the same context starts versions over.
+	restored := c.restored[r.ID]
+	delete(c.restored, r.ID)
+	if ok && !restored && isOlderVersion(r.ResourceVersion, existing.ResourceVersion) {
+		cacheStaleUpdates.Inc()
+		return false
+	}
+	if ok {
+		c.unindexLocked(existing)
+	}
 	c.resources[r.ID] = r
+	c.sets.Add(1)
+	delete(c.expiresAt, r.ID) // SetWithTTL sets it again
+	c.indexLocked(r)
+	c.generations[r.ID] = c.generation.Add(1)
+	c.notifyLocked(existing, r, CacheOpSet)
+
+	if c.lru == nil {
+		return true
+	}
+	if elem, ok := c.elements[r.ID]; ok {
+		c.lru.MoveToFront(elem)
+		return true
+	}
+	c.elements[r.ID] = c.lru.PushFront(r.ID)
+
+	for len(c.resources) > c.maxResources {
+		oldest := c.lru.Back()
+		id := oldest.Value.(string)
+		evicted := c.resources[id]
+		c.lru.Remove(oldest)
+		delete(c.elements, id)
+		delete(c.resources, id)
+		delete(c.generations, id)
+		delete(c.restored, id)
+		delete(c.expiresAt, id)
+		c.unindexLocked(evicted)
+		cacheEvictions.Inc()
+		c.notifyLocked(evicted, nil, CacheOpDelete)
+	}
+
+	c.checkSizeLocked()
+	return true
 }
 
 // Delete removes a resource from the cache by ID
 func (c *ResourceCache) Delete(id string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.deleteLocked(id)
+}
+
+// deleteLocked removes a resource by ID
+// Callers must hold the write lock
+func (c *ResourceCache) deleteLocked(id string) {
+	r, ok := c.resources[id]
 	delete(c.resources, id)
+	delete(c.generations, id)
+	delete(c.restored, id)
+	delete(c.expiresAt, id)
+	if ok {
+		c.deletes.Add(1)
+		c.unindexLocked(r)
+		c.notifyLocked(r, nil, CacheOpDelete)
+	}
+
+	if c.lru == nil {
+		return
+	}
+	if elem, ok := c.elements[id]; ok {
+		c.lru.Remove(elem)
+		delete(c.elements, id)
+	}
+	c.checkSizeLocked()
+}
+
+// maxIndexedAnnotationValue is the length above which annotation values aren't indexed
+// Long values are serialized documents (kubectl's last-applied-configuration) that
+// nobody looks up, and hashing them on every update isn't worth it.
+const maxIndexedAnnotationValue = 1024
+
+// indexLocked adds a stored resource to the annotation and owner indexes
+// Callers must hold the write lock
+func (c *ResourceCache) indexLocked(r *types.Resource) {
+	for key, value := range r.Annotations {
+		if len(value) > maxIndexedAnnotationValue {
+			continue
+		}
+		values, ok := c.annotations[key]
+		if !ok {
+			values = make(map[string]map[string]bool)
+			c.annotations[key] = values
+		}
+		addToIndex(values, value, r.ID)
+	}
+	if c.owners != nil {
+		for _, owner := range r.Relationships.OwnedBy {
+			addToIndex(c.owners, owner.ID, r.ID)
+		}
+	}
+}
+
+// unindexLocked removes a resource being replaced or removed from the indexes
+// Callers must hold the write lock
+func (c *ResourceCache) unindexLocked(r *types.Resource) {
+	for key, value := range r.Annotations {
+		if values, ok := c.annotations[key]; ok {
+			removeFromIndex(values, value, r.ID)
+			if len(values) == 0 {
+				delete(c.annotations, key)
+			}
+		}
+	}
+	if c.owners != nil {
+		for _, owner := range r.Relationships.OwnedBy {
+			removeFromIndex(c.owners, owner.ID, r.ID)
+		}
+	}
+}
+
+func addToIndex(index map[string]map[string]bool, key, id string) {
+	ids, ok := index[key]
+	if !ok {
+		ids = make(map[string]bool)
+		index[key] = ids
+	}
+	ids[id] = true
+}
+
+func removeFromIndex(index map[string]map[string]bool, key, id string) {
+	delete(index[key], id)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+// isOlderVersion reports whether resourceVersion a precedes b
+// Kubernetes defines resourceVersions as opaque, but etcd-backed API servers use increasing
+// integers, so they are compared numerically; "9" < "10" even though it sorts after it.
+// Versions that aren't integers (or are missing) are never considered older.
+func isOlderVersion(a, b string) bool {
+	va, errA := strconv.ParseUint(a, 10, 64)
+	vb, errB := strconv.ParseUint(b, 10, 64)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return va < vb
+}
+
+// GetGeneration returns the current cache generation (the number of Set calls so far)
+func (c *ResourceCache) GetGeneration() int64 {
+	return c.generation.Load()
+}
+
+// ChangedSince returns resources set after the given generation. Deleted resources are
+// not reported, so callers computing deltas must handle deletions separately.
+// Generations are int64 and won't overflow in practice (2^63 events).
+func (c *ResourceCache) ChangedSince(generation int64) []*types.Resource {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	resources := []*types.Resource{}
+	for id, gen := range c.generations {
+		if gen > generation {
+			resources = append(resources, c.resources[id])
+		}
+	}
+	return resources
+}
+
+// Subscribe registers a handler invoked synchronously after every Set or Delete of the
+// resource with the given ID, or of any resource when id is SubscribeAll ("*").
+// Handlers run while the cache write lock is held: they must be fast and must not
+// call any ResourceCache method (Set and Delete in particular), or they will deadlock.
+func (c *ResourceCache) Subscribe(id string, handler CacheChangeHandler) SubscriptionID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextSubscriptionID++
+	c.subscriptions[c.nextSubscriptionID] = cacheSubscription{resourceID: id, handler: handler}
+	return c.nextSubscriptionID
+}
+
+// Unsubscribe removes a subscription registered with Subscribe
+func (c *ResourceCache) Unsubscribe(id SubscriptionID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.subscriptions, id)
+}
+
+// notifyLocked invokes the subscriptions matching a change from old to r; old is nil for
+// added resources and r nil for deleted ones
+// Callers must hold the write lock
+func (c *ResourceCache) notifyLocked(old, r *types.Resource, op CacheOp) {
+	current := r
+	if current == nil {
+		current = old
+	}
+	for _, sub := range c.subscriptions {
+		if sub.resourceID != SubscribeAll && sub.resourceID != current.ID {
+			continue
+		}
+		if sub.watch != nil {
+			sub.watch(CacheChangeEvent{Op: op, OldResource: old, NewResource: r})
+		} else {
+			sub.handler(current, op)
+		}
+	}
+}
+
+// Watch streams every cache change for which pred (nil matches all) holds for the old or
+// the new resource, until ctx is done; the channel is then closed. pred runs under the
+// cache lock and must be fast. Changes are dropped, and counted by
+// k8v_cache_watch_dropped_total, while the channel is full, so callers must drain it
+// promptly. The resources are the cached ones and must not be modified.
+func (c *ResourceCache) Watch(ctx context.Context, pred func(r *types.Resource) bool) <-chan CacheChangeEvent {
+	events := make(chan CacheChangeEvent, cacheWatchBuffer)
+	matches := func(r *types.Resource) bool {
+		return r != nil && (pred == nil || pred(r))
+	}
+
+	c.mu.Lock()
+	c.nextSubscriptionID++
+	id := c.nextSubscriptionID
+	c.subscriptions[id] = cacheSubscription{resourceID: SubscribeAll, watch: func(event CacheChangeEvent) {
+		if !matches(event.OldResource) && !matches(event.NewResource) {
+			return
+		}
+		select {
+		case events <- event:
+		default:
+			cacheWatchDropped.Inc()
+		}
+	}}
+	c.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		// Once Unsubscribe returns the handler can't run again, so closing is safe
+		c.Unsubscribe(id)
+		close(events)
+	}()
+	return events
+}
+
+// checkSizeLocked logs a warning once when the cache fills past the warning threshold
+// Callers must hold the write lock
+func (c *ResourceCache) checkSizeLocked() {
+	aboveThreshold := float64(len(c.resources)) >= cacheWarnThreshold*float64(c.maxResources)
+	if aboveThreshold && !c.warned {
+		log.Printf("Warning: resource cache holds %d of %d resources (max), least recently used resources will be evicted", len(c.resources), c.maxResources)
+	}
+	c.warned = aboveThreshold
 }
 
 // List returns all resources in the cache
@@ -54,13 +545,48 @@ func (c *ResourceCache) List() []*types.Resource {
 	return resources
 }
 
-// ListByType returns all resources of a specific type
-func (c *ResourceCache) ListByType(resourceType string) []*types.Resource {
+// CacheSnapshot is a copy of the cache's resource map taken at one instant by Snapshot
+// Its methods need no locking and later cache changes never show through, so every read
+// of a snapshot sees the same state. Like List, it shares resources with the cache and
+// they must not be modified.
+type CacheSnapshot struct {
+	resources map[string]*types.Resource
+}
+
+// Snapshot copies the resource map under the read lock
+func (c *ResourceCache) Snapshot() CacheSnapshot {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	resources := make(map[string]*types.Resource, len(c.resources))
+	for id, r := range c.resources {
+		resources[id] = r
+	}
+	return CacheSnapshot{resources: resources}
+}
+
+// Get returns a copy of a resource in the snapshot, like ResourceCache.Get
+func (s CacheSnapshot) Get(id string) (*types.Resource, bool) {
+	r, ok := s.resources[id]
+	if !ok {
+		return nil, false
+	}
+	return r.Clone(), true
+}
+
+// List returns all resources in the snapshot
+func (s CacheSnapshot) List() []*types.Resource {
+	resources := make([]*types.Resource, 0, len(s.resources))
+	for _, r := range s.resources {
+		resources = append(resources, r)
+	}
+	return resources
+}
+
+// ListByType returns the resources of a type in the snapshot
+func (s CacheSnapshot) ListByType(resourceType string) []*types.Resource {
 	resources := []*types.Resource{}
-	for _, r := range c.resources {
+	for _, r := range s.resources {
 		if r.Type == resourceType {
 			resources = append(resources, r)
 		}
@@ -68,23 +594,147 @@ func (c *ResourceCache) ListByType(resourceType string) []*types.Resource {
 	return resources
 }
 
-// ListByNamespace returns all resources in a specific namespace
-func (c *ResourceCache) ListByNamespace(namespace string) []*types.Resource {
+// ListByNamespace returns the resources in a namespace in the snapshot ("" for the
+// cluster-scoped ones)
+func (s CacheSnapshot) ListByNamespace(namespace string) []*types.Resource {
+	resources := []*types.Resource{}
+	for _, r := range s.resources {
+		if r.Namespace == namespace {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// ListWithFilter returns the resources for which pred returns true
+// pred runs under the cache read lock and must not call cache methods.
+func (c *ResourceCache) ListWithFilter(pred func(r *types.Resource) bool) []*types.Resource {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	resources := []*types.Resource{}
 	for _, r := range c.resources {
-		if r.Namespace == namespace {
+		if pred(r) {
 			resources = append(resources, r)
 		}
 	}
 	return resources
 }
 
+// ListByType returns all resources of a specific type
+func (c *ResourceCache) ListByType(resourceType string) []*types.Resource {
+	return c.ListWithFilter(func(r *types.Resource) bool {
+		return r.Type == resourceType
+	})
+}
+
+// ListByMultipleTypes returns the resources of several types in one pass under a single
+// read lock, keyed by type. Every requested type has an entry, empty if none are cached.
+func (c *ResourceCache) ListByMultipleTypes(resourceTypes ...string) map[string][]*types.Resource {
+	byType := make(map[string][]*types.Resource, len(resourceTypes))
+	for _, t := range resourceTypes {
+		byType[t] = []*types.Resource{}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, r := range c.resources {
+		if resources, ok := byType[r.Type]; ok {
+			byType[r.Type] = append(resources, r)
+		}
+	}
+	return byType
+}
+
+// ListByAnnotation returns all resources carrying an annotation with the given value
+// Values longer than maxIndexedAnnotationValue are looked up by scanning the cache.
+func (c *ResourceCache) ListByAnnotation(key, value string) []*types.Resource {
+	if len(value) > maxIndexedAnnotationValue {
+		return c.ListWithFilter(func(r *types.Resource) bool {
+			v, ok := r.Annotations[key]
+			return ok && v == value
+		})
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := c.annotations[key][value]
+	resources := make([]*types.Resource, 0, len(ids))
+	for id := range ids {
+		resources = append(resources, c.resources[id])
+	}
+	return resources
+}
+
+// IndexesOwners reports whether the cache keeps the reverse ownership index
+func (c *ResourceCache) IndexesOwners() bool {
+	return c.owners != nil
+}
+
+// ListByOwner returns the resources whose OwnedBy includes ownerID, using the ownership
+// index when CacheOptions.IndexRelationships is set and scanning the cache otherwise
+func (c *ResourceCache) ListByOwner(ownerID string) []*types.Resource {
+	if c.owners == nil {
+		return c.ListWithFilter(func(r *types.Resource) bool {
+			return containsRef(r.Relationships.OwnedBy, types.ResourceRef{ID: ownerID})
+		})
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := c.owners[ownerID]
+	resources := make([]*types.Resource, 0, len(ids))
+	for id := range ids {
+		resources = append(resources, c.resources[id])
+	}
+	return resources
+}
+
+// ListByNamespace returns all resources in a specific namespace
+func (c *ResourceCache) ListByNamespace(namespace string) []*types.Resource {
+	return c.ListWithFilter(func(r *types.Resource) bool {
+		return r.Namespace == namespace
+	})
+}
+
 // Count returns the total number of resources in the cache
 func (c *ResourceCache) Count() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return len(c.resources)
 }
+
+// Metrics returns the cache's size per type and its Get, Set and Delete counters
+func (c *ResourceCache) Metrics() CacheMetrics {
+	c.mu.RLock()
+	byType := make(map[string]int)
+	for _, r := range c.resources {
+		byType[r.Type]++
+	}
+	total := len(c.resources)
+	c.mu.RUnlock()
+
+	metrics := CacheMetrics{
+		TotalResources: total,
+		ByType:         byType,
+		HitCount:       c.hits.Load(),
+		MissCount:      c.misses.Load(),
+		SetCount:       c.sets.Load(),
+		DeleteCount:    c.deletes.Load(),
+	}
+	if lookups := metrics.HitCount + metrics.MissCount; lookups > 0 {
+		metrics.HitRate = float64(metrics.HitCount) / float64(lookups)
+	}
+	return metrics
+}
+
+// ResetMetrics zeroes the counters reported by Metrics
+func (c *ResourceCache) ResetMetrics() {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.sets.Store(0)
+	c.deletes.Store(0)
+}
@@ -0,0 +1,175 @@
+package k8s
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// benchmarkCacheSize is the number of resources in the benchmarked caches
+const benchmarkCacheSize = 10000
+
+// benchmarkMixedResources returns n resources cycling through four types over 10 namespaces
+func benchmarkMixedResources(n int) []*types.Resource {
+	resourceTypes := []string{"Pod", "ReplicaSet", "Service", "ConfigMap"}
+	resources := make([]*types.Resource, n)
+	for i := range resources {
+		resources[i] = testResource(resourceTypes[i%len(resourceTypes)], fmt.Sprintf("ns-%d", i%10), fmt.Sprintf("res-%d", i), "1")
+	}
+	return resources
+}
+
+// newBenchmarkCache returns a cache holding resources, closed when the benchmark ends
+func newBenchmarkCache(b *testing.B, resources []*types.Resource) *ResourceCache {
+	b.Helper()
+	cache := NewResourceCache()
+	b.Cleanup(cache.Close)
+	cache.BulkSet(resources)
+	return cache
+}
+
+func BenchmarkCacheSet(b *testing.B) {
+	resources := benchmarkMixedResources(benchmarkCacheSize)
+	cache := newBenchmarkCache(b, resources)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Set(resources[i%len(resources)]) // Updates of cached resources, as informers send
+	}
+}
+
+func BenchmarkCacheGet(b *testing.B) {
+	resources := benchmarkMixedResources(benchmarkCacheSize)
+	cache := newBenchmarkCache(b, resources)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Get(resources[i%len(resources)].ID)
+	}
+}
+
+func BenchmarkCacheList(b *testing.B) {
+	cache := newBenchmarkCache(b, benchmarkMixedResources(benchmarkCacheSize))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.List()
+	}
+}
+
+func BenchmarkCacheListByType(b *testing.B) {
+	cache := newBenchmarkCache(b, benchmarkMixedResources(benchmarkCacheSize))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.ListByType("Service") // A quarter of the cache
+	}
+}
+
+// BenchmarkCacheConcurrentReadWrite runs 8 goroutines against one cache, each doing one
+// Set for every nine Gets, ListByTypes and ListByNamespaces. ns/op is per operation.
+func BenchmarkCacheConcurrentReadWrite(b *testing.B) {
+	const goroutines = 8
+	resources := benchmarkMixedResources(benchmarkCacheSize)
+	cache := newBenchmarkCache(b, resources)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < b.N; i += goroutines {
+				resource := resources[i%len(resources)]
+				switch i % 10 {
+				case 0:
+					cache.Set(resource)
+				case 1:
+					cache.ListByType(resource.Type)
+				case 2:
+					cache.ListByNamespace(resource.Namespace)
+				default:
+					cache.Get(resource.ID)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// BenchmarkFindExposedPods matches a Service selecting 50 labels against 500 cached Pods,
+// half of which carry every selected label
+func BenchmarkFindExposedPods(b *testing.B) {
+	const pods, selectorLabels = 500, 50
+	selector := make(map[string]string, selectorLabels)
+	for i := 0; i < selectorLabels; i++ {
+		selector[fmt.Sprintf("label-%d", i)] = "web"
+	}
+	resources := make([]*types.Resource, pods)
+	for i := range resources {
+		labels := make(map[string]string, selectorLabels)
+		for key, value := range selector {
+			labels[key] = value
+		}
+		if i%2 == 1 {
+			labels["label-49"] = "db"
+		}
+		resources[i] = testResource("Pod", "default", fmt.Sprintf("pod-%d", i), "1")
+		resources[i].Labels = labels
+	}
+	cache := newBenchmarkCache(b, resources)
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       v1.ServiceSpec{Selector: selector},
+	}
+	if exposed := FindExposedPods(service, cache); len(exposed) != pods/2 {
+		b.Fatalf("FindExposedPods() found %d Pods, want %d", len(exposed), pods/2)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FindExposedPods(service, cache)
+	}
+}
+
+// BenchmarkListByOwner looks up the 100 children of one owner in a 10,000-resource cache,
+// with and without CacheOptions.IndexRelationships
+func BenchmarkListByOwner(b *testing.B) {
+	ownerID := types.BuildID("ReplicaSet", "ns-0", "web-abc")
+	resources := benchmarkMixedResources(benchmarkCacheSize)
+	for i := 0; i < 100; i++ {
+		resources[i].Relationships.OwnedBy = []types.ResourceRef{types.NewResourceRef("ReplicaSet", "ns-0", "web-abc")}
+	}
+
+	for _, indexed := range []bool{false, true} {
+		name := "Scan"
+		if indexed {
+			name = "Indexed"
+		}
+		b.Run(name, func(b *testing.B) {
+			cache := NewResourceCacheWithOptions(CacheOptions{IndexRelationships: indexed})
+			b.Cleanup(cache.Close)
+			cache.BulkSet(resources)
+			if got := len(cache.ListByOwner(ownerID)); got != 100 {
+				b.Fatalf("ListByOwner() returned %d resources, want 100", got)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cache.ListByOwner(ownerID)
+			}
+		})
+	}
+}
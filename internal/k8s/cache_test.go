@@ -0,0 +1,182 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/user/k8v/internal/types"
+)
+
+func testResource(resourceType, namespace, name, resourceVersion string) *types.Resource {
+	return &types.Resource{
+		ID:              types.BuildID(resourceType, namespace, name),
+		Type:            resourceType,
+		Namespace:       namespace,
+		Name:            name,
+		ResourceVersion: resourceVersion,
+	}
+}
+
+func TestResourceCacheSetSkipsStaleUpdates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		cached     string // ResourceVersion already cached ("" = not cached)
+		update     string
+		wantStored bool
+	}{
+		{"new resource", "", "5", true},
+		{"newer version", "5", "6", true},
+		{"same version", "5", "5", true},
+		{"older version", "5", "4", false},
+		{"unparsable version", "5", "abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cache := NewResourceCache()
+			defer cache.Close()
+			if tt.cached != "" {
+				cache.Set(testResource("Pod", "default", "web", tt.cached))
+			}
+
+			stored := cache.Set(testResource("Pod", "default", "web", tt.update))
+			if stored != tt.wantStored {
+				t.Errorf("Set() = %v, want %v", stored, tt.wantStored)
+			}
+			want := tt.cached
+			if tt.wantStored {
+				want = tt.update
+			}
+			if got, _ := cache.Get("Pod:default:web"); got.ResourceVersion != want {
+				t.Errorf("cached ResourceVersion = %q, want %q", got.ResourceVersion, want)
+			}
+		})
+	}
+}
+
+// benchmarkResources returns n Pods spread over 10 namespaces
+func benchmarkResources(n int) []*types.Resource {
+	resources := make([]*types.Resource, n)
+	for i := range resources {
+		resources[i] = testResource("Pod", fmt.Sprintf("ns-%d", i%10), fmt.Sprintf("pod-%d", i), "1")
+	}
+	return resources
+}
+
+// BenchmarkBulkSet compares storing an initial load with BulkSet against one Set per resource
+func BenchmarkBulkSet(b *testing.B) {
+	resources := benchmarkResources(10000)
+
+	b.Run("Set", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cache := NewResourceCache()
+			for _, r := range resources {
+				cache.Set(r)
+			}
+			cache.Close()
+		}
+	})
+	b.Run("BulkSet", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cache := NewResourceCache()
+			for start := 0; start < len(resources); start += initialLoadBatchSize {
+				cache.BulkSet(resources[start:min(start+initialLoadBatchSize, len(resources))])
+			}
+			cache.Close()
+		}
+	})
+}
+
+func TestResourceCacheListByAnnotation(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", maxIndexedAnnotationValue+1)
+	cache := NewResourceCache()
+	defer cache.Close()
+	scraped := testResource("Service", "default", "web", "1")
+	scraped.Annotations = map[string]string{"prometheus.io/scrape": "true", "last-applied": long}
+	other := testResource("Service", "default", "db", "1")
+	other.Annotations = map[string]string{"prometheus.io/scrape": "false"}
+	cache.Set(scraped)
+	cache.Set(other)
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  []string
+	}{
+		{"indexed value", "prometheus.io/scrape", "true", []string{"Service:default:web"}},
+		{"other value", "prometheus.io/scrape", "false", []string{"Service:default:db"}},
+		{"unknown value", "prometheus.io/scrape", "maybe", nil},
+		{"unknown key", "team", "true", nil},
+		{"value too long to index", "last-applied", long, []string{"Service:default:web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := cache.ListByAnnotation(tt.key, tt.value)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListByAnnotation(%q, %q) returned %d resources, want %v", tt.key, tt.value, len(got), tt.want)
+			}
+			for i, r := range got {
+				if r.ID != tt.want[i] {
+					t.Errorf("resource %d = %s, want %s", i, r.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResourceCacheAnnotationIndexCleanup(t *testing.T) {
+	t.Parallel()
+
+	cache := NewResourceCache()
+	defer cache.Close()
+	scraped := testResource("Service", "default", "web", "1")
+	scraped.Annotations = map[string]string{"prometheus.io/scrape": "true"}
+	other := testResource("Service", "default", "db", "1")
+	other.Annotations = map[string]string{"prometheus.io/scrape": "false"}
+	cache.Set(scraped)
+	cache.Set(other)
+
+	// Updates and deletes leave no stale entries behind
+	cache.Set(testResource("Service", "default", "web", "2"))
+	cache.Delete("Service:default:db")
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	if len(cache.annotations) != 0 {
+		t.Errorf("annotation index = %v after removing every annotation, want it empty", cache.annotations)
+	}
+}
+
+// BenchmarkListWithFilter compares ListByNamespace, built on ListWithFilter, with the
+// loop it replaced, measuring the cost of the per-resource predicate call
+func BenchmarkListWithFilter(b *testing.B) {
+	cache := NewResourceCache()
+	defer cache.Close()
+	cache.BulkSet(benchmarkResources(10000))
+
+	b.Run("ListWithFilter", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = cache.ListByNamespace("ns-3")
+		}
+	})
+	b.Run("Loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cache.mu.RLock()
+			resources := []*types.Resource{}
+			for _, r := range cache.resources {
+				if r.Namespace == "ns-3" {
+					resources = append(resources, r)
+				}
+			}
+			cache.mu.RUnlock()
+			_ = resources
+		}
+	})
+}
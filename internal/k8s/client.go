@@ -1,16 +1,26 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 // Logger interface for logging (to avoid circular dependency)
@@ -18,12 +28,29 @@ type Logger interface {
 	Printf(format string, v ...interface{})
 }
 
+// KubernetesClient is the typed API a Client uses, satisfied by the real clientset and by
+// k8s.io/client-go/kubernetes/fake
+type KubernetesClient interface {
+	kubernetes.Interface
+}
+
 // Client wraps the Kubernetes clientset and informer factory
 type Client struct {
-	Clientset       *kubernetes.Clientset
+	Clientset       KubernetesClient
 	InformerFactory informers.SharedInformerFactory
 	config          *rest.Config
 	logger          Logger
+
+	// Dynamic client and informers for resources found by DiscoverAllAPIGroups
+	DynamicClient          dynamic.Interface
+	DynamicInformerFactory dynamicinformer.DynamicSharedInformerFactory
+
+	// informerSynced holds the HasSynced func of every informer registered by the watcher
+	informerSynced map[string]cache.InformerSynced
+
+	stopCh <-chan struct{} // passed to Start, stops the informers
+
+	namespace string // the only namespace informers list, "" for all
 }
 
 // NewClient creates a new Kubernetes client with informers using the current context
@@ -34,6 +61,36 @@ func NewClient() (*Client, error) {
 // NewClientWithContext creates a new Kubernetes client with informers using a specific context
 // If context is empty, uses the current context from kubeconfig
 func NewClientWithContext(context string) (*Client, error) {
+	return NewNamespacedClientWithContext(context, "")
+}
+
+// NewNamespacedClientWithContext creates a client whose informers only list and watch
+// resources in namespace ("" for all namespaces). It works with namespace-scoped
+// credentials as long as no cluster-scoped type (Node, StorageClass) is watched.
+func NewNamespacedClientWithContext(context, namespace string) (*Client, error) {
+	return NewClientWithOptions(context, ClientOptions{Namespace: namespace})
+}
+
+// ClientOptions configures what a client's informers list and watch
+type ClientOptions struct {
+	// Namespace is the only namespace watched ("" for all), see NewNamespacedClientWithContext
+	Namespace string
+
+	// WatchLabelSelector is a label selector (e.g. "monitored=true") applied by the API
+	// server to every informer's list and watch, typed and dynamic, reducing list sizes
+	// and informer memory. Resources that don't match never reach k8v, whatever its
+	// other filters: owners, Services and Nodes without the labels disappear too.
+	WatchLabelSelector string
+}
+
+// NewClientWithOptions creates a new Kubernetes client with informers using a specific
+// context ("" for the current one) and the given options
+func NewClientWithOptions(context string, options ClientOptions) (*Client, error) {
+	if _, err := labels.Parse(options.WatchLabelSelector); err != nil {
+		return nil, fmt.Errorf("invalid watch label selector: %w", err)
+	}
+	namespace := options.Namespace
+
 	config, err := getKubeConfigWithContext(context)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
@@ -44,16 +101,58 @@ func NewClientWithContext(context string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	// Filter server-side, so unselected resources are never listed
+	var tweakListOptions func(opts *metav1.ListOptions)
+	if options.WatchLabelSelector != "" {
+		tweakListOptions = func(opts *metav1.ListOptions) {
+			opts.LabelSelector = options.WatchLabelSelector
+		}
+	}
+
 	// Create SharedInformerFactory with 30 second resync period
-	informerFactory := informers.NewSharedInformerFactory(clientset, 30*time.Second)
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(clientset, 30*time.Second,
+		informers.WithNamespace(namespace), informers.WithTweakListOptions(tweakListOptions))
 
 	return &Client{
-		Clientset:       clientset,
-		InformerFactory: informerFactory,
-		config:          config,
+		Clientset:              clientset,
+		InformerFactory:        informerFactory,
+		DynamicClient:          dynamicClient,
+		DynamicInformerFactory: dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 30*time.Second, namespace, tweakListOptions),
+		config:                 config,
+		informerSynced:         make(map[string]cache.InformerSynced),
+		namespace:              namespace,
 	}, nil
 }
 
+// NewClientWithFake creates a client around a clientset such as a
+// k8s.io/client-go/kubernetes/fake one, so watchers and transformers can run without a
+// cluster. It has no REST config or dynamic client, so exec and WatchAllAPIs are
+// unavailable.
+func NewClientWithFake(fakeClientset kubernetes.Interface) *Client {
+	return NewClientWithFakeDynamic(fakeClientset, nil)
+}
+
+// NewClientWithFakeDynamic is NewClientWithFake with a dynamic client such as a
+// k8s.io/client-go/dynamic/fake one, for WatchAllAPIs. Resources are discovered through
+// the clientset's Discovery. A nil dynamic client is the same as NewClientWithFake.
+func NewClientWithFakeDynamic(fakeClientset kubernetes.Interface, fakeDynamicClient dynamic.Interface) *Client {
+	client := &Client{
+		Clientset:       fakeClientset,
+		InformerFactory: informers.NewSharedInformerFactory(fakeClientset, 0),
+		informerSynced:  make(map[string]cache.InformerSynced),
+	}
+	if fakeDynamicClient != nil {
+		client.DynamicClient = fakeDynamicClient
+		client.DynamicInformerFactory = dynamicinformer.NewDynamicSharedInformerFactory(fakeDynamicClient, 0)
+	}
+	return client
+}
+
 // getKubeConfig returns a Kubernetes client config using the current context
 // It tries in-cluster config first, then falls back to kubeconfig file
 func getKubeConfig() (*rest.Config, error) {
@@ -102,10 +201,35 @@ func getKubeconfigPath() string {
 
 // Context represents a Kubernetes context
 type Context struct {
-	Name      string `json:"name"`
-	Cluster   string `json:"cluster"`
-	Namespace string `json:"namespace"`
-	Current   bool   `json:"current"`
+	Name          string `json:"name"`
+	Cluster       string `json:"cluster"`
+	ClusterServer string `json:"clusterServer"` // API server URL of the cluster entry
+	AuthInfo      string `json:"authInfo"`      // kubeconfig user of the context
+	Namespace     string `json:"namespace"`
+	Current       bool   `json:"current"`
+}
+
+// ContextDetails is a context with the TLS settings of its cluster, so the UI can warn
+// about insecure contexts. Credentials are never included.
+type ContextDetails struct {
+	Context
+	InsecureSkipTLSVerify    bool `json:"insecureSkipTLSVerify"`
+	CertificateAuthorityData bool `json:"certificateAuthorityData"` // Whether CA data is set, never the data itself
+}
+
+// newContext builds a context entry from a loaded kubeconfig
+func newContext(config *clientcmdapi.Config, name string, ctxInfo *clientcmdapi.Context) Context {
+	entry := Context{
+		Name:      name,
+		Cluster:   ctxInfo.Cluster,
+		AuthInfo:  ctxInfo.AuthInfo,
+		Namespace: ctxInfo.Namespace,
+		Current:   name == config.CurrentContext,
+	}
+	if cluster, ok := config.Clusters[ctxInfo.Cluster]; ok {
+		entry.ClusterServer = cluster.Server
+	}
+	return entry
 }
 
 // ListContexts returns all available contexts from kubeconfig
@@ -120,17 +244,31 @@ func ListContexts() ([]Context, error) {
 
 	contexts := make([]Context, 0, len(config.Contexts))
 	for name, ctxInfo := range config.Contexts {
-		contexts = append(contexts, Context{
-			Name:      name,
-			Cluster:   ctxInfo.Cluster,
-			Namespace: ctxInfo.Namespace,
-			Current:   name == config.CurrentContext,
-		})
+		contexts = append(contexts, newContext(config, name, ctxInfo))
 	}
 
 	return contexts, nil
 }
 
+// GetContextDetails returns a kubeconfig context by name, and false if there is none
+func GetContextDetails(name string) (*ContextDetails, bool, error) {
+	config, err := clientcmd.LoadFromFile(getKubeconfigPath())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	ctxInfo, ok := config.Contexts[name]
+	if !ok {
+		return nil, false, nil
+	}
+	details := &ContextDetails{Context: newContext(config, name, ctxInfo)}
+	if cluster, ok := config.Clusters[ctxInfo.Cluster]; ok {
+		details.InsecureSkipTLSVerify = cluster.InsecureSkipTLSVerify
+		details.CertificateAuthorityData = len(cluster.CertificateAuthorityData) > 0
+	}
+	return details, true, nil
+}
+
 // GetCurrentContext returns the current context name
 func GetCurrentContext() (string, error) {
 	kubeconfigPath := getKubeconfigPath()
@@ -144,14 +282,101 @@ func GetCurrentContext() (string, error) {
 	return config.CurrentContext, nil
 }
 
+// Namespace returns the namespace informers are restricted to, or "" for all namespaces
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
 // SetLogger sets the logger for the client
 func (c *Client) SetLogger(logger Logger) {
 	c.logger = logger
 }
 
+// trackInformer registers an informer for WaitForCacheSync
+// Must be called before Start
+func (c *Client) trackInformer(name string, hasSynced cache.InformerSynced) {
+	c.informerSynced[name] = hasSynced
+}
+
 // Start starts all informers
 func (c *Client) Start(stopCh <-chan struct{}) {
+	c.stopCh = stopCh
 	c.InformerFactory.Start(stopCh)
+	if c.DynamicInformerFactory != nil { // nil for NewClientWithFake
+		c.DynamicInformerFactory.Start(stopCh)
+	}
+}
+
+// DiscoverAllAPIGroups returns the preferred version of every resource the API server
+// can list and watch, across all API groups (built-in and CRDs). Subresources are
+// skipped. Groups that fail discovery (e.g. an unavailable aggregated API) are skipped
+// rather than failing the whole discovery.
+func (c *Client) DiscoverAllAPIGroups(ctx context.Context) ([]schema.GroupVersionResource, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resourceLists, err := c.Clientset.Discovery().ServerPreferredResources()
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, fmt.Errorf("failed to discover API resources: %w", err)
+		}
+		c.logf("Warning: some API groups could not be discovered: %v", err)
+	}
+
+	gvrs := []schema.GroupVersionResource{}
+	for _, list := range resourceLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") {
+				continue // Subresource, e.g. pods/log
+			}
+			verbs := sets.New(resource.Verbs...)
+			if !verbs.Has("list") || !verbs.Has("watch") {
+				continue
+			}
+			gvrs = append(gvrs, gv.WithResource(resource.Name))
+		}
+	}
+	return gvrs, nil
+}
+
+// PreferredAPIVersion returns the API version ("group/version", or "version" for the core
+// group) the connected cluster prefers for a kind
+func (c *Client) PreferredAPIVersion(group, kind string) (string, error) {
+	groups, err := c.Clientset.Discovery().ServerGroups()
+	if err != nil {
+		return "", fmt.Errorf("failed to discover API groups: %w", err)
+	}
+
+	for _, g := range groups.Groups {
+		if g.Name != group {
+			continue
+		}
+		// The preferred version first, then the others in server order
+		versions := []string{g.PreferredVersion.GroupVersion}
+		for _, v := range g.Versions {
+			if v.GroupVersion != g.PreferredVersion.GroupVersion {
+				versions = append(versions, v.GroupVersion)
+			}
+		}
+		for _, groupVersion := range versions {
+			resources, err := c.Clientset.Discovery().ServerResourcesForGroupVersion(groupVersion)
+			if err != nil {
+				continue
+			}
+			for _, resource := range resources.APIResources {
+				if resource.Kind == kind && !strings.Contains(resource.Name, "/") {
+					return groupVersion, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("kind %s is not served by API group %q", kind, group)
+	}
+	return "", fmt.Errorf("API group %q is not served by the cluster", group)
 }
 
 // logf logs using the logger if available, otherwise falls back to fmt.Printf
@@ -164,6 +389,7 @@ func (c *Client) logf(format string, v ...interface{}) {
 }
 
 // WaitForCacheSync waits for all informer caches to sync
+// Prefer Watcher.StartAsync, which waits in the background and reports the result.
 func (c *Client) WaitForCacheSync(stopCh <-chan struct{}) bool {
 	syncStart := time.Now()
 	syncTimes := make(map[string]time.Time)
@@ -171,17 +397,8 @@ func (c *Client) WaitForCacheSync(stopCh <-chan struct{}) bool {
 
 	c.logf("Waiting for informer caches to sync...")
 
-	// Get all registered informers
-	informers := map[string]cache.InformerSynced{
-		"Pods":        c.InformerFactory.Core().V1().Pods().Informer().HasSynced,
-		"Deployments": c.InformerFactory.Apps().V1().Deployments().Informer().HasSynced,
-		"ReplicaSets": c.InformerFactory.Apps().V1().ReplicaSets().Informer().HasSynced,
-		"Services":    c.InformerFactory.Core().V1().Services().Informer().HasSynced,
-		"Ingresses":   c.InformerFactory.Networking().V1().Ingresses().Informer().HasSynced,
-		"ConfigMaps":  c.InformerFactory.Core().V1().ConfigMaps().Informer().HasSynced,
-		"Secrets":     c.InformerFactory.Core().V1().Secrets().Informer().HasSynced,
-		"Nodes":       c.InformerFactory.Core().V1().Nodes().Informer().HasSynced,
-	}
+	// Only informers registered by the watcher are started, so only those can sync
+	informers := c.informerSynced
 
 	// Poll each informer until all are synced
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -0,0 +1,225 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// customResourceTransformer converts a well-known custom resource with specialized health
+// and relationship logic
+type customResourceTransformer func(obj *unstructured.Unstructured, gvr schema.GroupVersionResource, cache *ResourceCache) *types.Resource
+
+// customResourceTransformers maps "Kind.group" type names to their transformer
+var customResourceTransformers = map[string]customResourceTransformer{
+	"Application.argoproj.io":     TransformArgoApplication,
+	"Certificate.cert-manager.io": TransformCertificate,
+}
+
+// customResourceTypeName returns the "Kind.group" name of a dynamic object, e.g. "Application.argoproj.io"
+func customResourceTypeName(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) string {
+	return dynamicKind(obj, gvr) + "." + gvr.Group
+}
+
+// TransformArgoApplication converts an Argo CD Application, deriving health from its
+// health, sync and operation status instead of conditions, and linking the resources it
+// manages (status.resources) that are in the cache
+func TransformArgoApplication(obj *unstructured.Unstructured, gvr schema.GroupVersionResource, cache *ResourceCache) *types.Resource {
+	resource := transformUnstructuredGeneric(obj, gvr)
+
+	healthStatus, _, _ := unstructured.NestedString(obj.Object, "status", "health", "status")
+	syncStatus, _, _ := unstructured.NestedString(obj.Object, "status", "sync", "status")
+	operationPhase, _, _ := unstructured.NestedString(obj.Object, "status", "operationState", "phase")
+	operationMessage, _, _ := unstructured.NestedString(obj.Object, "status", "operationState", "message")
+
+	resource.Status = types.ResourceStatus{
+		Phase:   healthStatus,
+		Ready:   syncStatus,
+		Message: operationMessage,
+	}
+	resource.Health = computeArgoApplicationHealth(healthStatus, syncStatus, operationPhase)
+
+	managed, _, _ := unstructured.NestedSlice(obj.Object, "status", "resources")
+	for _, m := range managed {
+		entry, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, _ := entry["group"].(string)
+		kind, _ := entry["kind"].(string)
+		namespace, _ := entry["namespace"].(string)
+		name, _ := entry["name"].(string)
+		if kind == "" || name == "" {
+			continue
+		}
+		ref := types.NewResourceRef(dynamicTypeName(kind, group), namespace, name)
+		if cache.Contains(ref.ID) {
+			resource.Relationships.Owns = append(resource.Relationships.Owns, ref)
+		}
+	}
+
+	return resource
+}
+
+// computeArgoApplicationHealth maps an Application's status to a health state
+// A failed sync operation is an error regardless of the last assessed health, and a
+// healthy Application that is out of sync needs attention.
+func computeArgoApplicationHealth(healthStatus, syncStatus, operationPhase string) types.HealthState {
+	if operationPhase == "Failed" || operationPhase == "Error" {
+		return types.HealthError
+	}
+
+	switch healthStatus {
+	case "Healthy":
+		if syncStatus == "OutOfSync" {
+			return types.HealthWarning
+		}
+		return types.HealthHealthy
+	case "Progressing", "Suspended":
+		return types.HealthWarning
+	case "Degraded", "Missing":
+		return types.HealthError
+	default:
+		return types.HealthUnknown
+	}
+}
+
+// Days before expiry at which a Certificate becomes a warning, then an error
+// cert-manager renews 30 days before expiry by default, so a warning means renewal is late
+const (
+	certExpiryWarningDays = 30
+	certExpiryErrorDays   = 7
+)
+
+// TransformCertificate converts a cert-manager Certificate, deriving health from its Ready
+// condition and the time left until status.notAfter
+// The expiry is added to the Spec as expiresAt (RFC 3339) and daysRemaining.
+func TransformCertificate(obj *unstructured.Unstructured, gvr schema.GroupVersionResource, cache *ResourceCache) *types.Resource {
+	resource := transformUnstructuredGeneric(obj, gvr)
+
+	readyStatus, readyMessage := "", ""
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Ready" {
+			readyStatus, _ = condition["status"].(string)
+			readyMessage, _ = condition["message"].(string)
+			break
+		}
+	}
+
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec") // A copy, safe to add fields to
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+
+	resource.Status = types.ResourceStatus{Ready: readyStatus}
+	if readyStatus != "True" {
+		resource.Status.Message = readyMessage
+	}
+
+	notAfter, _, _ := unstructured.NestedString(obj.Object, "status", "notAfter")
+	expiresAt, err := time.Parse(time.RFC3339, notAfter)
+	if err != nil {
+		// Not issued yet (or unparseable): only the Ready condition is known
+		resource.Health = computeUnstructuredHealth(obj)
+		if readyStatus != "" && readyStatus != "True" {
+			resource.Health = types.HealthError
+		}
+		return resource
+	}
+
+	days := daysUntil(expiresAt, time.Now())
+	spec["expiresAt"] = expiresAt.UTC().Format(time.RFC3339)
+	spec["daysRemaining"] = days
+	resource.Spec = types.NewSpec(spec)
+	if resource.Status.Message == "" {
+		resource.Status.Message = certExpiryMessage(days)
+	}
+	resource.Health = computeCertificateHealth(readyStatus, days)
+	return resource
+}
+
+// computeCertificateHealth maps a Certificate's Ready status and days until expiry to a
+// health state
+func computeCertificateHealth(readyStatus string, daysRemaining int) types.HealthState {
+	switch {
+	case readyStatus != "True" || daysRemaining < certExpiryErrorDays:
+		return types.HealthError
+	case daysRemaining < certExpiryWarningDays:
+		return types.HealthWarning
+	default:
+		return types.HealthHealthy
+	}
+}
+
+// daysUntil returns the whole days from now until t, negative once t has passed
+func daysUntil(t, now time.Time) int {
+	return int(t.Sub(now).Hours() / 24)
+}
+
+// certExpiryMessage describes the time left until a certificate expires
+func certExpiryMessage(days int) string {
+	switch {
+	case days < 0:
+		return fmt.Sprintf("Expired %d days ago", -days)
+	case days == 1:
+		return "Expires in 1 day"
+	default:
+		return fmt.Sprintf("Expires in %d days", days)
+	}
+}
+
+// ExpiringCertificate is a cert-manager Certificate expiring within the requested window
+type ExpiringCertificate struct {
+	Name          string            `json:"name"`
+	Namespace     string            `json:"namespace"`
+	ExpiresAt     time.Time         `json:"expiresAt"`
+	DaysRemaining int               `json:"daysRemaining"`
+	Health        types.HealthState `json:"health"`
+	Message       string            `json:"message,omitempty"`
+}
+
+// GetExpiringCertificates returns cached cert-manager Certificates in a namespace ("" for
+// all) expiring within the given duration, soonest first
+// Days are recomputed from expiresAt, as the cached daysRemaining is only refreshed when
+// the Certificate changes.
+func (w *Watcher) GetExpiringCertificates(namespace string, within time.Duration) []ExpiringCertificate {
+	now := time.Now()
+	result := []ExpiringCertificate{}
+	for _, cert := range w.cache.ListByType("Certificate.cert-manager.io") {
+		if namespace != "" && cert.Namespace != namespace {
+			continue
+		}
+		var spec struct {
+			ExpiresAt string `json:"expiresAt"` // Set by TransformCertificate
+		}
+		if json.Unmarshal(cert.Spec, &spec) != nil {
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339, spec.ExpiresAt)
+		if err != nil || expiresAt.Sub(now) > within {
+			continue
+		}
+		days := daysUntil(expiresAt, now)
+		result = append(result, ExpiringCertificate{
+			Name:          cert.Name,
+			Namespace:     cert.Namespace,
+			ExpiresAt:     expiresAt,
+			DaysRemaining: days,
+			Health:        computeCertificateHealth(cert.Status.Ready, days),
+			Message:       cert.Status.Message,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ExpiresAt.Before(result[j].ExpiresAt)
+	})
+	return result
+}
@@ -0,0 +1,262 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// maxDynamicResourcesPerGVR caps how many objects of a single discovered resource are cached
+const maxDynamicResourcesPerGVR = 50000
+
+// staticGVRs are watched by typed informers (see Watcher.informers) and never watched dynamically
+var staticGVRs = map[schema.GroupVersionResource]bool{
+	{Version: "v1", Resource: "pods"}:                                         true,
+	{Group: "apps", Version: "v1", Resource: "deployments"}:                   true,
+	{Group: "apps", Version: "v1", Resource: "replicasets"}:                   true,
+	{Version: "v1", Resource: "services"}:                                     true,
+	{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}:        true,
+	{Version: "v1", Resource: "configmaps"}:                                   true,
+	{Version: "v1", Resource: "secrets"}:                                      true,
+	{Version: "v1", Resource: "nodes"}:                                        true,
+	{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}:      true,
+	{Version: "v1", Resource: "persistentvolumeclaims"}:                       true,
+	{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}:        true,
+	{Version: "v1", Resource: "events"}:                                       true, // High churn, not useful as graph nodes
+	{Group: "events.k8s.io", Version: "v1", Resource: "events"}:               true,
+	{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}:         true, // Renewed every few seconds
+	{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}:    true,
+	{Version: "v1", Resource: "endpoints"}:                                    true,
+	{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}: true,
+}
+
+// registerDynamicInformers watches every discovered resource not covered by a typed informer
+// Dynamic informers aren't tracked by WaitForCacheSync: a resource the user may not list
+// would never sync and block the app from reporting synced.
+func (w *Watcher) registerDynamicInformers(ctx context.Context) error {
+	if w.client.DynamicInformerFactory == nil {
+		return errors.New("client has no dynamic informer factory")
+	}
+	gvrs, err := w.client.DiscoverAllAPIGroups(ctx)
+	if err != nil {
+		return err
+	}
+	w.subscribeDynamicCounts()
+
+	registered := 0
+	for _, gvr := range gvrs {
+		if staticGVRs[gvr] {
+			continue
+		}
+		gvr := gvr
+		informer := w.client.DynamicInformerFactory.ForResource(gvr).Informer()
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { w.handleDynamicAdd(gvr, obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) { w.handleDynamicUpdate(gvr, newObj) },
+			DeleteFunc: func(obj interface{}) { w.handleDynamicDelete(gvr, obj) },
+		})
+		registered++
+	}
+
+	log.Printf("Dynamic informers registered for %d discovered resources", registered)
+	return nil
+}
+
+// TransformUnstructured converts any object served by the dynamic client to our Resource model
+// Only ownership relationships are known for arbitrary resources, unless a transformer in
+// customResourceTransformers knows more about the kind.
+func TransformUnstructured(obj *unstructured.Unstructured, gvr schema.GroupVersionResource, cache *ResourceCache) *types.Resource {
+	if transform, ok := customResourceTransformers[customResourceTypeName(obj, gvr)]; ok {
+		return transform(obj, gvr, cache)
+	}
+	return transformUnstructuredGeneric(obj, gvr)
+}
+
+// transformUnstructuredGeneric is the TransformUnstructured conversion shared by all kinds
+func transformUnstructuredGeneric(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) *types.Resource {
+	kind := dynamicTypeName(dynamicKind(obj, gvr), gvr.Group)
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	spec, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec")
+
+	return &types.Resource{
+		ID:        types.BuildID(kind, obj.GetNamespace(), obj.GetName()),
+		Type:      kind,
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+
+		Status: types.ResourceStatus{
+			Phase: phase,
+		},
+
+		Health: computeUnstructuredHealth(obj),
+
+		Relationships: types.Relationships{
+			OwnedBy: ExtractOwners(obj),
+		},
+
+		Labels:      obj.GetLabels(),
+		Annotations: obj.GetAnnotations(),
+		CreatedAt:   obj.GetCreationTimestamp().Time,
+		Spec:        types.NewSpec(spec),
+		YAML:        marshalToYAML(obj.Object),
+
+		ResourceVersion: obj.GetResourceVersion(),
+	}
+}
+
+// dynamicKind returns an object's kind, falling back to the resource name when unset
+func dynamicKind(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) string {
+	if kind := obj.GetKind(); kind != "" {
+		return kind
+	}
+	return gvr.Resource
+}
+
+// dynamicTypeName returns the Type of a dynamically watched object, and of references to
+// it: its kind, qualified with its API group ("Certificate.cert-manager.io") unless the
+// group is built into Kubernetes. Custom resource kinds can repeat across groups or match
+// a built-in kind like Deployment, and a bare kind would give them colliding IDs.
+func dynamicTypeName(kind, group string) string {
+	if isBuiltinGroup(group) {
+		return kind
+	}
+	return kind + "." + group
+}
+
+// isBuiltinGroup reports whether an API group is served by Kubernetes itself: the core
+// group, undotted groups like apps and batch, and *.k8s.io groups (reserved for
+// Kubernetes, so custom resources can't use them without an approval annotation)
+func isBuiltinGroup(group string) bool {
+	return !strings.Contains(group, ".") || strings.HasSuffix(group, ".k8s.io")
+}
+
+// dynamicID returns the cache ID of a dynamically watched object
+func dynamicID(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) string {
+	return types.BuildID(dynamicTypeName(dynamicKind(obj, gvr), gvr.Group), obj.GetNamespace(), obj.GetName())
+}
+
+// computeUnstructuredHealth derives health from a Ready or Available status condition,
+// the convention most controllers follow
+func computeUnstructuredHealth(obj *unstructured.Unstructured) types.HealthState {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] != "Ready" && condition["type"] != "Available" {
+			continue
+		}
+		switch condition["status"] {
+		case "True":
+			return types.HealthHealthy
+		case "False":
+			return types.HealthWarning
+		}
+	}
+	return types.HealthUnknown
+}
+
+// Dynamic event handlers
+
+func (w *Watcher) handleDynamicAdd(gvr schema.GroupVersionResource, obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	// Counted before it's stored: a delete or eviction can only uncount it afterwards
+	id := dynamicID(u, gvr)
+	w.dynamicMu.Lock()
+	if _, counted := w.dynamicIDs[id]; !counted {
+		if w.dynamicCounts[gvr] >= maxDynamicResourcesPerGVR {
+			if !w.dynamicCapWarned[gvr] {
+				log.Printf("Warning: %s exceeds %d objects, further objects are not cached", dynamicResourceName(gvr), maxDynamicResourcesPerGVR)
+				w.dynamicCapWarned[gvr] = true
+			}
+			w.dynamicMu.Unlock()
+			return
+		}
+		w.dynamicIDs[id] = gvr
+		w.dynamicCounts[gvr]++
+	}
+	w.dynamicMu.Unlock()
+
+	resource := TransformUnstructured(u, gvr, w.cache)
+	if !w.cache.Set(resource) {
+		return // Older than the cached version
+	}
+	UpdateBidirectionalRelationships(w.cache, resource)
+
+	if w.handler != nil {
+		w.emit(ResourceEvent{Type: EventAdded, Resource: resource})
+	}
+}
+
+func (w *Watcher) handleDynamicUpdate(gvr schema.GroupVersionResource, newObj interface{}) {
+	u, ok := newObj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	resource := TransformUnstructured(u, gvr, w.cache)
+	if _, cached := w.cache.Get(resource.ID); !cached {
+		return // Skipped by the per-resource cap
+	}
+	w.updateResource(resource)
+}
+
+func (w *Watcher) handleDynamicDelete(gvr schema.GroupVersionResource, obj interface{}) {
+	u, ok := deletedObject(obj).(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	id := dynamicID(u, gvr)
+	resource, _ := w.cache.Get(id)
+	if resource == nil {
+		return
+	}
+	w.cache.Delete(id) // Uncounted by the subscription registerDynamicInformers adds
+
+	if w.handler != nil {
+		w.emit(ResourceEvent{Type: EventDeleted, Resource: resource})
+	}
+}
+
+// subscribeDynamicCounts uncounts dynamic resources however they leave the cache,
+// LRU eviction included
+func (w *Watcher) subscribeDynamicCounts() {
+	w.cache.Subscribe(SubscribeAll, func(resource *types.Resource, op CacheOp) {
+		if op == CacheOpDelete {
+			w.uncountDynamic(resource.ID)
+		}
+	})
+}
+
+// uncountDynamic forgets a dynamically watched resource that left the cache
+// It runs under the cache write lock, so it must not call the cache.
+func (w *Watcher) uncountDynamic(id string) {
+	w.dynamicMu.Lock()
+	defer w.dynamicMu.Unlock()
+	if gvr, ok := w.dynamicIDs[id]; ok {
+		delete(w.dynamicIDs, id)
+		w.dynamicCounts[gvr]--
+	}
+}
+
+// dynamicResourceName formats a GVR for logs, e.g. "flowschemas.flowcontrol.apiserver.k8s.io/v1"
+func dynamicResourceName(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return fmt.Sprintf("%s/%s", gvr.Resource, gvr.Version)
+	}
+	return fmt.Sprintf("%s.%s/%s", gvr.Resource, gvr.Group, gvr.Version)
+}
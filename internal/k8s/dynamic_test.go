@@ -0,0 +1,200 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testUnstructured(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestDynamicTypeName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		kind  string
+		group string
+		want  string
+	}{
+		{"Pod", "", "Pod"},
+		{"Deployment", "apps", "Deployment"},
+		{"Ingress", "networking.k8s.io", "Ingress"},
+		{"Certificate", "cert-manager.io", "Certificate.cert-manager.io"},
+		{"Deployment", "example.com", "Deployment.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind+"."+tt.group, func(t *testing.T) {
+			t.Parallel()
+			if got := dynamicTypeName(tt.kind, tt.group); got != tt.want {
+				t.Errorf("dynamicTypeName(%q, %q) = %q, want %q", tt.kind, tt.group, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDynamicIDsDontCollideAcrossGroups(t *testing.T) {
+	t.Parallel()
+
+	w, _ := newTestWatcher(t, WatcherOptions{})
+	w.subscribeDynamicCounts()
+	certManager := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	other := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "certificates"}
+
+	w.handleDynamicAdd(certManager, testUnstructured("cert-manager.io/v1", "Certificate", "default", "tls"))
+	w.handleDynamicAdd(other, testUnstructured("example.com/v1", "Certificate", "default", "tls"))
+
+	for _, id := range []string{"Certificate.cert-manager.io:default:tls", "Certificate.example.com:default:tls"} {
+		if !w.cache.Contains(id) {
+			t.Errorf("%s missing from the cache", id)
+		}
+	}
+}
+
+func TestDynamicCountsFollowTheCache(t *testing.T) {
+	t.Parallel()
+
+	cache := NewResourceCacheWithOptions(CacheOptions{MaxResources: 1})
+	t.Cleanup(cache.Close)
+	w := NewWatcherWithOptions(NewClientWithFake(fake.NewSimpleClientset()), cache, nil, WatcherOptions{})
+	w.subscribeDynamicCounts()
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	count := func() int {
+		w.dynamicMu.Lock()
+		defer w.dynamicMu.Unlock()
+		return w.dynamicCounts[gvr]
+	}
+
+	first := testUnstructured("example.com/v1", "Widget", "default", "first")
+	w.handleDynamicAdd(gvr, first)
+	w.handleDynamicAdd(gvr, first) // A relist of a counted object isn't counted twice
+	if got := count(); got != 1 {
+		t.Fatalf("count after adding one object = %d, want 1", got)
+	}
+
+	// Evicting the first object for the second uncounts it
+	w.handleDynamicAdd(gvr, testUnstructured("example.com/v1", "Widget", "default", "second"))
+	if got := count(); got != 1 {
+		t.Errorf("count after an eviction = %d, want 1", got)
+	}
+
+	w.handleDynamicDelete(gvr, testUnstructured("example.com/v1", "Widget", "default", "second"))
+	if got := count(); got != 0 {
+		t.Errorf("count after deleting every object = %d, want 0", got)
+	}
+}
+
+func TestExtractOwnersQualifiesCustomResources(t *testing.T) {
+	t.Parallel()
+
+	pod := testPod("default", "web")
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc"},
+		{APIVersion: "example.com/v1", Kind: "Widget", Name: "web"},
+	}
+
+	owners := ExtractOwners(pod)
+	want := []string{"ReplicaSet:default:web-abc", "Widget.example.com:default:web"}
+	if len(owners) != len(want) {
+		t.Fatalf("ExtractOwners() = %+v, want %v", owners, want)
+	}
+	for i, owner := range owners {
+		if owner.ID != want[i] {
+			t.Errorf("owner %d ID = %q, want %q", i, owner.ID, want[i])
+		}
+	}
+}
+
+func TestRegisterDynamicInformersWithoutFactory(t *testing.T) {
+	t.Parallel()
+
+	w, _ := newTestWatcher(t, WatcherOptions{})
+	if err := w.registerDynamicInformers(context.Background()); err == nil {
+		t.Error("registerDynamicInformers() without a dynamic informer factory returned no error")
+	}
+}
+
+// discoveringClientset is a fake clientset whose discovery reports resources as
+// preferred, which the fake's own ServerPreferredResources never does
+type discoveringClientset struct {
+	*fake.Clientset
+	resources []*metav1.APIResourceList
+}
+
+func (c discoveringClientset) Discovery() discovery.DiscoveryInterface {
+	return preferredDiscovery{FakeDiscovery: c.Clientset.Discovery().(*fakediscovery.FakeDiscovery), resources: c.resources}
+}
+
+type preferredDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	resources []*metav1.APIResourceList
+}
+
+func (d preferredDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return d.resources, nil
+}
+
+func TestWatchAllAPIsWithFakeDynamicClient(t *testing.T) {
+	t.Parallel()
+
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	widget := testUnstructured("example.com/v1", "Widget", "default", "gear")
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "WidgetList"}, widget)
+	clientset := discoveringClientset{Clientset: fake.NewSimpleClientset(), resources: []*metav1.APIResourceList{{
+		GroupVersion: "example.com/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: metav1.Verbs{"list", "watch"}},
+			{Name: "widgets/status", Kind: "Widget", Namespaced: true, Verbs: metav1.Verbs{"get"}},
+		},
+	}}}
+
+	cache := NewResourceCache()
+	t.Cleanup(cache.Close)
+	client := NewClientWithFakeDynamic(clientset, dynamicClient)
+	client.SetLogger(log.New(io.Discard, "", 0))
+	w := NewWatcherWithOptions(client, cache, nil, WatcherOptions{WatchResourceTypes: []string{"Pod"}, WatchAllAPIs: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	synced, err := w.StartAsync(ctx)
+	if err != nil {
+		t.Fatalf("StartAsync() error = %v", err)
+	}
+	if err := <-synced; err != nil {
+		t.Fatalf("sync error = %v", err)
+	}
+
+	// Dynamic informers aren't waited for by the sync
+	deadline := time.Now().Add(5 * time.Second)
+	for !cache.Contains("Widget.example.com:default:gear") {
+		if time.Now().After(deadline) {
+			t.Fatal("discovered Widget was never cached")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchAllAPIsWithoutDynamicClient(t *testing.T) {
+	t.Parallel()
+
+	w, _ := newTestWatcher(t, WatcherOptions{WatchResourceTypes: []string{"Pod"}, WatchAllAPIs: true})
+	if err := w.Start(); err == nil {
+		t.Error("Start() with WatchAllAPIs and no dynamic client returned no error")
+	}
+}
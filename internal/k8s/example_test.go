@@ -0,0 +1,87 @@
+package k8s_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+// This example follows a Deployment through a rolling update of its 3 replicas: it is
+// created without ready pods, becomes available, surges a fourth pod, replaces an old
+// one, and settles back on 3 ready replicas.
+func ExampleWatcher_StreamResourceEvents() {
+	clientset := fake.NewSimpleClientset()
+	// The fake clientset drops changes made before an informer watches, so wait for it
+	watching := make(chan struct{})
+	clientset.PrependWatchReactor("deployments", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		w, err := clientset.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		close(watching)
+		return true, w, err
+	})
+
+	cache := k8s.NewResourceCache()
+	defer cache.Close()
+	client := k8s.NewClientWithFake(clientset)
+	client.SetLogger(log.New(io.Discard, "", 0))
+	watcher := k8s.NewWatcherWithOptions(client, cache, nil, k8s.WatcherOptions{
+		WatchResourceTypes: []string{"Deployment"},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	synced, err := watcher.StartAsync(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := <-synced; err != nil {
+		fmt.Println(err)
+		return
+	}
+	<-watching
+
+	events := watcher.StreamResourceEvents(ctx, "Deployment:default:web")
+
+	replicas := int32(3)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	deployments := clientset.AppsV1().Deployments("default")
+	deployments.Create(ctx, deployment, metav1.CreateOptions{})
+	rollout := []appsv1.DeploymentStatus{
+		{Replicas: 3, ReadyReplicas: 3},
+		{Replicas: 4, ReadyReplicas: 3}, // Surge pod starting
+		{Replicas: 4, ReadyReplicas: 2}, // Old pod terminating
+		{Replicas: 3, ReadyReplicas: 3},
+	}
+	for _, status := range rollout {
+		deployment.Status = status
+		deployments.UpdateStatus(ctx, deployment, metav1.UpdateOptions{})
+	}
+
+	for i := 0; i < len(rollout)+1; i++ {
+		select {
+		case event := <-events:
+			fmt.Println(event.Type, event.Resource.Health)
+		case <-ctx.Done():
+			fmt.Println("timed out")
+			return
+		}
+	}
+	// Output:
+	// ADDED error
+	// MODIFIED healthy
+	// MODIFIED warning
+	// MODIFIED warning
+	// MODIFIED healthy
+}
@@ -3,27 +3,48 @@ package k8s
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/remotecommand"
 )
 
 // ExecMessage represents a bidirectional exec communication message
 type ExecMessage struct {
-	Type string `json:"type"`           // INPUT, OUTPUT, RESIZE, CLOSE, ERROR, CONNECTED
-	Data string `json:"data,omitempty"` // For INPUT/OUTPUT messages
+	Type string `json:"type"`           // INPUT, PASTE, OUTPUT, RESIZE, CLOSE, ERROR, CONNECTED
+	Data string `json:"data,omitempty"` // For INPUT/PASTE/OUTPUT messages
 	Cols uint16 `json:"cols,omitempty"` // For RESIZE messages
 	Rows uint16 `json:"rows,omitempty"` // For RESIZE messages
+
+	SessionID string `json:"sessionId,omitempty"` // For CONNECTED messages: the recording and audit ID
+	Protocol  string `json:"protocol,omitempty"`  // For CONNECTED messages: ExecProtocolSPDY or ExecProtocolWebSocket
 }
 
+// Streaming protocols used to reach the API server's exec endpoint
+const (
+	ExecProtocolSPDY      = "SPDY"
+	ExecProtocolWebSocket = "WebSocket"
+)
+
 // Exec message types
 const (
 	ExecMessageInput     = "INPUT"     // Client -> Server: keyboard input
+	ExecMessagePaste     = "PASTE"     // Client -> Server: pasted text, handled as INPUT but audited
 	ExecMessageOutput    = "OUTPUT"    // Server -> Client: stdout/stderr
 	ExecMessageResize    = "RESIZE"    // Client -> Server: terminal resize
 	ExecMessageClose     = "CLOSE"     // Bidirectional: session ended
@@ -68,53 +89,170 @@ func (q *TerminalSizeQueue) Close() {
 	close(q.resizeChan)
 }
 
-// DetectShell tries to detect an available shell in the container
-// Returns /bin/bash if available, otherwise falls back to /bin/sh
-func (c *Client) DetectShell(ctx context.Context, namespace, pod, container string) ([]string, error) {
-	shells := [][]string{
-		{"/bin/bash"},
-		{"/bin/sh"},
+// shellFallbackChain is tried in order when no exec command is given. Commands are
+// run directly rather than probed with `test -x`, which itself needs a shell and so
+// fails on distroless images.
+var shellFallbackChain = [][]string{
+	{"bash", "-i"},
+	{"sh", "-i"},
+	{"ash", "-i"}, // BusyBox
+	{"sh"},
+}
+
+// shellStartTimeout is how long a silent shell must keep running before it is considered
+// started; a missing binary fails well within it. Shells printing a prompt are started
+// at their first output.
+const shellStartTimeout = 2 * time.Second
+
+// startOnWrite returns w calling start before each write, so a session is reported
+// started (CONNECTED sent) as soon as it produces output, and before that output
+// start must be idempotent. A nil w stays nil, as streams treat nil as not requested.
+func startOnWrite(w io.Writer, start func()) io.Writer {
+	if w == nil {
+		return nil
 	}
+	return &startedWriter{w: w, start: start}
+}
 
-	for _, shell := range shells {
-		// Test if shell exists by running a simple command
-		req := c.Clientset.CoreV1().RESTClient().Post().
-			Resource("pods").
-			Name(pod).
-			Namespace(namespace).
-			SubResource("exec").
-			VersionedParams(&corev1.PodExecOptions{
-				Container: container,
-				Command:   []string{"test", "-x", shell[0]},
-				Stdin:     false,
-				Stdout:    true,
-				Stderr:    true,
-				TTY:       false,
-			}, scheme.ParameterCodec)
-
-		exec, err := remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
-		if err != nil {
-			continue
+type startedWriter struct {
+	w     io.Writer
+	start func()
+}
+
+func (s *startedWriter) Write(p []byte) (int, error) {
+	s.start()
+	return s.w.Write(p)
+}
+
+// attemptStdin hands stdin to one exec attempt at a time, so a failed attempt's
+// lingering stdin copy can't swallow input meant for the next shell
+type attemptStdin struct {
+	chunks   <-chan []byte
+	done     chan struct{} // closed when the attempt is abandoned
+	leftover []byte
+}
+
+func (r *attemptStdin) Read(p []byte) (int, error) {
+	if len(r.leftover) == 0 {
+		select {
+		case <-r.done:
+			return 0, io.EOF
+		default:
 		}
+		select {
+		case <-r.done:
+			return 0, io.EOF
+		case chunk, ok := <-r.chunks:
+			if !ok {
+				return 0, io.EOF
+			}
+			r.leftover = chunk
+		}
+	}
+	n := copy(p, r.leftover)
+	r.leftover = r.leftover[n:]
+	return n, nil
+}
 
-		var stdout, stderr bytes.Buffer
-		err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
-			Stdout: &stdout,
-			Stderr: &stderr,
-		})
+// attemptSizeQueue hands terminal resizes to one exec attempt at a time, starting with
+// the most recent size so a resize consumed by a failed attempt isn't lost
+type attemptSizeQueue struct {
+	sizes  <-chan remotecommand.TerminalSize
+	latest *remotecommand.TerminalSize
+	done   chan struct{}
+}
 
-		if err == nil {
-			c.logf("[Exec] Detected shell: %s", shell[0])
-			return shell, nil
+func (q *attemptSizeQueue) Next() *remotecommand.TerminalSize {
+	if q.latest != nil {
+		size := q.latest
+		q.latest = nil
+		return size
+	}
+	select {
+	case <-q.done:
+		return nil
+	case size, ok := <-q.sizes:
+		if !ok {
+			return nil
 		}
+		return &size
 	}
+}
+
+// shellAttempts shares stdin and terminal resizes between successive exec attempts
+type shellAttempts struct {
+	chunks <-chan []byte
+	sizes  chan remotecommand.TerminalSize
 
-	// Fallback to /bin/sh without testing
-	c.logf("[Exec] Shell detection failed, falling back to /bin/sh")
-	return []string{"/bin/sh"}, nil
+	mu     sync.Mutex
+	latest *remotecommand.TerminalSize
+}
+
+// newShellAttempts starts pumping stdin and sizeQueue until they are closed or ctx is done
+func newShellAttempts(ctx context.Context, stdin io.Reader, sizeQueue remotecommand.TerminalSizeQueue) *shellAttempts {
+	a := &shellAttempts{
+		chunks: pumpStdin(ctx, stdin),
+		sizes:  make(chan remotecommand.TerminalSize),
+	}
+	go func() {
+		defer close(a.sizes)
+		for {
+			size := sizeQueue.Next()
+			if size == nil {
+				return
+			}
+			a.mu.Lock()
+			a.latest = size
+			a.mu.Unlock()
+			select {
+			case a.sizes <- *size:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return a
+}
+
+// next returns the stdin and size queue for a new attempt, and a func abandoning it
+func (a *shellAttempts) next() (*attemptStdin, *attemptSizeQueue, func()) {
+	done := make(chan struct{})
+	a.mu.Lock()
+	latest := a.latest
+	a.mu.Unlock()
+	return &attemptStdin{chunks: a.chunks, done: done},
+		&attemptSizeQueue{sizes: a.sizes, latest: latest, done: done},
+		func() { close(done) }
+}
+
+// pumpStdin reads stdin into a channel shared by exec attempts until stdin is closed
+// or ctx is done
+func pumpStdin(ctx context.Context, stdin io.Reader) <-chan []byte {
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		buf := make([]byte, 4096)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				select {
+				case chunks <- append([]byte(nil), buf[:n]...):
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return chunks
 }
 
 // ExecPodShell creates an interactive shell session in a pod container
+// With an empty command, each command of the shell fallback chain is executed until one
+// produces output or keeps running for shellStartTimeout. onStart is called with the
+// command once it runs, and the protocol it was streamed with.
 func (c *Client) ExecPodShell(
 	ctx context.Context,
 	namespace string,
@@ -125,6 +263,7 @@ func (c *Client) ExecPodShell(
 	stdout io.Writer,
 	stderr io.Writer,
 	sizeQueue remotecommand.TerminalSizeQueue,
+	onStart func(command []string, protocol string),
 ) error {
 	// Validate pod exists
 	podObj, err := c.Clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
@@ -149,6 +288,98 @@ func (c *Client) ExecPodShell(
 		return fmt.Errorf("pod is not running (status: %s)", podObj.Status.Phase)
 	}
 
+	shells := shellFallbackChain
+	if len(command) > 0 {
+		shells = [][]string{command}
+	}
+	err = c.runShellChain(ctx, shells, stdin, stdout, stderr, sizeQueue, onStart, namespace+"/"+pod,
+		func(command []string, stdin io.Reader, stdout, stderr io.Writer, sizeQueue remotecommand.TerminalSizeQueue, protocol *atomic.Value) error {
+			return c.streamPodExec(ctx, namespace, pod, container, command, stdin, stdout, stderr, sizeQueue, protocol)
+		})
+	if err != nil && len(command) > 0 {
+		return err
+	}
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("no shell found in container %s: %w", container, err)
+	}
+	return err
+}
+
+// shellStream streams one shell attempt with its own stdin, output writers and size queue
+type shellStream func(command []string, stdin io.Reader, stdout, stderr io.Writer, sizeQueue remotecommand.TerminalSizeQueue, protocol *atomic.Value) error
+
+// runShellChain executes each shell in turn until one produces output or keeps running for
+// shellStartTimeout, then calls onStart and waits for it to exit. A shell failing before
+// then falls back to the next one. It returns nil when a shell exits cleanly (a quick
+// `exit`), and the last attempt's error when every shell fails.
+func (c *Client) runShellChain(
+	ctx context.Context,
+	shells [][]string,
+	stdin io.Reader,
+	stdout io.Writer,
+	stderr io.Writer,
+	sizeQueue remotecommand.TerminalSizeQueue,
+	onStart func(command []string, protocol string),
+	target string,
+	stream shellStream,
+) error {
+	attempts := newShellAttempts(ctx, stdin, sizeQueue)
+	var lastErr error
+	for _, shell := range shells {
+		attemptStdin, attemptSizes, abandon := attempts.next()
+		var protocol atomic.Value
+		started := make(chan struct{})
+		start := sync.OnceFunc(func() {
+			c.logf("[Exec] Started shell %v in %s over %s", shell, target, protocol.Load())
+			onStart(shell, protocol.Load().(string))
+			close(started)
+		})
+		result := make(chan error, 1)
+		go func() {
+			result <- stream(shell, attemptStdin, startOnWrite(stdout, start), startOnWrite(stderr, start), attemptSizes, &protocol)
+		}()
+
+		select {
+		case <-started:
+			return <-result
+		case err := <-result:
+			select {
+			case <-started:
+				return err // Ran, then failed: not a missing shell
+			default:
+			}
+			if err == nil {
+				start() // Ran and exited silently, e.g. a quick `exit`
+				return nil
+			}
+			if ctx.Err() != nil {
+				return err // The client went away
+			}
+			abandon()
+			c.logf("[Exec] %v failed in %s: %v", shell, target, err)
+			lastErr = err
+		case <-time.After(shellStartTimeout):
+			start()
+			return <-result
+		}
+	}
+	return lastErr
+}
+
+// streamPodExec runs a command in a pod container with a TTY until it exits
+// protocol is set to the protocol being tried, and holds the one used once the stream is up
+func (c *Client) streamPodExec(
+	ctx context.Context,
+	namespace string,
+	pod string,
+	container string,
+	command []string,
+	stdin io.Reader,
+	stdout io.Writer,
+	stderr io.Writer,
+	sizeQueue remotecommand.TerminalSizeQueue,
+	protocol *atomic.Value,
+) error {
 	// Build exec request
 	req := c.Clientset.CoreV1().RESTClient().Post().
 		Resource("pods").
@@ -164,33 +395,109 @@ func (c *Client) ExecPodShell(
 			TTY:       true,
 		}, scheme.ParameterCodec)
 
-	// Create SPDY executor
-	exec, err := remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
-	if err != nil {
-		return fmt.Errorf("failed to create executor: %w", err)
-	}
-
 	// Stream with TTY support
-	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+	return c.streamExec(ctx, req.URL(), remotecommand.StreamOptions{
 		Stdin:             stdin,
 		Stdout:            stdout,
 		Stderr:            stderr,
 		Tty:               true,
 		TerminalSizeQueue: sizeQueue,
-	})
+	}, protocol)
+}
 
+// streamExec streams an exec request over SPDY, retrying over WebSocket when SPDY is
+// blocked, which some API servers and corporate proxies do
+// protocol is set to each protocol before it is tried
+func (c *Client) streamExec(ctx context.Context, execURL *url.URL, options remotecommand.StreamOptions, protocol *atomic.Value) error {
+	protocol.Store(ExecProtocolSPDY)
+	exec, err := remotecommand.NewSPDYExecutor(c.config, "POST", execURL)
 	if err != nil {
-		return fmt.Errorf("exec stream error: %w", err)
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+	var established atomic.Bool
+	err = exec.StreamWithContext(ctx, trackEstablished(options, &established))
+	if err != nil && ctx.Err() == nil && !established.Load() && spdyBlocked(err) {
+		c.logf("[Exec] SPDY failed, retrying over WebSocket: %v", err)
+		protocol.Store(ExecProtocolWebSocket)
+		// The WebSocket upgrade is a GET, unlike SPDY's POST
+		exec, err = remotecommand.NewWebSocketExecutor(c.config, "GET", execURL.String())
+		if err != nil {
+			return fmt.Errorf("failed to create WebSocket executor: %w", err)
+		}
+		err = exec.StreamWithContext(ctx, options)
 	}
 
+	if err != nil {
+		return fmt.Errorf("exec stream error: %w", err)
+	}
 	return nil
 }
 
+// trackEstablished returns options whose streams set established once used, which only
+// happens after the upgrade succeeded: a connection reset afterwards is the session
+// failing, not SPDY being blocked, and retrying it would run the command twice
+func trackEstablished(options remotecommand.StreamOptions, established *atomic.Bool) remotecommand.StreamOptions {
+	if options.Stdin != nil {
+		options.Stdin = &establishedReader{r: options.Stdin, established: established}
+	}
+	if options.Stdout != nil {
+		options.Stdout = &establishedWriter{w: options.Stdout, established: established}
+	}
+	if options.Stderr != nil {
+		options.Stderr = &establishedWriter{w: options.Stderr, established: established}
+	}
+	return options
+}
+
+type establishedReader struct {
+	r           io.Reader
+	established *atomic.Bool
+}
+
+func (e *establishedReader) Read(p []byte) (int, error) {
+	e.established.Store(true)
+	return e.r.Read(p)
+}
+
+type establishedWriter struct {
+	w           io.Writer
+	established *atomic.Bool
+}
+
+func (e *establishedWriter) Write(p []byte) (int, error) {
+	e.established.Store(true)
+	return e.w.Write(p)
+}
+
+// spdyBlocked reports whether an exec error means the SPDY upgrade itself was rejected
+// (a 403 or a reset connection rather than the command failing). Only meaningful for
+// errors returned before the stream was established, when WebSocket can be tried with
+// the same streams.
+func spdyBlocked(err error) bool {
+	message := err.Error()
+	return httpstream.IsUpgradeFailure(err) ||
+		apierrors.IsForbidden(err) ||
+		strings.Contains(message, "SPDY") ||
+		strings.Contains(message, "unable to upgrade connection") ||
+		strings.Contains(message, "connection reset by peer")
+}
+
 // NodeDebugPodOptions configures the debug pod for node shell access
 type NodeDebugPodOptions struct {
-	Image          string // Debug image (default: busybox:latest)
-	Namespace      string // Namespace for debug pod (default: kube-system)
-	TimeoutSeconds int    // Pod ready timeout (default: 120)
+	Image            string   // Debug image (default: busybox:latest)
+	ImageRegistry    string   // Registry prepended to Image, e.g. my-registry.corp (default: none)
+	ImagePullSecrets []string // Secrets in Namespace used to pull Image
+	Namespace        string   // Namespace for debug pod (default: kube-system)
+	TimeoutSeconds   int      // Pod ready timeout (default: 120)
+	Shell            string   // Shell run on the node, e.g. /bin/zsh (default: "" = bash, then sh)
+}
+
+// ImageRef returns the debug image prefixed with the image registry, if any
+func (o NodeDebugPodOptions) ImageRef() string {
+	if o.ImageRegistry == "" {
+		return o.Image
+	}
+	return strings.TrimSuffix(o.ImageRegistry, "/") + "/" + o.Image
 }
 
 // DefaultNodeDebugPodOptions returns default options for node debug pods
@@ -216,26 +523,35 @@ func (c *Client) CreateNodeDebugPod(ctx context.Context, nodeName string, opts N
 
 	// Create privileged pod spec
 	privileged := true
+	pullSecrets := []corev1.LocalObjectReference{}
+	for _, name := range opts.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: name})
+	}
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      podName,
 			Namespace: opts.Namespace,
 			Labels: map[string]string{
-				"app":          "k8v-debug",
-				"k8v.io/node":  nodeName,
-				"k8v.io/debug": "true",
+				"app":             "k8v-debug",
+				"k8v.io/node":     nodeName,
+				"k8v.io/debug":    "true",
+				debugPodHostLabel: debugPodHost(),
+			},
+			Annotations: map[string]string{
+				debugPodPIDAnnotation: strconv.Itoa(os.Getpid()),
 			},
 		},
 		Spec: corev1.PodSpec{
-			NodeName:      nodeName, // Schedule on specific node
-			HostPID:       true,
-			HostNetwork:   true,
-			HostIPC:       true,
-			RestartPolicy: corev1.RestartPolicyNever,
+			NodeName:         nodeName, // Schedule on specific node
+			HostPID:          true,
+			HostNetwork:      true,
+			HostIPC:          true,
+			RestartPolicy:    corev1.RestartPolicyNever,
+			ImagePullSecrets: pullSecrets,
 			Containers: []corev1.Container{
 				{
 					Name:            "debug",
-					Image:           opts.Image,
+					Image:           opts.ImageRef(),
 					ImagePullPolicy: corev1.PullIfNotPresent,
 					Command:         []string{"sleep", "infinity"},
 					SecurityContext: &corev1.SecurityContext{
@@ -282,6 +598,81 @@ func (c *Client) DeleteNodeDebugPod(ctx context.Context, namespace, podName stri
 	return nil
 }
 
+// debugPodLabelSelector selects the debug pods created by CreateNodeDebugPod
+const debugPodLabelSelector = "k8v.io/debug=true"
+
+// Debug pods record the k8v process that created them, so cleanup only deletes the
+// pods of processes on this machine that are gone, never those of other users' or
+// still running instances
+const (
+	debugPodHostLabel     = "k8v.io/host"
+	debugPodPIDAnnotation = "k8v.io/pid"
+)
+
+// debugPodHost returns this machine's hostname as a label value
+func debugPodHost() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	value := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, host)
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	// Label values must start and end with an alphanumeric character
+	value = strings.Trim(value, "-_.")
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// processAlive reports whether a process with pid is running on this machine
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false // Windows opens the process, failing when there is none
+	}
+	defer process.Release()
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM) // EPERM: another user's process
+}
+
+// DeleteOrphanedDebugPods deletes debug pods in namespace older than minAge, which were
+// left behind by a k8v process that exited without ending its node shell sessions
+// Only pods created on this machine by a process that is no longer running are deleted.
+func (c *Client) DeleteOrphanedDebugPods(ctx context.Context, namespace string, minAge time.Duration) error {
+	selector := debugPodLabelSelector + "," + debugPodHostLabel + "=" + debugPodHost()
+	pods, err := c.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list debug pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		age := time.Since(pod.CreationTimestamp.Time)
+		if age < minAge {
+			continue // Possibly an active session
+		}
+		if pid, err := strconv.Atoi(pod.Annotations[debugPodPIDAnnotation]); err != nil || processAlive(pid) {
+			continue // Another live instance's session, or a pod we can't attribute
+		}
+		if err := c.Clientset.CoreV1().Pods(namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+			c.logf("[NodeExec] Failed to delete orphaned debug pod %s/%s: %v", namespace, pod.Name, err)
+			continue
+		}
+		c.logf("[NodeExec] Deleted orphaned debug pod %s/%s (age %s)", namespace, pod.Name, age.Round(time.Second))
+	}
+	return nil
+}
+
 // WaitForPodReady waits for a pod to be running and ready
 func (c *Client) WaitForPodReady(ctx context.Context, namespace, podName string, timeoutSeconds int) error {
 	timeout := time.Duration(timeoutSeconds) * time.Second
@@ -320,61 +711,105 @@ func (c *Client) WaitForPodReady(ctx context.Context, namespace, podName string,
 	return fmt.Errorf("timeout waiting for debug pod to be ready")
 }
 
+// nodeShellFallbackChain is tried in order on the host when NodeDebugPodOptions.Shell is empty
+var nodeShellFallbackChain = [][]string{
+	{"/bin/bash", "--login"},
+	{"/bin/sh", "-l"},
+	{"/bin/sh"},
+}
+
 // ExecNodeDebugShell creates an interactive shell session in the debug pod
-// It runs "chroot /host bash -l" to get full node access with a login shell
+// It chroots into the host filesystem and runs shell as a login shell, or the node shell
+// fallback chain when shell is empty. TERM and HOME are set by env when the host has it;
+// minimal OS images without env get the bare shell. onStart is called with the shell that
+// started, without the chroot prefix, and the protocol.
 func (c *Client) ExecNodeDebugShell(
 	ctx context.Context,
 	namespace string,
 	podName string,
+	shell string,
 	stdin io.Reader,
 	stdout io.Writer,
 	stderr io.Writer,
 	sizeQueue remotecommand.TerminalSizeQueue,
+	onStart func(command []string, protocol string),
 ) error {
-	// Build exec request with chroot command
-	// Use env to set TERM and HOME, then run bash as interactive login shell
-	command := []string{
-		"chroot", "/host",
-		"/usr/bin/env",
-		"TERM=xterm-256color",
-		"HOME=/root",
-		"/bin/bash", "--login",
+	prefix := []string{"chroot", "/host"}
+	if env := c.probeNodeEnv(ctx, namespace, podName); env != "" {
+		prefix = append(prefix, env, "TERM=xterm-256color", "HOME=/root")
+	} else {
+		c.logf("[NodeExec] No env binary on the host of %s/%s, starting the shell without TERM and HOME", namespace, podName)
 	}
 
-	req := c.Clientset.CoreV1().RESTClient().Post().
-		Resource("pods").
-		Name(podName).
-		Namespace(namespace).
-		SubResource("exec").
-		VersionedParams(&corev1.PodExecOptions{
-			Container: "debug",
-			Command:   command,
-			Stdin:     stdin != nil,
-			Stdout:    stdout != nil,
-			Stderr:    stderr != nil,
-			TTY:       true,
-		}, scheme.ParameterCodec)
-
-	// Create SPDY executor
-	exec, err := remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
-	if err != nil {
-		return fmt.Errorf("failed to create executor: %w", err)
+	shells := nodeShellFallbackChain
+	if shell != "" {
+		shells = [][]string{{shell, "-l"}}
+	}
+	commands := make([][]string, 0, len(shells))
+	for _, sh := range shells {
+		commands = append(commands, append(append([]string{}, prefix...), sh...))
 	}
 
 	c.logf("[NodeExec] Starting shell session in %s/%s", namespace, podName)
+	started := func(command []string, protocol string) {
+		onStart(command[len(prefix):], protocol)
+	}
+	err := c.runShellChain(ctx, commands, stdin, stdout, stderr, sizeQueue, started, namespace+"/"+podName,
+		func(command []string, stdin io.Reader, stdout, stderr io.Writer, sizeQueue remotecommand.TerminalSizeQueue, protocol *atomic.Value) error {
+			return c.streamExec(ctx, c.debugPodExecURL(namespace, podName, command, true), remotecommand.StreamOptions{
+				Stdin:             stdin,
+				Stdout:            stdout,
+				Stderr:            stderr,
+				Tty:               true,
+				TerminalSizeQueue: sizeQueue,
+			}, protocol)
+		})
+	c.logf("[NodeExec] Shell session in %s/%s ended", namespace, podName)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("no shell found on node: %w", err)
+	}
+	return err
+}
 
-	// Stream with TTY support
-	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
-		Stdin:             stdin,
-		Stdout:            stdout,
-		Stderr:            stderr,
-		Tty:               true,
-		TerminalSizeQueue: sizeQueue,
-	})
-
+// probeNodeEnv returns the path of env on the host of a debug pod, or "" if it has none
+// It checks /usr/bin/env, then asks the host shell for env on its PATH
+func (c *Client) probeNodeEnv(ctx context.Context, namespace, podName string) string {
+	if _, err := c.runDebugPodCommand(ctx, namespace, podName, []string{"chroot", "/host", "ls", "/usr/bin/env"}); err == nil {
+		return "/usr/bin/env"
+	}
+	out, err := c.runDebugPodCommand(ctx, namespace, podName, []string{"chroot", "/host", "sh", "-c", "command -v env"})
 	if err != nil {
-		return fmt.Errorf("exec stream error: %w", err)
+		return ""
 	}
+	return strings.TrimSpace(out)
+}
 
-	return nil
+// runDebugPodCommand runs a command without a TTY in a debug pod and returns its stdout
+// A non-zero exit status is returned as an error
+func (c *Client) runDebugPodCommand(ctx context.Context, namespace, podName string, command []string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	var protocol atomic.Value
+	err := c.streamExec(ctx, c.debugPodExecURL(namespace, podName, command, false), remotecommand.StreamOptions{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}, &protocol)
+	return stdout.String(), err
+}
+
+// debugPodExecURL builds the exec request URL for a command in a debug pod's container
+func (c *Client) debugPodExecURL(namespace, podName string, command []string, tty bool) *url.URL {
+	return c.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: "debug",
+			Command:   command,
+			Stdin:     tty,
+			Stdout:    true,
+			Stderr:    true,
+			TTY:       tty,
+		}, scheme.ParameterCodec).
+		URL()
 }
@@ -0,0 +1,241 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// fakeShell is how a shellStream attempt behaves for one command
+type fakeShell struct {
+	output string // Written once the shell runs ("" = silent)
+	err    error  // Returned once it has written output
+}
+
+// nilSizeQueue is a TerminalSizeQueue without resizes
+type nilSizeQueue struct{}
+
+func (nilSizeQueue) Next() *remotecommand.TerminalSize { return nil }
+
+// syncBuffer is a bytes.Buffer safe for a stream and the test to use concurrently
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunShellChain(t *testing.T) {
+	t.Parallel()
+
+	missing := errors.New("executable file not found in $PATH")
+	tests := []struct {
+		name        string
+		shells      map[string]fakeShell // By command; absent commands are missing
+		wantStarted string               // "" = no shell started
+		wantErr     bool
+	}{
+		{
+			name:        "first shell prints a prompt",
+			shells:      map[string]fakeShell{"bash": {output: "$ "}},
+			wantStarted: "bash",
+		},
+		{
+			name:        "fall back to a later shell",
+			shells:      map[string]fakeShell{"ash": {output: "/ # "}},
+			wantStarted: "ash",
+		},
+		{
+			name:        "shell failing after its output doesn't fall back",
+			shells:      map[string]fakeShell{"bash": {output: "$ ", err: errors.New("command terminated with exit code 1")}, "sh": {output: "# "}},
+			wantStarted: "bash",
+			wantErr:     true,
+		},
+		{
+			name:        "silent shell exiting cleanly",
+			shells:      map[string]fakeShell{"bash": {}},
+			wantStarted: "bash",
+		},
+		{
+			name:    "no shell",
+			shells:  map[string]fakeShell{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := &Client{logger: log.New(io.Discard, "", 0)}
+			var output syncBuffer
+			var started []string
+			onStart := func(command []string, protocol string) {
+				started = append(started, command[0])
+				output.Write([]byte("[CONNECTED]"))
+			}
+			stream := func(command []string, stdin io.Reader, stdout, stderr io.Writer, sizeQueue remotecommand.TerminalSizeQueue, protocol *atomic.Value) error {
+				protocol.Store(ExecProtocolSPDY)
+				shell, ok := tt.shells[command[0]]
+				if !ok {
+					return missing
+				}
+				if shell.output != "" {
+					stdout.Write([]byte(shell.output))
+				}
+				return shell.err
+			}
+
+			begin := time.Now()
+			err := c.runShellChain(context.Background(), shellFallbackChain, strings.NewReader(""), &output, &output, nilSizeQueue{}, onStart, "default/web", stream)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runShellChain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(begin); elapsed >= shellStartTimeout {
+				t.Errorf("runShellChain() took %v, want no wait for shellStartTimeout", elapsed)
+			}
+
+			if tt.wantStarted == "" {
+				if len(started) != 0 {
+					t.Errorf("onStart called for %v, want no call", started)
+				}
+				return
+			}
+			if len(started) != 1 || started[0] != tt.wantStarted {
+				t.Fatalf("onStart called for %v, want [%s]", started, tt.wantStarted)
+			}
+			// CONNECTED precedes the shell's output
+			if want := "[CONNECTED]" + tt.shells[tt.wantStarted].output; output.String() != want {
+				t.Errorf("output = %q, want %q", output.String(), want)
+			}
+		})
+	}
+}
+
+func TestDeleteOrphanedDebugPods(t *testing.T) {
+	t.Parallel()
+
+	const deadPID = 1 << 30 // Above any pid_max
+	tests := []struct {
+		name        string
+		age         time.Duration
+		host        string
+		pid         int
+		wantDeleted bool
+	}{
+		{"old pod of an exited process", time.Hour, debugPodHost(), deadPID, true},
+		{"recent pod of an exited process", time.Minute, debugPodHost(), deadPID, false},
+		{"old pod of a running process", time.Hour, debugPodHost(), os.Getpid(), false},
+		{"old pod from another machine", time.Hour, "other-host", deadPID, false},
+		{"old pod without a PID", time.Hour, debugPodHost(), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:              "k8v-debug-node-1",
+				Namespace:         "kube-system",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-tt.age)),
+				Labels:            map[string]string{"k8v.io/debug": "true", debugPodHostLabel: tt.host},
+			}}
+			if tt.pid != 0 {
+				pod.Annotations = map[string]string{debugPodPIDAnnotation: strconv.Itoa(tt.pid)}
+			}
+			clientset := fake.NewSimpleClientset(pod)
+			client := NewClientWithFake(clientset)
+			client.logger = log.New(io.Discard, "", 0)
+
+			if err := client.DeleteOrphanedDebugPods(context.Background(), "kube-system", 10*time.Minute); err != nil {
+				t.Fatalf("DeleteOrphanedDebugPods() error = %v", err)
+			}
+			_, err := clientset.CoreV1().Pods("kube-system").Get(context.Background(), pod.Name, metav1.GetOptions{})
+			if deleted := apierrors.IsNotFound(err); deleted != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestStreamExecFallsBackWhenUpgradeIsReset(t *testing.T) {
+	t.Parallel()
+
+	// The API server resets every connection without answering, as a proxy blocking SPDY does
+	var mu sync.Mutex
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		conn.(*net.TCPConn).SetLinger(0) // Close with a RST
+		conn.Close()
+	}))
+	defer server.Close()
+
+	c := &Client{config: &rest.Config{Host: server.URL}, logger: log.New(io.Discard, "", 0)}
+	execURL, _ := url.Parse(server.URL + "/api/v1/namespaces/default/pods/web/exec")
+	var protocol atomic.Value
+	err := c.streamExec(context.Background(), execURL, remotecommand.StreamOptions{Stdout: io.Discard, Tty: true}, &protocol)
+	if err == nil {
+		t.Fatal("streamExec() succeeded against a server resetting connections")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(methods) != 2 || methods[0] != http.MethodPost || methods[1] != http.MethodGet {
+		t.Errorf("requests = %v, want the SPDY POST then the WebSocket GET", methods)
+	}
+	if got := protocol.Load(); got != ExecProtocolWebSocket {
+		t.Errorf("protocol = %v, want %s", got, ExecProtocolWebSocket)
+	}
+}
+
+func TestTrackEstablished(t *testing.T) {
+	t.Parallel()
+
+	var established atomic.Bool
+	options := trackEstablished(remotecommand.StreamOptions{Stdin: strings.NewReader("ls\n"), Stdout: io.Discard}, &established)
+	if options.Stderr != nil {
+		t.Error("trackEstablished() set a stream that wasn't requested")
+	}
+	if established.Load() {
+		t.Fatal("established before any stream was used")
+	}
+	options.Stdin.Read(make([]byte, 8))
+	if !established.Load() {
+		t.Error("reading stdin didn't mark the stream established")
+	}
+}
@@ -0,0 +1,172 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// HelmReleaseType is the type of the synthetic resources grouping a Helm release's members
+// They aren't backed by a Kubernetes API and only exist with -enable-helm-grouping
+const HelmReleaseType = "HelmRelease"
+
+// Labels Helm sets on the resources of a release
+const (
+	helmManagedByLabel = "app.kubernetes.io/managed-by"
+	helmInstanceLabel  = "app.kubernetes.io/instance"
+	helmChartLabel     = "helm.sh/chart"
+)
+
+// helmGrouping maintains HelmRelease resources from the events of their members
+type helmGrouping struct {
+	mu      sync.Mutex
+	members map[string]string // member ID -> HelmRelease ID
+}
+
+func newHelmGrouping() *helmGrouping {
+	return &helmGrouping{members: make(map[string]string)}
+}
+
+// helmReleaseID returns the HelmRelease a resource belongs to, or "" if it isn't
+// managed by Helm
+func helmReleaseID(resource *types.Resource) string {
+	if resource.Labels[helmManagedByLabel] != "Helm" || resource.Labels[helmInstanceLabel] == "" {
+		return ""
+	}
+	return types.BuildID(HelmReleaseType, resource.Namespace, resource.Labels[helmInstanceLabel])
+}
+
+// observeHelmMember updates the HelmRelease of a member after its event, emitting events
+// for the releases that changed. Called by emit for every event, before event filters.
+func (w *Watcher) observeHelmMember(event ResourceEvent) {
+	member := event.Resource
+	if member.Type == HelmReleaseType {
+		return
+	}
+
+	newID := ""
+	if event.Type != EventDeleted {
+		newID = helmReleaseID(member)
+	}
+
+	w.helm.mu.Lock()
+	oldID := w.helm.members[member.ID]
+	if newID == "" {
+		delete(w.helm.members, member.ID)
+	} else {
+		w.helm.members[member.ID] = newID
+	}
+	var changed []ResourceEvent
+	if oldID != "" && oldID != newID {
+		changed = append(changed, w.updateHelmReleaseLocked(oldID, member, false))
+	}
+	if newID != "" {
+		changed = append(changed, w.updateHelmReleaseLocked(newID, member, true))
+	}
+	w.helm.mu.Unlock()
+
+	for _, e := range changed {
+		if e.Resource != nil {
+			w.emit(e)
+		}
+	}
+}
+
+// updateHelmReleaseLocked adds or removes a member and recomputes the release's status,
+// returning the event to emit (with a nil Resource if nothing changed)
+// Callers must hold helm.mu
+func (w *Watcher) updateHelmReleaseLocked(releaseID string, member *types.Resource, isMember bool) ResourceEvent {
+	ref := types.NewResourceRef(member.Type, member.Namespace, member.Name)
+	existing, found := w.cache.Get(releaseID)
+
+	var release types.Resource
+	if found {
+		release = *existing // Copy, readers may hold the cached one
+	} else {
+		release = types.Resource{
+			ID:          releaseID,
+			Type:        HelmReleaseType,
+			Name:        member.Labels[helmInstanceLabel],
+			Namespace:   member.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+			CreatedAt:   member.CreatedAt,
+		}
+	}
+
+	owns := []types.ResourceRef{}
+	for _, r := range release.Relationships.Owns {
+		if r.ID != ref.ID {
+			owns = append(owns, r)
+		}
+	}
+	if isMember {
+		owns = append(owns, ref)
+	}
+	sort.Slice(owns, func(i, j int) bool { return owns[i].ID < owns[j].ID })
+	release.Relationships.Owns = owns
+
+	if len(owns) == 0 {
+		if !found {
+			return ResourceEvent{}
+		}
+		w.cache.Delete(releaseID)
+		return ResourceEvent{Type: EventDeleted, Resource: existing}
+	}
+
+	chart := ""
+	if spec, err := release.TypedSpec(); err == nil && spec != nil {
+		chart = spec.(*types.HelmReleaseSpec).Chart
+	}
+	if isMember && member.Labels[helmChartLabel] != "" {
+		chart = member.Labels[helmChartLabel]
+	}
+	if isMember && member.CreatedAt.Before(release.CreatedAt) {
+		release.CreatedAt = member.CreatedAt
+	}
+	release.Spec = types.NewSpec(types.HelmReleaseSpec{Release: release.Name, Chart: chart, Resources: len(owns)})
+	release.Health, release.Status = w.helmReleaseHealth(owns, member, isMember)
+
+	w.cache.Set(&release)
+	if found {
+		return ResourceEvent{Type: EventModified, Resource: &release}
+	}
+	return ResourceEvent{Type: EventAdded, Resource: &release}
+}
+
+// helmReleaseHealth returns the worst health of a release's members
+// The member being updated may not be stored yet (batched initial load), so it's passed in
+func (w *Watcher) helmReleaseHealth(owns []types.ResourceRef, member *types.Resource, isMember bool) (types.HealthState, types.ResourceStatus) {
+	health := types.HealthHealthy
+	unhealthy := 0
+	for _, ref := range owns {
+		r, ok := w.cache.Get(ref.ID)
+		if ref.ID == member.ID && isMember {
+			r, ok = member, true
+		}
+		if !ok {
+			continue
+		}
+		switch r.Health {
+		case types.HealthError:
+			health = types.HealthError
+			unhealthy++
+		case types.HealthWarning:
+			if health != types.HealthError {
+				health = types.HealthWarning
+			}
+			unhealthy++
+		}
+	}
+
+	status := types.ResourceStatus{
+		Phase: "Deployed",
+		Ready: fmt.Sprintf("%d/%d", len(owns)-unhealthy, len(owns)),
+	}
+	if unhealthy > 0 {
+		status.Message = fmt.Sprintf("%d of %d resources unhealthy", unhealthy, len(owns))
+	}
+	return health, status
+}
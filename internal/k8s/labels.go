@@ -0,0 +1,86 @@
+package k8s
+
+import (
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// LabelComplianceViolation is a Pod missing one or more required labels
+type LabelComplianceViolation struct {
+	Pod           string            `json:"pod"`
+	Namespace     string            `json:"namespace"`
+	MissingLabels []string          `json:"missingLabels"`
+	Labels        map[string]string `json:"labels"`
+}
+
+// ParseList splits a comma-separated flag value, trimming spaces and ignoring empty entries
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// missingLabels returns the required label keys absent from labels
+func missingLabels(labels map[string]string, required []string) []string {
+	var missing []string
+	for _, key := range required {
+		if _, ok := labels[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// GetLabelComplianceViolations returns cached Pods in a namespace ("" for all) that are
+// missing any of the required labels
+func (w *Watcher) GetLabelComplianceViolations(namespace string, required []string) []LabelComplianceViolation {
+	result := []LabelComplianceViolation{}
+	for _, pod := range w.cache.ListByType("Pod") {
+		if namespace != "" && pod.Namespace != namespace {
+			continue
+		}
+		if missing := missingLabels(pod.Labels, required); len(missing) > 0 {
+			result = append(result, LabelComplianceViolation{
+				Pod:           pod.Name,
+				Namespace:     pod.Namespace,
+				MissingLabels: missing,
+				Labels:        pod.Labels,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Pod < result[j].Pod
+	})
+	return result
+}
+
+// applyRequiredLabels downgrades a healthy Deployment to a warning when its pod template
+// is missing any of the labels configured in WatcherOptions.RequiredLabels
+func (w *Watcher) applyRequiredLabels(resource *types.Resource, deployment *appsv1.Deployment) {
+	missing := missingLabels(deployment.Spec.Template.Labels, w.options.RequiredLabels)
+	if len(missing) == 0 {
+		return
+	}
+
+	if resource.Health == types.HealthHealthy {
+		resource.Health = types.HealthWarning
+	}
+	message := "Pod template missing required labels: " + strings.Join(missing, ", ")
+	if resource.Status.Message == "" {
+		resource.Status.Message = message
+	} else {
+		resource.Status.Message += "; " + message
+	}
+}
@@ -3,7 +3,11 @@ package k8s
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,6 +19,16 @@ type LogMessage struct {
 	Line   string `json:"line,omitempty"`
 	Reason string `json:"reason,omitempty"`
 	Error  string `json:"error,omitempty"`
+
+	Previous *ContainerExitInfo `json:"previous,omitempty"` // Set on LOG_PREVIOUS_INFO messages
+}
+
+// ContainerExitInfo describes how the previous instance of a restarted container terminated
+type ContainerExitInfo struct {
+	ExitCode     int32     `json:"exitCode"`
+	Reason       string    `json:"reason,omitempty"` // e.g. "OOMKilled", "Error"
+	FinishedAt   time.Time `json:"finishedAt"`
+	RestartCount int32     `json:"restartCount"`
 }
 
 // LogOptions represents options for streaming pod logs
@@ -23,6 +37,56 @@ type LogOptions struct {
 	HeadLines    *int64 // Limit to first N lines (not supported by K8s API, implemented by counting)
 	SinceSeconds *int64
 	Follow       bool
+	Previous     bool // Stream logs of the previous (terminated) container instance
+
+	Grep       string // Only stream lines containing Grep ("" = every line); HeadLines counts matches
+	GrepInvert bool   // Only stream lines not matching Grep
+	GrepRegex  bool   // Match Grep as a regular expression instead of a substring
+}
+
+// LineMatcher returns the filter selecting the lines to stream, or nil without Grep
+// It fails if GrepRegex is set and Grep doesn't compile.
+func (o LogOptions) LineMatcher() (func(line string) bool, error) {
+	if o.Grep == "" {
+		return nil, nil
+	}
+	matches := func(line string) bool { return strings.Contains(line, o.Grep) }
+	if o.GrepRegex {
+		re, err := regexp.Compile(o.Grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+		matches = re.MatchString
+	}
+	return func(line string) bool { return matches(line) != o.GrepInvert }, nil
+}
+
+// ListAllPodLogs returns the logs of every init and app container of a Pod, keyed by
+// container name: the last tailLines lines, or all of them when tailLines is 0.
+// Containers whose logs can't be read (e.g. not started yet) are left out and reported
+// in the returned error, alongside the logs that could be read.
+func (c *Client) ListAllPodLogs(ctx context.Context, namespace, podName string, tailLines int64) (map[string]string, error) {
+	pod, err := c.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("pod not found: %w", err)
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	logs := make(map[string]string, len(containers))
+	var errs []error
+	for _, container := range containers {
+		logOptions := &corev1.PodLogOptions{Container: container.Name}
+		if tailLines > 0 {
+			logOptions.TailLines = &tailLines
+		}
+		data, err := c.Clientset.CoreV1().Pods(namespace).GetLogs(podName, logOptions).DoRaw(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("container %s: %w", container.Name, err))
+			continue
+		}
+		logs[container.Name] = string(data)
+	}
+	return logs, errors.Join(errs...)
 }
 
 // StreamPodLogs streams logs from a specific pod container to the broadcast channel
@@ -52,11 +116,17 @@ func (c *Client) StreamPodLogs(
 		return fmt.Errorf("container not found: %s", containerName)
 	}
 
+	matches, err := opts.LineMatcher()
+	if err != nil {
+		return err
+	}
+
 	// Configure log options
 	logOptions := &corev1.PodLogOptions{
 		Container:  containerName,
 		Follow:     opts.Follow,
 		Timestamps: true,
+		Previous:   opts.Previous,
 	}
 	if opts.TailLines != nil {
 		logOptions.TailLines = opts.TailLines
@@ -77,10 +147,14 @@ func (c *Client) StreamPodLogs(
 	scanner := bufio.NewScanner(stream)
 	lineCount := int64(0)
 	for scanner.Scan() {
+		line := scanner.Text()
+		if matches != nil && !matches(line) {
+			continue
+		}
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case broadcast <- LogMessage{Type: "LOG_LINE", Line: scanner.Text() + "\n"}:
+		case broadcast <- LogMessage{Type: "LOG_LINE", Line: line + "\n"}:
 			// Sent successfully
 			lineCount++
 			// Stop if we've reached the head limit
@@ -99,3 +173,73 @@ func (c *Client) StreamPodLogs(
 	broadcast <- LogMessage{Type: "LOG_END", Reason: "EOF"}
 	return nil
 }
+
+// GetPreviousContainerExitInfo returns the termination state of a container's previous instance
+// It returns an error if the container has never restarted, so there are no previous logs
+func (c *Client) GetPreviousContainerExitInfo(ctx context.Context, namespace, podName, containerName string) (*ContainerExitInfo, error) {
+	pod, err := c.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("pod not found: %w", err)
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+		terminated := status.LastTerminationState.Terminated
+		if terminated == nil {
+			return nil, fmt.Errorf("container %s has not restarted, so there are no previous logs", containerName)
+		}
+		return &ContainerExitInfo{
+			ExitCode:     terminated.ExitCode,
+			Reason:       terminated.Reason,
+			FinishedAt:   terminated.FinishedAt.Time,
+			RestartCount: status.RestartCount,
+		}, nil
+	}
+	return nil, fmt.Errorf("container not found: %s", containerName)
+}
+
+// DefaultLogBufferLines is the number of recent log lines kept per container for new clients
+const DefaultLogBufferLines = 500
+
+// LogRingBuffer keeps the most recent log messages of a container, dropping the oldest when full
+// It is not safe for concurrent use; callers must synchronize access
+type LogRingBuffer struct {
+	messages []LogMessage
+	start    int // index of the oldest message
+	size     int
+}
+
+// NewLogRingBuffer creates a ring buffer holding up to capacity messages
+func NewLogRingBuffer(capacity int) *LogRingBuffer {
+	return &LogRingBuffer{messages: make([]LogMessage, capacity)}
+}
+
+// Add appends a message, overwriting the oldest one when the buffer is full
+func (b *LogRingBuffer) Add(message LogMessage) {
+	if len(b.messages) == 0 {
+		return
+	}
+	if b.size < len(b.messages) {
+		b.messages[(b.start+b.size)%len(b.messages)] = message
+		b.size++
+		return
+	}
+	b.messages[b.start] = message
+	b.start = (b.start + 1) % len(b.messages)
+}
+
+// Messages returns the buffered messages, oldest first
+func (b *LogRingBuffer) Messages() []LogMessage {
+	result := make([]LogMessage, b.size)
+	for i := range result {
+		result[i] = b.messages[(b.start+i)%len(b.messages)]
+	}
+	return result
+}
+
+// Len returns the number of buffered messages
+func (b *LogRingBuffer) Len() int {
+	return b.size
+}
@@ -0,0 +1,125 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// uncoveredPodMessage is appended to the status message of Pods not selected by any NetworkPolicy
+const uncoveredPodMessage = "Not selected by any NetworkPolicy (unrestricted ingress/egress)"
+
+// UncoveredPod is a Pod that no NetworkPolicy in its namespace selects
+type UncoveredPod struct {
+	Pod       string            `json:"pod"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// GetUncoveredPods returns Pods in a namespace ("" for all namespaces) that are not
+// selected by any NetworkPolicy and therefore have unrestricted ingress and egress
+func (c *Client) GetUncoveredPods(ctx context.Context, namespace string) ([]UncoveredPod, error) {
+	policyList, err := c.Clientset.NetworkingV1().NetworkPolicies(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network policies: %w", err)
+	}
+	policiesByNamespace := make(map[string][]*netv1.NetworkPolicy)
+	for i := range policyList.Items {
+		policy := &policyList.Items[i]
+		policiesByNamespace[policy.Namespace] = append(policiesByNamespace[policy.Namespace], policy)
+	}
+
+	pods, err := c.InformerFactory.Core().V1().Pods().Lister().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	result := []UncoveredPod{}
+	for _, pod := range pods {
+		if !PodSelectedByNetworkPolicy(pod, policiesByNamespace[pod.Namespace]) {
+			result = append(result, UncoveredPod{
+				Pod:       pod.Name,
+				Namespace: pod.Namespace,
+				Labels:    pod.Labels,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Pod < result[j].Pod
+	})
+	return result, nil
+}
+
+// PodSelectedByNetworkPolicy reports whether any of the policies (which must be in the
+// Pod's namespace) selects the Pod. An empty podSelector selects every Pod.
+func PodSelectedByNetworkPolicy(pod *v1.Pod, policies []*netv1.NetworkPolicy) bool {
+	for _, policy := range policies {
+		if policy.Namespace != pod.Namespace {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// registerNetworkPolicyCoverage watches NetworkPolicies so Pod health can reflect coverage
+func (w *Watcher) registerNetworkPolicyCoverage() {
+	informer := w.client.InformerFactory.Networking().V1().NetworkPolicies().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.handleNetworkPolicyChange,
+		UpdateFunc: func(oldObj, newObj interface{}) { w.handleNetworkPolicyChange(newObj) },
+		DeleteFunc: w.handleNetworkPolicyChange,
+	})
+	w.client.trackInformer("NetworkPolicies", informer.HasSynced)
+}
+
+// handleNetworkPolicyChange re-evaluates the health of all Pods in the policy's namespace
+func (w *Watcher) handleNetworkPolicyChange(obj interface{}) {
+	policy, ok := deletedObject(obj).(*netv1.NetworkPolicy)
+	if !ok {
+		return
+	}
+
+	pods, err := w.client.InformerFactory.Core().V1().Pods().Lister().Pods(policy.Namespace).List(labels.Everything())
+	if err != nil {
+		return
+	}
+	for _, pod := range pods {
+		w.handlePodUpdate(nil, pod)
+	}
+}
+
+// applyNetworkPolicyCoverage downgrades healthy Pods to a warning when no NetworkPolicy selects them
+func (w *Watcher) applyNetworkPolicyCoverage(resource *types.Resource, pod *v1.Pod) {
+	policies, err := w.client.InformerFactory.Networking().V1().NetworkPolicies().Lister().NetworkPolicies(pod.Namespace).List(labels.Everything())
+	if err != nil || PodSelectedByNetworkPolicy(pod, policies) {
+		return
+	}
+
+	if resource.Health == types.HealthHealthy {
+		resource.Health = types.HealthWarning
+	}
+	if resource.Status.Message == "" {
+		resource.Status.Message = uncoveredPodMessage
+	} else {
+		resource.Status.Message += "; " + uncoveredPodMessage
+	}
+}
@@ -0,0 +1,93 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+// MetadataPatch describes label and annotation changes to apply to a resource
+type MetadataPatch struct {
+	AddLabels         map[string]string
+	RemoveLabels      []string
+	AddAnnotations    map[string]string
+	RemoveAnnotations []string
+}
+
+// mergePatch builds a JSON merge patch; removed keys are set to null
+func (p MetadataPatch) mergePatch() ([]byte, error) {
+	metadata := map[string]interface{}{}
+	if labels := mergeKeys(p.AddLabels, p.RemoveLabels); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	if annotations := mergeKeys(p.AddAnnotations, p.RemoveAnnotations); len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+	return json.Marshal(map[string]interface{}{"metadata": metadata})
+}
+
+func mergeKeys(add map[string]string, remove []string) map[string]interface{} {
+	merged := make(map[string]interface{}, len(add)+len(remove))
+	for _, key := range remove {
+		merged[key] = nil
+	}
+	for key, value := range add {
+		merged[key] = value
+	}
+	return merged
+}
+
+// IsClusterScopedType reports whether resources of the given type have no namespace
+func IsClusterScopedType(resourceType string) bool {
+	switch resourceType {
+	case "Node", "StorageClass":
+		return true
+	}
+	return false
+}
+
+// PatchMetadata applies label and annotation changes to a resource with a JSON merge
+// patch. dryRun is passed through to the API server (e.g. []string{"All"}).
+func (c *Client) PatchMetadata(ctx context.Context, resourceType, namespace, name string, patch MetadataPatch, dryRun []string) error {
+	data, err := patch.mergePatch()
+	if err != nil {
+		return fmt.Errorf("failed to build patch: %w", err)
+	}
+	opts := metav1.PatchOptions{DryRun: dryRun}
+	pt := k8stypes.MergePatchType
+
+	switch resourceType {
+	case "Pod":
+		_, err = c.Clientset.CoreV1().Pods(namespace).Patch(ctx, name, pt, data, opts)
+	case "Deployment":
+		_, err = c.Clientset.AppsV1().Deployments(namespace).Patch(ctx, name, pt, data, opts)
+	case "ReplicaSet":
+		_, err = c.Clientset.AppsV1().ReplicaSets(namespace).Patch(ctx, name, pt, data, opts)
+	case "Service":
+		_, err = c.Clientset.CoreV1().Services(namespace).Patch(ctx, name, pt, data, opts)
+	case "Ingress":
+		_, err = c.Clientset.NetworkingV1().Ingresses(namespace).Patch(ctx, name, pt, data, opts)
+	case "ConfigMap":
+		_, err = c.Clientset.CoreV1().ConfigMaps(namespace).Patch(ctx, name, pt, data, opts)
+	case "Secret":
+		_, err = c.Clientset.CoreV1().Secrets(namespace).Patch(ctx, name, pt, data, opts)
+	case "PersistentVolumeClaim":
+		_, err = c.Clientset.CoreV1().PersistentVolumeClaims(namespace).Patch(ctx, name, pt, data, opts)
+	case "PodDisruptionBudget":
+		_, err = c.Clientset.PolicyV1().PodDisruptionBudgets(namespace).Patch(ctx, name, pt, data, opts)
+	case "Node":
+		_, err = c.Clientset.CoreV1().Nodes().Patch(ctx, name, pt, data, opts)
+	case "StorageClass":
+		_, err = c.Clientset.StorageV1().StorageClasses().Patch(ctx, name, pt, data, opts)
+	default:
+		return fmt.Errorf("unsupported resource type: %s", resourceType)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to patch %s %s: %w", resourceType, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package k8s
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// Dump writes every cached resource to path as gzip-compressed JSON, without Secret data
+// The file is written to a temporary name and renamed, so a crash mid-dump never
+// leaves a truncated cache file behind
+func (c *ResourceCache) Dump(path string) error {
+	resources := RedactSecrets(c.List())
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	gz := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(gz).Encode(resources); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compress cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+	return nil
+}
+
+// Restore loads resources written by Dump into the cache
+// Restored resources are marked until an informer delivers them again; call PruneRestored
+// after the initial sync to drop the ones deleted from the cluster in the meantime
+func (c *ResourceCache) Restore(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open cache file: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to decompress cache file: %w", err)
+	}
+	defer gz.Close()
+
+	var resources []*types.Resource
+	if err := json.NewDecoder(gz).Decode(&resources); err != nil {
+		return fmt.Errorf("failed to decode cache file: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range resources {
+		if _, ok := c.resources[r.ID]; ok {
+			continue // Never replace live data with the file's copy
+		}
+		c.setLocked(r)
+		c.restored[r.ID] = true
+	}
+	return nil
+}
+
+// PruneRestored deletes the restored resources that no informer has delivered since
+// Restore and returns them, so callers can emit delete events
+func (c *ResourceCache) PruneRestored() []*types.Resource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pruned := []*types.Resource{}
+	for id := range c.restored {
+		if r, ok := c.resources[id]; ok {
+			pruned = append(pruned, r)
+		}
+		c.deleteLocked(id)
+	}
+	return pruned
+}
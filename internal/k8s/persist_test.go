@@ -0,0 +1,97 @@
+package k8s
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDumpRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	source := NewResourceCache()
+	defer source.Close()
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Annotations: map[string]string{
+			v1.LastAppliedConfigAnnotation: `{"data":{"password":"aHVudGVyMg=="}}`,
+			"team":                         "a",
+		}},
+		Data: map[string][]byte{"password": []byte("hunter2")},
+	}
+	source.Set(TransformSecret(secret, source))
+	source.Set(TransformConfigMap(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}}, source))
+
+	path := filepath.Join(t.TempDir(), "cache.json.gz")
+	if err := source.Dump(path); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	restored := NewResourceCache()
+	defer restored.Close()
+	if err := restored.Restore(path); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	got, ok := restored.Get("Secret:default:db")
+	if !ok {
+		t.Fatal("Secret missing from the restored cache")
+	}
+	if got.YAML != "" {
+		t.Errorf("restored Secret YAML = %q, want it cleared", got.YAML)
+	}
+	if _, ok := got.Annotations[v1.LastAppliedConfigAnnotation]; ok {
+		t.Error("restored Secret kept its last-applied-configuration annotation")
+	}
+	if got.Annotations["team"] != "a" {
+		t.Errorf("restored Secret annotations = %v, want the team annotation kept", got.Annotations)
+	}
+	if configMap, _ := restored.Get("ConfigMap:default:settings"); configMap == nil || configMap.YAML == "" {
+		t.Error("ConfigMap YAML was redacted too")
+	}
+
+	// The live cache keeps the Secret as transformed
+	if live, _ := source.Get("Secret:default:db"); !strings.Contains(live.YAML, "password") {
+		t.Error("Dump redacted the cached Secret itself")
+	}
+}
+
+func TestRestoredResourceReplacedByLowerVersion(t *testing.T) {
+	t.Parallel()
+
+	source := NewResourceCache()
+	defer source.Close()
+	source.Set(testResource("Pod", "default", "web", "5000"))
+	source.Set(testResource("Pod", "default", "gone", "5001"))
+	path := filepath.Join(t.TempDir(), "cache.json.gz")
+	if err := source.Dump(path); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	cache := NewResourceCache()
+	defer cache.Close()
+	if err := cache.Restore(path); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	// The cluster was recreated, so its live objects have lower versions than the dump
+	if !cache.Set(testResource("Pod", "default", "web", "12")) {
+		t.Fatal("Set() rejected the live object as stale against the restored copy")
+	}
+	if got, _ := cache.Get("Pod:default:web"); got.ResourceVersion != "12" {
+		t.Errorf("cached ResourceVersion = %q, want the live 12", got.ResourceVersion)
+	}
+
+	// Once confirmed, the resource is an ordinary cached one again
+	if cache.Set(testResource("Pod", "default", "web", "11")) {
+		t.Error("Set() accepted a stale update of a confirmed resource")
+	}
+	pruned := cache.PruneRestored()
+	if len(pruned) != 1 || pruned[0].ID != "Pod:default:gone" {
+		t.Errorf("PruneRestored() = %v, want only Pod:default:gone", pruned)
+	}
+	if !cache.Contains("Pod:default:web") {
+		t.Error("PruneRestored() removed the confirmed resource")
+	}
+}
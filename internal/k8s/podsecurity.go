@@ -0,0 +1,285 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Pod Security Standards levels, from least to most restrictive
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+// PodSecurityViolation lists the Pod Security Standards checks a Pod fails
+type PodSecurityViolation struct {
+	Pod              string   `json:"pod"`
+	Namespace        string   `json:"namespace"`
+	ViolatedStandard string   `json:"violatedStandard"` // Least restrictive level violated ("baseline" or "restricted")
+	Violations       []string `json:"violations"`
+}
+
+// baselineCapabilities may be added under the baseline standard
+var baselineCapabilities = map[v1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true,
+	"KILL": true, "MKNOD": true, "NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true,
+	"SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+// baselineSELinuxTypes are the SELinux types allowed under the baseline standard
+var baselineSELinuxTypes = map[string]bool{
+	"": true, "container_t": true, "container_init_t": true, "container_kvm_t": true, "container_engine_t": true,
+}
+
+// baselineSysctls are the "safe" sysctls allowed under the baseline standard
+var baselineSysctls = map[string]bool{
+	"kernel.shm_rmid_forced": true, "net.ipv4.ip_local_port_range": true, "net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.tcp_syncookies": true, "net.ipv4.ping_group_range": true, "net.ipv4.ip_local_reserved_ports": true,
+	"net.ipv4.tcp_keepalive_time": true, "net.ipv4.tcp_fin_timeout": true, "net.ipv4.tcp_keepalive_intvl": true,
+	"net.ipv4.tcp_keepalive_probes": true,
+}
+
+// GetPodSecurityViolations checks every Pod in a namespace ("" for all namespaces) against
+// the Pod Security Standards, using the same checks as the Pod Security Admission
+// controller. Only Pods that fail at least the restricted level are returned.
+func (c *Client) GetPodSecurityViolations(namespace string) ([]PodSecurityViolation, error) {
+	pods, err := c.InformerFactory.Core().V1().Pods().Lister().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	result := []PodSecurityViolation{}
+	for _, pod := range pods {
+		baseline := checkBaseline(pod)
+		restricted := checkRestricted(pod)
+		if len(baseline) == 0 && len(restricted) == 0 {
+			continue
+		}
+
+		violation := PodSecurityViolation{
+			Pod:              pod.Name,
+			Namespace:        pod.Namespace,
+			ViolatedStandard: PodSecurityRestricted,
+			Violations:       append(baseline, restricted...),
+		}
+		if len(baseline) > 0 {
+			violation.ViolatedStandard = PodSecurityBaseline
+		}
+		result = append(result, violation)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Pod < result[j].Pod
+	})
+	return result, nil
+}
+
+// podContainer is a container of any kind together with its security context
+type podContainer struct {
+	name            string
+	securityContext *v1.SecurityContext
+	ports           []v1.ContainerPort
+}
+
+// allContainers returns init, regular and ephemeral containers of a Pod
+func allContainers(pod *v1.Pod) []podContainer {
+	var containers []podContainer
+	for _, c := range pod.Spec.InitContainers {
+		containers = append(containers, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		containers = append(containers, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	return containers
+}
+
+// checkBaseline returns the baseline standard checks the Pod fails
+func checkBaseline(pod *v1.Pod) []string {
+	var violations []string
+	spec := pod.Spec
+	podSC := spec.SecurityContext
+
+	if spec.HostNetwork {
+		violations = append(violations, "hostNetwork=true")
+	}
+	if spec.HostPID {
+		violations = append(violations, "hostPID=true")
+	}
+	if spec.HostIPC {
+		violations = append(violations, "hostIPC=true")
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("volume %q uses hostPath %s", volume.Name, volume.HostPath.Path))
+		}
+	}
+
+	if podSC != nil {
+		if podSC.WindowsOptions != nil && podSC.WindowsOptions.HostProcess != nil && *podSC.WindowsOptions.HostProcess {
+			violations = append(violations, "pod runs as a Windows HostProcess")
+		}
+		if podSC.SELinuxOptions != nil {
+			violations = append(violations, checkSELinux("pod", podSC.SELinuxOptions)...)
+		}
+		if podSC.SeccompProfile != nil && podSC.SeccompProfile.Type == v1.SeccompProfileTypeUnconfined {
+			violations = append(violations, "pod seccompProfile is Unconfined")
+		}
+		if podSC.AppArmorProfile != nil && podSC.AppArmorProfile.Type == v1.AppArmorProfileTypeUnconfined {
+			violations = append(violations, "pod appArmorProfile is Unconfined")
+		}
+		for _, sysctl := range podSC.Sysctls {
+			if !baselineSysctls[sysctl.Name] {
+				violations = append(violations, fmt.Sprintf("unsafe sysctl %s", sysctl.Name))
+			}
+		}
+	}
+
+	for key, value := range pod.Annotations {
+		if strings.HasPrefix(key, v1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix) &&
+			value != v1.DeprecatedAppArmorBetaProfileRuntimeDefault &&
+			!strings.HasPrefix(value, v1.DeprecatedAppArmorBetaProfileNamePrefix) {
+			violations = append(violations, fmt.Sprintf("AppArmor annotation %s=%s", key, value))
+		}
+	}
+
+	for _, c := range allContainers(pod) {
+		for _, port := range c.ports {
+			if port.HostPort != 0 {
+				violations = append(violations, fmt.Sprintf("container %q uses hostPort %d", c.name, port.HostPort))
+			}
+		}
+
+		sc := c.securityContext
+		if sc == nil {
+			continue
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, fmt.Sprintf("container %q is privileged", c.name))
+		}
+		if sc.WindowsOptions != nil && sc.WindowsOptions.HostProcess != nil && *sc.WindowsOptions.HostProcess {
+			violations = append(violations, fmt.Sprintf("container %q runs as a Windows HostProcess", c.name))
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !baselineCapabilities[capability] {
+					violations = append(violations, fmt.Sprintf("container %q adds capability %s", c.name, capability))
+				}
+			}
+		}
+		if sc.SELinuxOptions != nil {
+			violations = append(violations, checkSELinux(fmt.Sprintf("container %q", c.name), sc.SELinuxOptions)...)
+		}
+		if sc.ProcMount != nil && *sc.ProcMount != v1.DefaultProcMount {
+			violations = append(violations, fmt.Sprintf("container %q uses procMount %s", c.name, *sc.ProcMount))
+		}
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == v1.SeccompProfileTypeUnconfined {
+			violations = append(violations, fmt.Sprintf("container %q seccompProfile is Unconfined", c.name))
+		}
+		if sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == v1.AppArmorProfileTypeUnconfined {
+			violations = append(violations, fmt.Sprintf("container %q appArmorProfile is Unconfined", c.name))
+		}
+	}
+
+	return violations
+}
+
+// checkSELinux returns baseline violations for custom SELinux options
+func checkSELinux(subject string, opts *v1.SELinuxOptions) []string {
+	var violations []string
+	if !baselineSELinuxTypes[opts.Type] {
+		violations = append(violations, fmt.Sprintf("%s uses SELinux type %s", subject, opts.Type))
+	}
+	if opts.User != "" {
+		violations = append(violations, fmt.Sprintf("%s sets SELinux user %s", subject, opts.User))
+	}
+	if opts.Role != "" {
+		violations = append(violations, fmt.Sprintf("%s sets SELinux role %s", subject, opts.Role))
+	}
+	return violations
+}
+
+// checkRestricted returns the additional restricted standard checks the Pod fails
+func checkRestricted(pod *v1.Pod) []string {
+	var violations []string
+	podSC := pod.Spec.SecurityContext
+	if podSC == nil {
+		podSC = &v1.PodSecurityContext{}
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		source := volume.VolumeSource
+		allowed := source.ConfigMap != nil || source.CSI != nil || source.DownwardAPI != nil ||
+			source.EmptyDir != nil || source.Ephemeral != nil || source.PersistentVolumeClaim != nil ||
+			source.Projected != nil || source.Secret != nil
+		// hostPath volumes are already reported by the baseline check
+		if !allowed && source.HostPath == nil {
+			violations = append(violations, fmt.Sprintf("volume %q uses a restricted volume type", volume.Name))
+		}
+	}
+
+	if podSC.RunAsUser != nil && *podSC.RunAsUser == 0 {
+		violations = append(violations, "pod runAsUser=0")
+	}
+
+	for _, c := range allContainers(pod) {
+		sc := c.securityContext
+		if sc == nil {
+			sc = &v1.SecurityContext{}
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("container %q must set allowPrivilegeEscalation=false", c.name))
+		}
+
+		runAsNonRoot := podSC.RunAsNonRoot != nil && *podSC.RunAsNonRoot
+		if sc.RunAsNonRoot != nil {
+			runAsNonRoot = *sc.RunAsNonRoot
+		}
+		if !runAsNonRoot {
+			violations = append(violations, fmt.Sprintf("container %q must set runAsNonRoot=true", c.name))
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			violations = append(violations, fmt.Sprintf("container %q runAsUser=0", c.name))
+		}
+
+		seccomp := podSC.SeccompProfile
+		if sc.SeccompProfile != nil {
+			seccomp = sc.SeccompProfile
+		}
+		if seccomp == nil {
+			violations = append(violations, fmt.Sprintf("container %q must set seccompProfile to RuntimeDefault or Localhost", c.name))
+		}
+
+		dropsAll := false
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Drop {
+				if capability == "ALL" {
+					dropsAll = true
+				}
+			}
+			for _, capability := range sc.Capabilities.Add {
+				// Other capabilities outside the baseline set are already reported by the baseline check
+				if capability != "NET_BIND_SERVICE" && baselineCapabilities[capability] {
+					violations = append(violations, fmt.Sprintf("container %q adds capability %s", c.name, capability))
+				}
+			}
+		}
+		if !dropsAll {
+			violations = append(violations, fmt.Sprintf("container %q must drop ALL capabilities", c.name))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,155 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// DefaultQuotaPressureThreshold is the percentage of a quota's hard limit at which its
+// usage is reported as pressure, and at which QUOTA_PRESSURE events are sent
+const DefaultQuotaPressureThreshold = 75.0
+
+// quotaCriticalPercent is the usage percentage reported as critical pressure
+const quotaCriticalPercent = 90.0
+
+// Quota pressure levels
+const (
+	QuotaPressureHigh     = "high"     // At or above the threshold
+	QuotaPressureCritical = "critical" // At or above quotaCriticalPercent
+	QuotaPressureExceeded = "exceeded" // At or above the hard limit; new Pods are rejected
+)
+
+// QuotaPressure is a ResourceQuota resource whose usage approaches its hard limit
+type QuotaPressure struct {
+	Namespace   string  `json:"namespace"`
+	Quota       string  `json:"quota"`
+	Resource    string  `json:"resource"` // e.g. "requests.cpu", "pods"
+	Used        string  `json:"used"`
+	Hard        string  `json:"hard"`
+	PercentUsed float64 `json:"percentUsed"`
+	Pressure    string  `json:"pressure"`
+}
+
+// quotaPressures returns the resources of a quota used at or above threshold percent
+func quotaPressures(quota *v1.ResourceQuota, threshold float64) []QuotaPressure {
+	var result []QuotaPressure
+	for name, hard := range quota.Status.Hard {
+		limit := hard.AsApproximateFloat64()
+		if limit <= 0 {
+			continue
+		}
+		used := quota.Status.Used[name]
+		percent := used.AsApproximateFloat64() / limit * 100
+		if percent < threshold {
+			continue
+		}
+
+		pressure := QuotaPressureHigh
+		if percent >= 100 {
+			pressure = QuotaPressureExceeded
+		} else if percent >= quotaCriticalPercent {
+			pressure = QuotaPressureCritical
+		}
+		result = append(result, QuotaPressure{
+			Namespace:   quota.Namespace,
+			Quota:       quota.Name,
+			Resource:    string(name),
+			Used:        used.String(),
+			Hard:        hard.String(),
+			PercentUsed: percent,
+			Pressure:    pressure,
+		})
+	}
+	return result
+}
+
+// GetQuotaPressure returns the quota resources in a namespace ("" for all) used at or above
+// threshold percent of their hard limit, most used first
+// Requires WatcherOptions.WatchResourceQuotas, as quotas are read from their informer.
+func (w *Watcher) GetQuotaPressure(namespace string, threshold float64) ([]QuotaPressure, error) {
+	if !w.options.WatchResourceQuotas {
+		return nil, fmt.Errorf("resource quotas are not watched (enable -watch-resource-quotas)")
+	}
+	quotas, err := w.client.InformerFactory.Core().V1().ResourceQuotas().Lister().ResourceQuotas(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource quotas: %w", err)
+	}
+
+	result := []QuotaPressure{}
+	for _, quota := range quotas {
+		result = append(result, quotaPressures(quota, threshold)...)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PercentUsed > result[j].PercentUsed
+	})
+	return result, nil
+}
+
+// registerQuotaPressure watches ResourceQuotas for GetQuotaPressure and QUOTA_PRESSURE events
+func (w *Watcher) registerQuotaPressure() {
+	informer := w.client.InformerFactory.Core().V1().ResourceQuotas().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			// Quotas already under pressure at startup are listed by GetQuotaPressure, not announced
+			if !isInInitialList {
+				w.handleQuotaChange(nil, obj)
+			}
+		},
+		UpdateFunc: w.handleQuotaChange,
+	})
+	w.client.trackInformer("ResourceQuotas", informer.HasSynced)
+}
+
+// handleQuotaChange emits a QUOTA_PRESSURE event when any resource of a quota crosses
+// DefaultQuotaPressureThreshold. The event's resource describes the quota, with the
+// resources under pressure as its Spec; it isn't stored in the cache.
+func (w *Watcher) handleQuotaChange(oldObj, newObj interface{}) {
+	quota, ok := newObj.(*v1.ResourceQuota)
+	if !ok || w.handler == nil {
+		return
+	}
+
+	before := map[string]bool{}
+	if old, ok := oldObj.(*v1.ResourceQuota); ok {
+		for _, p := range quotaPressures(old, DefaultQuotaPressureThreshold) {
+			before[p.Resource] = true
+		}
+	}
+	pressures := quotaPressures(quota, DefaultQuotaPressureThreshold)
+	crossed := false
+	for _, p := range pressures {
+		if !before[p.Resource] {
+			crossed = true
+			break
+		}
+	}
+	if !crossed {
+		return
+	}
+
+	sort.Slice(pressures, func(i, j int) bool {
+		return pressures[i].PercentUsed > pressures[j].PercentUsed
+	})
+	top := pressures[0]
+	w.emit(ResourceEvent{Type: EventQuotaPressure, Resource: &types.Resource{
+		ID:        types.BuildID("ResourceQuota", quota.Namespace, quota.Name),
+		Type:      "ResourceQuota",
+		Name:      quota.Name,
+		Namespace: quota.Namespace,
+		Status: types.ResourceStatus{
+			Message: fmt.Sprintf("%s at %.0f%% of quota (%s/%s)", top.Resource, top.PercentUsed, top.Used, top.Hard),
+		},
+		Health:          types.HealthWarning,
+		Labels:          quota.Labels,
+		Annotations:     quota.Annotations,
+		CreatedAt:       quota.CreationTimestamp.Time,
+		Spec:            types.NewSpec(pressures),
+		ResourceVersion: quota.ResourceVersion,
+	}})
+}
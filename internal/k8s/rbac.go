@@ -0,0 +1,427 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PermissionRule is a single RBAC policy rule with a human-readable description
+type PermissionRule struct {
+	Verbs           []string `json:"verbs"`
+	APIGroups       []string `json:"apiGroups,omitempty"`
+	Resources       []string `json:"resources,omitempty"`
+	ResourceNames   []string `json:"resourceNames,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+	Description     string   `json:"description"` // e.g. "get, list on pods, services"
+}
+
+// BindingPermissions describes what a single (Cluster)RoleBinding grants
+type BindingPermissions struct {
+	Kind           string           `json:"kind"` // "RoleBinding" or "ClusterRoleBinding"
+	Name           string           `json:"name"`
+	Namespace      string           `json:"namespace,omitempty"` // Empty for ClusterRoleBindings
+	RoleKind       string           `json:"roleKind"`            // "Role" or "ClusterRole"
+	RoleName       string           `json:"roleName"`
+	MatchedSubject string           `json:"matchedSubject"` // Subject that matched, e.g. "Group:system:serviceaccounts"
+	Rules          []PermissionRule `json:"rules"`
+	Error          string           `json:"error,omitempty"` // Set when the referenced role can't be read
+}
+
+// ServiceAccountPermissions summarizes everything a ServiceAccount is allowed to do
+type ServiceAccountPermissions struct {
+	ServiceAccount string               `json:"serviceAccount"`
+	Namespace      string               `json:"namespace"`
+	Bindings       []BindingPermissions `json:"bindings"`
+}
+
+// GetServiceAccountPermissions lists the RoleBindings and ClusterRoleBindings that
+// reference a ServiceAccount (directly or through its implicit groups) together with
+// the rules they grant. This is the equivalent of
+// "kubectl auth can-i --list --as system:serviceaccount:<namespace>:<name>".
+func (c *Client) GetServiceAccountPermissions(ctx context.Context, namespace, name string) (*ServiceAccountPermissions, error) {
+	if _, err := c.Clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{}); err != nil {
+		return nil, fmt.Errorf("service account not found: %w", err)
+	}
+
+	result := &ServiceAccountPermissions{
+		ServiceAccount: name,
+		Namespace:      namespace,
+		Bindings:       []BindingPermissions{},
+	}
+	roles := newRoleResolver(c)
+
+	clusterBindings, err := c.Clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster role bindings: %w", err)
+	}
+	for _, binding := range clusterBindings.Items {
+		subject, ok := matchServiceAccountSubject(binding.Subjects, "", namespace, name)
+		if !ok {
+			continue
+		}
+		result.Bindings = append(result.Bindings, roles.bindingPermissions(ctx, "ClusterRoleBinding", binding.Name, "", binding.RoleRef, subject))
+	}
+
+	// Only RoleBindings in the ServiceAccount's own namespace can grant it namespaced
+	// permissions there, but bindings in other namespaces may reference it too
+	roleBindings, err := c.Clientset.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+	}
+	for _, binding := range roleBindings.Items {
+		subject, ok := matchServiceAccountSubject(binding.Subjects, binding.Namespace, namespace, name)
+		if !ok {
+			continue
+		}
+		result.Bindings = append(result.Bindings, roles.bindingPermissions(ctx, "RoleBinding", binding.Name, binding.Namespace, binding.RoleRef, subject))
+	}
+
+	return result, nil
+}
+
+// matchServiceAccountSubject reports whether any subject refers to the ServiceAccount,
+// either directly or via the groups every ServiceAccount belongs to.
+// bindingNamespace is the namespace of a RoleBinding ("" for ClusterRoleBindings),
+// used as the default namespace for ServiceAccount subjects.
+func matchServiceAccountSubject(subjects []rbacv1.Subject, bindingNamespace, namespace, name string) (string, bool) {
+	groups := map[string]bool{
+		"system:serviceaccounts":              true,
+		"system:serviceaccounts:" + namespace: true,
+		"system:authenticated":                true,
+	}
+
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.ServiceAccountKind:
+			subjectNamespace := subject.Namespace
+			if subjectNamespace == "" {
+				subjectNamespace = bindingNamespace
+			}
+			if subject.Name == name && subjectNamespace == namespace {
+				return fmt.Sprintf("ServiceAccount:%s:%s", namespace, name), true
+			}
+		case rbacv1.GroupKind:
+			if groups[subject.Name] {
+				return "Group:" + subject.Name, true
+			}
+		case rbacv1.UserKind:
+			if subject.Name == fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name) {
+				return "User:" + subject.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// roleResolver fetches Roles and ClusterRoles, caching each lookup for the
+// duration of a single request
+type roleResolver struct {
+	client *Client
+	rules  map[string][]rbacv1.PolicyRule
+	errors map[string]error
+}
+
+func newRoleResolver(c *Client) *roleResolver {
+	return &roleResolver{
+		client: c,
+		rules:  make(map[string][]rbacv1.PolicyRule),
+		errors: make(map[string]error),
+	}
+}
+
+// resolve returns the rules of the role referenced by roleRef
+func (r *roleResolver) resolve(ctx context.Context, bindingNamespace string, roleRef rbacv1.RoleRef) ([]rbacv1.PolicyRule, error) {
+	key := roleRef.Kind + ":" + bindingNamespace + ":" + roleRef.Name
+	if roleRef.Kind == "ClusterRole" {
+		key = roleRef.Kind + "::" + roleRef.Name
+	}
+	if rules, ok := r.rules[key]; ok {
+		return rules, nil
+	}
+	if err, ok := r.errors[key]; ok {
+		return nil, err
+	}
+
+	var rules []rbacv1.PolicyRule
+	var err error
+	if roleRef.Kind == "ClusterRole" {
+		var role *rbacv1.ClusterRole
+		role, err = r.client.Clientset.RbacV1().ClusterRoles().Get(ctx, roleRef.Name, metav1.GetOptions{})
+		if err == nil {
+			rules = role.Rules
+		}
+	} else {
+		var role *rbacv1.Role
+		role, err = r.client.Clientset.RbacV1().Roles(bindingNamespace).Get(ctx, roleRef.Name, metav1.GetOptions{})
+		if err == nil {
+			rules = role.Rules
+		}
+	}
+
+	if err != nil {
+		r.errors[key] = err
+		return nil, err
+	}
+	r.rules[key] = rules
+	return rules, nil
+}
+
+// bindingPermissions builds the permission summary for a single binding
+func (r *roleResolver) bindingPermissions(ctx context.Context, kind, name, namespace string, roleRef rbacv1.RoleRef, subject string) BindingPermissions {
+	binding := BindingPermissions{
+		Kind:           kind,
+		Name:           name,
+		Namespace:      namespace,
+		RoleKind:       roleRef.Kind,
+		RoleName:       roleRef.Name,
+		MatchedSubject: subject,
+		Rules:          []PermissionRule{},
+	}
+
+	rules, err := r.resolve(ctx, namespace, roleRef)
+	if err != nil {
+		binding.Error = fmt.Sprintf("failed to read %s %s: %v", roleRef.Kind, roleRef.Name, err)
+		return binding
+	}
+
+	for _, rule := range rules {
+		binding.Rules = append(binding.Rules, toPermissionRule(rule))
+	}
+	return binding
+}
+
+// toPermissionRule converts an RBAC policy rule into its summarized form
+func toPermissionRule(rule rbacv1.PolicyRule) PermissionRule {
+	return PermissionRule{
+		Verbs:           rule.Verbs,
+		APIGroups:       rule.APIGroups,
+		Resources:       rule.Resources,
+		ResourceNames:   rule.ResourceNames,
+		NonResourceURLs: rule.NonResourceURLs,
+		Description:     describeRule(rule),
+	}
+}
+
+// describeRule renders a policy rule as a short human-readable sentence
+func describeRule(rule rbacv1.PolicyRule) string {
+	verbs := strings.Join(rule.Verbs, ", ")
+
+	if len(rule.NonResourceURLs) > 0 {
+		return fmt.Sprintf("%s on URLs %s", verbs, strings.Join(rule.NonResourceURLs, ", "))
+	}
+
+	targets := make([]string, 0, len(rule.Resources))
+	for _, resource := range rule.Resources {
+		targets = append(targets, qualifyResource(resource, rule.APIGroups))
+	}
+	description := fmt.Sprintf("%s on %s", verbs, strings.Join(targets, ", "))
+	if len(rule.ResourceNames) > 0 {
+		description += fmt.Sprintf(" (names: %s)", strings.Join(rule.ResourceNames, ", "))
+	}
+	return description
+}
+
+// qualifyResource appends the API group to a resource name, kubectl style
+// (e.g. "deployments.apps"). Core group resources are left unqualified.
+func qualifyResource(resource string, apiGroups []string) string {
+	if len(apiGroups) != 1 || apiGroups[0] == "" {
+		return resource
+	}
+	return resource + "." + apiGroups[0]
+}
+
+// BindingSubject is a user, group or ServiceAccount referenced by a binding
+type BindingSubject struct {
+	Kind      string `json:"kind"` // "User", "Group" or "ServiceAccount"
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"` // Only set for ServiceAccounts
+}
+
+// RoleBindingSummary describes a (Cluster)RoleBinding and the subjects it binds
+type RoleBindingSummary struct {
+	Kind      string           `json:"kind"` // "RoleBinding" or "ClusterRoleBinding"
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace,omitempty"` // Empty for ClusterRoleBindings
+	Subjects  []BindingSubject `json:"subjects"`
+}
+
+// GetClusterAdminBindings lists every ClusterRoleBinding and RoleBinding that grants
+// the cluster-admin ClusterRole, together with the subjects it is granted to
+func (c *Client) GetClusterAdminBindings(ctx context.Context) ([]RoleBindingSummary, error) {
+	result := []RoleBindingSummary{}
+
+	clusterBindings, err := c.Clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster role bindings: %w", err)
+	}
+	for _, binding := range clusterBindings.Items {
+		if isClusterAdminRef(binding.RoleRef) {
+			result = append(result, RoleBindingSummary{
+				Kind:     "ClusterRoleBinding",
+				Name:     binding.Name,
+				Subjects: toBindingSubjects(binding.Subjects, ""),
+			})
+		}
+	}
+
+	// A RoleBinding to cluster-admin grants full control over its own namespace
+	roleBindings, err := c.Clientset.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+	}
+	for _, binding := range roleBindings.Items {
+		if isClusterAdminRef(binding.RoleRef) {
+			result = append(result, RoleBindingSummary{
+				Kind:      "RoleBinding",
+				Name:      binding.Name,
+				Namespace: binding.Namespace,
+				Subjects:  toBindingSubjects(binding.Subjects, binding.Namespace),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// isClusterAdminRef reports whether a role reference points at the cluster-admin ClusterRole
+func isClusterAdminRef(roleRef rbacv1.RoleRef) bool {
+	return roleRef.Kind == "ClusterRole" && roleRef.Name == "cluster-admin"
+}
+
+// toBindingSubjects converts RBAC subjects, defaulting ServiceAccount namespaces
+// to the binding's namespace
+func toBindingSubjects(subjects []rbacv1.Subject, bindingNamespace string) []BindingSubject {
+	result := make([]BindingSubject, 0, len(subjects))
+	for _, subject := range subjects {
+		s := BindingSubject{Kind: subject.Kind, Name: subject.Name}
+		if subject.Kind == rbacv1.ServiceAccountKind {
+			s.Namespace = subject.Namespace
+			if s.Namespace == "" {
+				s.Namespace = bindingNamespace
+			}
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// WildcardRole is a Role or ClusterRole with at least one rule granting "*" verbs, resources
+// or API groups
+type WildcardRole struct {
+	Kind          string           `json:"kind"` // "Role" or "ClusterRole"
+	Name          string           `json:"name"`
+	Namespace     string           `json:"namespace,omitempty"` // Empty for ClusterRoles
+	WildcardRules []PermissionRule `json:"wildcardRules"`
+	BoundTo       []BindingSubject `json:"boundTo"` // Subjects of all bindings referencing the role
+}
+
+// GetWildcardRoles lists Roles and ClusterRoles containing rules with verbs=["*"],
+// resources=["*"] or apiGroups=["*"], along with the subjects they are bound to. namespace
+// limits Roles (and the RoleBindings considered) to a single namespace; "" means all
+// namespaces. ClusterRoles are always included since they can be bound in any namespace.
+func (c *Client) GetWildcardRoles(ctx context.Context, namespace string) ([]WildcardRole, error) {
+	return c.getWildcardRoles(ctx, namespace, false)
+}
+
+// GetNamespaceWildcardRoles is GetWildcardRoles limited to what a single namespace can
+// see: its Roles, and the ClusterRoles its RoleBindings reference, bound only to the
+// subjects of those RoleBindings. ClusterRoleBindings are never read.
+func (c *Client) GetNamespaceWildcardRoles(ctx context.Context, namespace string) ([]WildcardRole, error) {
+	return c.getWildcardRoles(ctx, namespace, true)
+}
+
+func (c *Client) getWildcardRoles(ctx context.Context, namespace string, namespaceOnly bool) ([]WildcardRole, error) {
+	clusterRoles, err := c.Clientset.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster roles: %w", err)
+	}
+	roles, err := c.Clientset.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	roleBindings, err := c.Clientset.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+	}
+
+	// Index subjects by role key ("ClusterRole::name" or "Role:namespace:name")
+	subjects := make(map[string][]BindingSubject)
+	if !namespaceOnly {
+		clusterBindings, err := c.Clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cluster role bindings: %w", err)
+		}
+		for _, binding := range clusterBindings.Items {
+			key := "ClusterRole::" + binding.RoleRef.Name
+			subjects[key] = append(subjects[key], toBindingSubjects(binding.Subjects, "")...)
+		}
+	}
+	for _, binding := range roleBindings.Items {
+		key := "ClusterRole::" + binding.RoleRef.Name
+		if binding.RoleRef.Kind == "Role" {
+			key = "Role:" + binding.Namespace + ":" + binding.RoleRef.Name
+		}
+		subjects[key] = append(subjects[key], toBindingSubjects(binding.Subjects, binding.Namespace)...)
+	}
+
+	result := []WildcardRole{}
+	for _, role := range clusterRoles.Items {
+		if _, bound := subjects["ClusterRole::"+role.Name]; namespaceOnly && !bound {
+			continue
+		}
+		if rules := wildcardRules(role.Rules); len(rules) > 0 {
+			result = append(result, WildcardRole{
+				Kind:          "ClusterRole",
+				Name:          role.Name,
+				WildcardRules: rules,
+				BoundTo:       nonNilSubjects(subjects["ClusterRole::"+role.Name]),
+			})
+		}
+	}
+	for _, role := range roles.Items {
+		if rules := wildcardRules(role.Rules); len(rules) > 0 {
+			result = append(result, WildcardRole{
+				Kind:          "Role",
+				Name:          role.Name,
+				Namespace:     role.Namespace,
+				WildcardRules: rules,
+				BoundTo:       nonNilSubjects(subjects["Role:"+role.Namespace+":"+role.Name]),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// wildcardRules returns the rules that grant all verbs, resources or API groups
+func wildcardRules(rules []rbacv1.PolicyRule) []PermissionRule {
+	var result []PermissionRule
+	for _, rule := range rules {
+		if containsWildcard(rule.Verbs) || containsWildcard(rule.Resources) || containsWildcard(rule.APIGroups) {
+			result = append(result, toPermissionRule(rule))
+		}
+	}
+	return result
+}
+
+// containsWildcard reports whether values contains the RBAC "*" wildcard
+func containsWildcard(values []string) bool {
+	for _, v := range values {
+		if v == rbacv1.VerbAll {
+			return true
+		}
+	}
+	return false
+}
+
+// nonNilSubjects ensures unbound roles serialize as an empty list rather than null
+func nonNilSubjects(subjects []BindingSubject) []BindingSubject {
+	if subjects == nil {
+		return []BindingSubject{}
+	}
+	return subjects
+}
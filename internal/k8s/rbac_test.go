@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWildcardRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule rbacv1.PolicyRule
+		want bool
+	}{
+		{"wildcard verbs", rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"pods"}}, true},
+		{"wildcard resources", rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"*"}}, true},
+		{"wildcard api groups", rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"*"}, Resources: []string{"deployments"}}, true},
+		{"explicit rule", rbacv1.PolicyRule{Verbs: []string{"get", "list"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := len(wildcardRules([]rbacv1.PolicyRule{tt.rule})) > 0
+			if got != tt.want {
+				t.Errorf("wildcardRules(%+v) matched = %v, want %v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetNamespaceWildcardRoles(t *testing.T) {
+	t.Parallel()
+
+	wildcard := []rbacv1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}}
+	clientset := fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "bound-here"}, Rules: wildcard},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "bound-elsewhere"}, Rules: wildcard},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: "team-a"}, Rules: wildcard},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-b"}, Rules: wildcard},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "use-cluster-role", Namespace: "team-a"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "bound-here"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-wide"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "bound-here"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "admin"}},
+		},
+	)
+	client := NewClientWithFake(clientset)
+
+	roles, err := client.GetNamespaceWildcardRoles(context.Background(), "team-a")
+	if err != nil {
+		t.Fatalf("GetNamespaceWildcardRoles() error = %v", err)
+	}
+
+	got := make(map[string][]BindingSubject)
+	for _, role := range roles {
+		got[role.Kind+"/"+role.Name] = role.BoundTo
+	}
+	if len(got) != 2 {
+		t.Fatalf("got roles %v, want ClusterRole/bound-here and Role/local", got)
+	}
+	subjects, ok := got["ClusterRole/bound-here"]
+	if !ok {
+		t.Fatalf("ClusterRole/bound-here missing from %v", got)
+	}
+	if len(subjects) != 1 || subjects[0].Name != "app" || subjects[0].Namespace != "team-a" {
+		t.Errorf("ClusterRole/bound-here bound to %+v, want only the team-a ServiceAccount", subjects)
+	}
+	if _, ok := got["Role/local"]; !ok {
+		t.Errorf("Role/local missing from %v", got)
+	}
+}
@@ -1,19 +1,29 @@
 package k8s
 
 import (
+	"sort"
+
 	v1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/user/k8v/internal/types"
 )
 
 // ExtractOwners extracts ownership relationships from OwnerReferences
+// Owners that are custom resources get group-qualified types, matching dynamicTypeName.
 func ExtractOwners(obj metav1.Object) []types.ResourceRef {
 	refs := []types.ResourceRef{}
 	for _, owner := range obj.GetOwnerReferences() {
+		group := ""
+		if gv, err := schema.ParseGroupVersion(owner.APIVersion); err == nil {
+			group = gv.Group
+		}
 		refs = append(refs, types.NewResourceRef(
-			owner.Kind,
+			dynamicTypeName(owner.Kind, group),
 			obj.GetNamespace(),
 			owner.Name,
 		))
@@ -30,6 +40,14 @@ func FindReverseRelationships(
 ) []types.ResourceRef {
 	refs := []types.ResourceRef{}
 
+	// Owned resources are indexed when the cache keeps the ownership index
+	if forwardRelType == types.RelOwnedBy && cache.IndexesOwners() {
+		for _, resource := range cache.ListByOwner(targetID) {
+			refs = append(refs, types.NewResourceRef(resource.Type, resource.Namespace, resource.Name))
+		}
+		return refs
+	}
+
 	// Search all resources in cache
 	for _, resource := range cache.List() {
 		// Get the forward relationship field (e.g., OwnedBy, DependsOn)
@@ -65,6 +83,20 @@ func ExtractConfigMapDeps(pod *v1.Pod) []types.ResourceRef {
 				seen[id] = true
 			}
 		}
+
+		// Projected volumes (e.g. service account tokens) can combine several sources
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap == nil {
+					continue
+				}
+				id := types.BuildID("ConfigMap", pod.Namespace, source.ConfigMap.Name)
+				if !seen[id] {
+					refs = append(refs, types.NewResourceRef("ConfigMap", pod.Namespace, source.ConfigMap.Name))
+					seen[id] = true
+				}
+			}
+		}
 	}
 
 	// Env from
@@ -108,6 +140,20 @@ func ExtractSecretDeps(pod *v1.Pod) []types.ResourceRef {
 				seen[id] = true
 			}
 		}
+
+		// Projected volumes (e.g. service account tokens) can combine several sources
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret == nil {
+					continue
+				}
+				id := types.BuildID("Secret", pod.Namespace, source.Secret.Name)
+				if !seen[id] {
+					refs = append(refs, types.NewResourceRef("Secret", pod.Namespace, source.Secret.Name))
+					seen[id] = true
+				}
+			}
+		}
 	}
 
 	// Env from
@@ -193,13 +239,17 @@ func FindRoutedServices(ingress *netv1.Ingress) []types.ResourceRef {
 }
 
 // LabelsMatch checks if a set of labels matches a selector
+// Every selector label must be present with the same value; extra labels don't matter.
+// An empty selector matches nothing (a Service without one selects no Pods), and nil
+// labels match no non-empty selector. Namespaces aren't considered: callers compare them.
 func LabelsMatch(labels map[string]string, selector map[string]string) bool {
 	if len(selector) == 0 {
 		return false
 	}
 
 	for key, value := range selector {
-		if labels[key] != value {
+		// A key selected with an empty value must still be present
+		if actual, ok := labels[key]; !ok || actual != value {
 			return false
 		}
 	}
@@ -214,65 +264,85 @@ func LabelsMatch(labels map[string]string, selector map[string]string) bool {
 func UpdateBidirectionalRelationships(cache *ResourceCache, resource *types.Resource) {
 	// Update reverse ownership relationships
 	for _, ownerRef := range resource.Relationships.OwnedBy {
-		if owner, ok := cache.Get(ownerRef.ID); ok {
-			addToOwns(owner, resource)
-			cache.Set(owner)
-		}
+		cache.update(ownerRef.ID, func(owner *types.Resource) bool {
+			return addToOwns(owner, resource)
+		})
 	}
 
 	// Update reverse dependency relationships
 	for _, depRef := range resource.Relationships.DependsOn {
-		if dep, ok := cache.Get(depRef.ID); ok {
-			addToUsedBy(dep, resource)
-			cache.Set(dep)
-		}
+		cache.update(depRef.ID, func(dep *types.Resource) bool {
+			return addToUsedBy(dep, resource)
+		})
 	}
 
 	// Update reverse network relationships
 	for _, exposedRef := range resource.Relationships.Exposes {
-		if exposed, ok := cache.Get(exposedRef.ID); ok {
-			addToExposedBy(exposed, resource)
-			cache.Set(exposed)
-		}
+		cache.update(exposedRef.ID, func(exposed *types.Resource) bool {
+			return addToExposedBy(exposed, resource)
+		})
 	}
 
 	// Update reverse routing relationships
 	for _, routeRef := range resource.Relationships.RoutesTo {
-		if routed, ok := cache.Get(routeRef.ID); ok {
-			addToRoutedBy(routed, resource)
-			cache.Set(routed)
-		}
+		cache.update(routeRef.ID, func(routed *types.Resource) bool {
+			return addToRoutedBy(routed, resource)
+		})
+	}
+
+	// Update reverse disruption relationships
+	for _, protectedRef := range resource.Relationships.Protects {
+		cache.update(protectedRef.ID, func(protected *types.Resource) bool {
+			return addToProtectedBy(protected, resource)
+		})
 	}
 }
 
-// Helper functions to add relationships without duplicates
+// Helper functions to add relationships without duplicates, reporting whether they were added
 
-func addToOwns(resource *types.Resource, owned *types.Resource) {
+func addToOwns(resource *types.Resource, owned *types.Resource) bool {
 	ref := types.NewResourceRef(owned.Type, owned.Namespace, owned.Name)
-	if !containsRef(resource.Relationships.Owns, ref) {
-		resource.Relationships.Owns = append(resource.Relationships.Owns, ref)
+	if containsRef(resource.Relationships.Owns, ref) {
+		return false
 	}
+	resource.Relationships.Owns = append(resource.Relationships.Owns, ref)
+	return true
 }
 
-func addToUsedBy(resource *types.Resource, user *types.Resource) {
+func addToUsedBy(resource *types.Resource, user *types.Resource) bool {
 	ref := types.NewResourceRef(user.Type, user.Namespace, user.Name)
-	if !containsRef(resource.Relationships.UsedBy, ref) {
-		resource.Relationships.UsedBy = append(resource.Relationships.UsedBy, ref)
+	if containsRef(resource.Relationships.UsedBy, ref) {
+		return false
 	}
+	resource.Relationships.UsedBy = append(resource.Relationships.UsedBy, ref)
+	return true
 }
 
-func addToExposedBy(resource *types.Resource, exposer *types.Resource) {
+func addToExposedBy(resource *types.Resource, exposer *types.Resource) bool {
 	ref := types.NewResourceRef(exposer.Type, exposer.Namespace, exposer.Name)
-	if !containsRef(resource.Relationships.ExposedBy, ref) {
-		resource.Relationships.ExposedBy = append(resource.Relationships.ExposedBy, ref)
+	if containsRef(resource.Relationships.ExposedBy, ref) {
+		return false
 	}
+	resource.Relationships.ExposedBy = append(resource.Relationships.ExposedBy, ref)
+	return true
 }
 
-func addToRoutedBy(resource *types.Resource, router *types.Resource) {
+func addToRoutedBy(resource *types.Resource, router *types.Resource) bool {
 	ref := types.NewResourceRef(router.Type, router.Namespace, router.Name)
-	if !containsRef(resource.Relationships.RoutedBy, ref) {
-		resource.Relationships.RoutedBy = append(resource.Relationships.RoutedBy, ref)
+	if containsRef(resource.Relationships.RoutedBy, ref) {
+		return false
 	}
+	resource.Relationships.RoutedBy = append(resource.Relationships.RoutedBy, ref)
+	return true
+}
+
+func addToProtectedBy(resource *types.Resource, protector *types.Resource) bool {
+	ref := types.NewResourceRef(protector.Type, protector.Namespace, protector.Name)
+	if containsRef(resource.Relationships.ProtectedBy, ref) {
+		return false
+	}
+	resource.Relationships.ProtectedBy = append(resource.Relationships.ProtectedBy, ref)
+	return true
 }
 
 func containsRef(refs []types.ResourceRef, ref types.ResourceRef) bool {
@@ -293,3 +363,70 @@ func ExtractPodNodeScheduling(pod *v1.Pod) []types.ResourceRef {
 		types.NewResourceRef("Node", "", pod.Spec.NodeName), // Nodes are cluster-scoped
 	}
 }
+
+// ExtractPVCStorageClassDeps extracts the StorageClass a PersistentVolumeClaim is provisioned from
+func ExtractPVCStorageClassDeps(pvc *v1.PersistentVolumeClaim) []types.ResourceRef {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return []types.ResourceRef{} // Statically bound or not yet defaulted
+	}
+	return []types.ResourceRef{
+		types.NewResourceRef("StorageClass", "", *pvc.Spec.StorageClassName), // StorageClasses are cluster-scoped
+	}
+}
+
+// FindProtectedPods finds all Pods that match a PodDisruptionBudget's selector
+func FindProtectedPods(pdb *policyv1.PodDisruptionBudget, cache *ResourceCache) []types.ResourceRef {
+	refs := []types.ResourceRef{}
+
+	// A nil selector matches no pods, an empty selector matches all pods in the namespace
+	if pdb.Spec.Selector == nil {
+		return refs
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		return refs
+	}
+
+	for _, resource := range cache.ListByType("Pod") {
+		if resource.Namespace != pdb.Namespace {
+			continue
+		}
+		if selector.Matches(labels.Set(resource.Labels)) {
+			refs = append(refs, types.NewResourceRef("Pod", resource.Namespace, resource.Name))
+		}
+	}
+
+	return refs
+}
+
+// FindBlockingPDBs returns the names of the PodDisruptionBudgets in namespace that select
+// pods labeled podLabels and currently allow no disruptions, sorted by name.
+// Budgets are matched on their cached Spec, where a budget without a selector has none
+// and "<none>" is the empty selector, which matches every pod.
+func FindBlockingPDBs(namespace string, podLabels map[string]string, cache *ResourceCache) []string {
+	var names []string
+	for _, resource := range cache.ListByType("PodDisruptionBudget") {
+		if resource.Namespace != namespace {
+			continue
+		}
+		typed, err := resource.TypedSpec()
+		if err != nil {
+			continue
+		}
+		spec, ok := typed.(*types.PodDisruptionBudgetSpec)
+		if !ok || spec.DisruptionsAllowed != 0 || spec.Selector == "" {
+			continue
+		}
+		selector := labels.Everything()
+		if spec.Selector != "<none>" {
+			if selector, err = labels.Parse(spec.Selector); err != nil {
+				continue
+			}
+		}
+		if selector.Matches(labels.Set(podLabels)) {
+			names = append(names, resource.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,125 @@
+package k8s
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTransformPodProjectedVolumeDeps(t *testing.T) {
+	t.Parallel()
+
+	pod := testPod("default", "web")
+	pod.Spec.Volumes = []v1.Volume{{
+		Name: "config",
+		VolumeSource: v1.VolumeSource{Projected: &v1.ProjectedVolumeSource{Sources: []v1.VolumeProjection{
+			{ConfigMap: &v1.ConfigMapProjection{LocalObjectReference: v1.LocalObjectReference{Name: "web-settings"}}},
+			{Secret: &v1.SecretProjection{LocalObjectReference: v1.LocalObjectReference{Name: "web-credentials"}}},
+			{ServiceAccountToken: &v1.ServiceAccountTokenProjection{Path: "token"}},
+		}}},
+	}}
+
+	cache := NewResourceCache()
+	defer cache.Close()
+	resource := TransformPod(pod, cache)
+
+	dependsOn := make(map[string]bool)
+	for _, ref := range resource.Relationships.DependsOn {
+		dependsOn[ref.ID] = true
+	}
+	for _, want := range []string{"ConfigMap:default:web-settings", "Secret:default:web-credentials"} {
+		if !dependsOn[want] {
+			t.Errorf("DependsOn = %v, want it to include %s", resource.Relationships.DependsOn, want)
+		}
+	}
+}
+
+func TestUpdateBidirectionalRelationships(t *testing.T) {
+	t.Parallel()
+
+	cache := NewResourceCache()
+	defer cache.Close()
+	pod := testPod("default", "web")
+	cache.Set(TransformPod(pod, cache))
+	service := TransformService(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	}, cache)
+	cache.Set(service)
+	UpdateBidirectionalRelationships(cache, service)
+
+	cached, _ := cache.Get("Pod:default:web")
+	if got := cached.Relationships.ExposedBy; len(got) != 1 || got[0].ID != "Service:default:web" {
+		t.Fatalf("ExposedBy = %v, want [Service:default:web]", got)
+	}
+
+	// The next update of the Pod rebuilds ExposedBy without the deleted Service
+	cache.Delete("Service:default:web")
+	updated := pod.DeepCopy()
+	updated.ResourceVersion = "2"
+	resource := TransformPod(updated, cache)
+	cache.Set(resource)
+	UpdateBidirectionalRelationships(cache, resource)
+
+	cached, _ = cache.Get("Pod:default:web")
+	if got := cached.Relationships.ExposedBy; len(got) != 0 {
+		t.Errorf("ExposedBy = %v after the Service was deleted, want it empty", got)
+	}
+}
+
+func TestLabelsMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		selector map[string]string
+		want     bool
+	}{
+		// A Service without a selector selects no Pods, whatever their labels
+		{"empty selector", map[string]string{"app": "web"}, map[string]string{}, false},
+		{"nil selector", map[string]string{"app": "web"}, nil, false},
+		{"nil labels and selector", nil, nil, false},
+		// A resource without labels can't carry the selected ones
+		{"nil labels", nil, map[string]string{"app": "web"}, false},
+		{"exact match", map[string]string{"app": "web"}, map[string]string{"app": "web"}, true},
+		{"different value", map[string]string{"app": "api"}, map[string]string{"app": "web"}, false},
+		// Every selected label is required, not just one of them
+		{"partial match", map[string]string{"app": "web"}, map[string]string{"app": "web", "tier": "frontend"}, false},
+		// Labels the selector doesn't mention don't matter
+		{"superset labels", map[string]string{"app": "web", "tier": "frontend", "version": "2"}, map[string]string{"app": "web"}, true},
+		// An empty selected value still needs the key, as with Kubernetes equality selectors
+		{"empty value, key present", map[string]string{"canary": ""}, map[string]string{"canary": ""}, true},
+		{"empty value, key missing", map[string]string{"app": "web"}, map[string]string{"canary": ""}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := LabelsMatch(tt.labels, tt.selector); got != tt.want {
+				t.Errorf("LabelsMatch(%v, %v) = %v, want %v", tt.labels, tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindExposedPodsStaysInNamespace(t *testing.T) {
+	t.Parallel()
+
+	// The same template deployed to two namespaces gives Pods with identical labels;
+	// LabelsMatch can't tell them apart, so FindExposedPods compares namespaces
+	cache := NewResourceCache()
+	defer cache.Close()
+	for _, namespace := range []string{"staging", "production"} {
+		cache.Set(TransformPod(testPod(namespace, "web"), cache))
+	}
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "staging"},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	}
+
+	refs := FindExposedPods(service, cache)
+	if len(refs) != 1 || refs[0].ID != "Pod:staging:web" {
+		t.Errorf("FindExposedPods() = %v, want only [Pod:staging:web]", refs)
+	}
+}
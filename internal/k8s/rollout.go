@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// ErrDeploymentsNotWatched is returned for rollouts when Deployments aren't in
+// WatcherOptions.WatchResourceTypes, so their informer never lists them
+var ErrDeploymentsNotWatched = errors.New("Deployment not watched")
+
+// restartedAtAnnotation is set on the pod template by `kubectl rollout restart`
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// RolloutStatus is the progress of a Deployment rollout
+type RolloutStatus struct {
+	UpdatedReplicas     int32  `json:"updatedReplicas"`
+	TotalReplicas       int32  `json:"totalReplicas"` // Desired replicas
+	UnavailableReplicas int32  `json:"unavailableReplicas"`
+	RestartedAt         string `json:"restartedAt,omitempty"` // Last `kubectl rollout restart`, if any
+	Complete            bool   `json:"complete"`
+}
+
+// deploymentRolloutStatus computes the rollout progress of a Deployment
+// A status the controller hasn't observed the latest spec for is never complete, as its
+// counts still describe the previous rollout.
+func deploymentRolloutStatus(deployment *appsv1.Deployment) RolloutStatus {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	observed := deployment.Status.ObservedGeneration >= deployment.Generation
+	return RolloutStatus{
+		UpdatedReplicas:     deployment.Status.UpdatedReplicas,
+		TotalReplicas:       desired,
+		UnavailableReplicas: deployment.Status.UnavailableReplicas,
+		RestartedAt:         deployment.Spec.Template.Annotations[restartedAtAnnotation],
+		Complete:            observed && deployment.Status.UpdatedReplicas == desired && deployment.Status.UnavailableReplicas == 0,
+	}
+}
+
+// GetRolloutStatus returns the rollout progress of a Deployment from the informer cache
+func (w *Watcher) GetRolloutStatus(namespace, name string) (*RolloutStatus, error) {
+	if !w.Watches("Deployment") {
+		// The lister would create an informer nobody starts, and never find anything
+		return nil, ErrDeploymentsNotWatched
+	}
+	deployment, err := w.client.InformerFactory.Apps().V1().Deployments().Lister().Deployments(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	status := deploymentRolloutStatus(deployment)
+	return &status, nil
+}
+
+// WatchRollout streams the rollout progress of a Deployment each time it changes, until
+// ctx is done or the Deployment is deleted, when the channel is closed
+// It is driven by WatchResource and the informer cache, so it makes no API calls.
+func (w *Watcher) WatchRollout(ctx context.Context, namespace, name string) (<-chan RolloutStatus, error) {
+	if !w.Watches("Deployment") {
+		return nil, ErrDeploymentsNotWatched
+	}
+	events, err := w.WatchResource(ctx, types.BuildID("Deployment", namespace, name))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(chan RolloutStatus, watchResourceBuffer)
+	go func() {
+		defer close(statuses)
+		var last *RolloutStatus
+		for event := range events {
+			if event.Type == EventDeleted {
+				return
+			}
+			status, err := w.GetRolloutStatus(namespace, name)
+			if err != nil {
+				return
+			}
+			if last != nil && *last == *status {
+				continue // Relationship updates re-store the Deployment unchanged
+			}
+			last = status
+			select {
+			case statuses <- *status:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return statuses, nil
+}
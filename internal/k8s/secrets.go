@@ -0,0 +1,99 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// StaleSecret is a Secret that hasn't been updated for longer than the rotation threshold
+type StaleSecret struct {
+	Name        string    `json:"name"`
+	Namespace   string    `json:"namespace"`
+	Type        string    `json:"type"`
+	LastUpdated time.Time `json:"lastUpdated"`
+	AgeDays     int       `json:"ageDays"`
+}
+
+// RedactSecrets returns resources without Secret data, for copies of the cache that
+// leave the process (cache files, snapshot exports): the YAML of each Secret is cleared,
+// as is a kubectl last-applied-configuration annotation, which repeats the data. Secret
+// Specs only hold the type. Other resources are shared, not copied.
+func RedactSecrets(resources []*types.Resource) []*types.Resource {
+	redacted := make([]*types.Resource, len(resources))
+	for i, r := range resources {
+		if r.Type == "Secret" {
+			r = r.Clone()
+			r.YAML = ""
+			delete(r.Annotations, v1.LastAppliedConfigAnnotation)
+		}
+		redacted[i] = r
+	}
+	return redacted
+}
+
+// ParseDurationWithDays parses a duration like time.ParseDuration, additionally
+// accepting a whole number of days ("90d")
+func ParseDurationWithDays(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration: %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// secretLastUpdated returns when a Secret was last written: the latest managedFields
+// timestamp (set by every create, update and apply) or its creation time
+func secretLastUpdated(secret *v1.Secret) time.Time {
+	last := secret.CreationTimestamp.Time
+	for _, entry := range secret.ManagedFields {
+		if entry.Time != nil && entry.Time.After(last) {
+			last = entry.Time.Time
+		}
+	}
+	return last
+}
+
+// GetStaleSecrets returns Secrets in a namespace ("" for all) not updated for longer than
+// olderThan, oldest first. ServiceAccount token Secrets are managed by Kubernetes and excluded.
+func (c *Client) GetStaleSecrets(namespace string, olderThan time.Duration) ([]StaleSecret, error) {
+	secrets, err := c.InformerFactory.Core().V1().Secrets().Lister().Secrets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	now := time.Now()
+	result := []StaleSecret{}
+	for _, secret := range secrets {
+		if secret.Type == v1.SecretTypeServiceAccountToken {
+			continue
+		}
+		lastUpdated := secretLastUpdated(secret)
+		age := now.Sub(lastUpdated)
+		if age <= olderThan {
+			continue
+		}
+		result = append(result, StaleSecret{
+			Name:        secret.Name,
+			Namespace:   secret.Namespace,
+			Type:        string(secret.Type),
+			LastUpdated: lastUpdated,
+			AgeDays:     int(age.Hours() / 24),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastUpdated.Before(result[j].LastUpdated)
+	})
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Reasons reported for Pods missing their service mesh sidecar
+const (
+	// SidecarMissingAnnotation means the injector never processed the Pod (no injection
+	// status annotation), typically because it was created before injection was enabled
+	SidecarMissingAnnotation = "MissingAnnotation"
+	// SidecarNotInjected means the injector processed the Pod but no sidecar container is present
+	SidecarNotInjected = "NotInjected"
+)
+
+// MissingSidecar is a Pod in a mesh-enabled namespace without its sidecar container
+type MissingSidecar struct {
+	Pod             string `json:"pod"`
+	Namespace       string `json:"namespace"`
+	ExpectedSidecar string `json:"expectedSidecar"` // "istio-proxy" or "linkerd-proxy"
+	Reason          string `json:"reason"`          // "MissingAnnotation" or "NotInjected"
+}
+
+// serviceMesh describes how a mesh enables injection and marks injected Pods
+type serviceMesh struct {
+	sidecar string
+	// namespaceEnabled reports whether injection is enabled for a namespace
+	namespaceEnabled func(ns *v1.Namespace) bool
+	// podOptedOut reports whether the Pod explicitly disables injection
+	podOptedOut func(pod *v1.Pod) bool
+	// statusAnnotation is set by the injector on every Pod it processes
+	statusAnnotation string
+}
+
+var serviceMeshes = []serviceMesh{
+	{
+		sidecar: "istio-proxy",
+		namespaceEnabled: func(ns *v1.Namespace) bool {
+			if ns.Labels["istio-injection"] == "disabled" {
+				return false
+			}
+			return ns.Labels["istio-injection"] == "enabled" || ns.Labels["istio.io/rev"] != ""
+		},
+		podOptedOut: func(pod *v1.Pod) bool {
+			return pod.Labels["sidecar.istio.io/inject"] == "false" || pod.Annotations["sidecar.istio.io/inject"] == "false"
+		},
+		statusAnnotation: "sidecar.istio.io/status",
+	},
+	{
+		sidecar: "linkerd-proxy",
+		namespaceEnabled: func(ns *v1.Namespace) bool {
+			return ns.Annotations["linkerd.io/inject"] == "enabled"
+		},
+		podOptedOut: func(pod *v1.Pod) bool {
+			return pod.Annotations["linkerd.io/inject"] == "disabled"
+		},
+		statusAnnotation: "linkerd.io/proxy-version",
+	},
+}
+
+// GetMissingSidecars returns Pods in namespaces with Istio or Linkerd injection enabled
+// that don't run the expected sidecar. namespace limits the check to one namespace ("" = all).
+func (c *Client) GetMissingSidecars(ctx context.Context, namespace string) ([]MissingSidecar, error) {
+	var namespaces []v1.Namespace
+	if namespace == "" {
+		list, err := c.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		namespaces = list.Items
+	} else {
+		ns, err := c.Clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get namespace: %w", err)
+		}
+		namespaces = []v1.Namespace{*ns}
+	}
+
+	result := []MissingSidecar{}
+	podLister := c.InformerFactory.Core().V1().Pods().Lister()
+	for i := range namespaces {
+		ns := &namespaces[i]
+		for _, mesh := range serviceMeshes {
+			if !mesh.namespaceEnabled(ns) {
+				continue
+			}
+
+			pods, err := podLister.Pods(ns.Name).List(labels.Everything())
+			if err != nil {
+				return nil, fmt.Errorf("failed to list pods: %w", err)
+			}
+			for _, pod := range pods {
+				// Host network pods are never injected; completed pods no longer matter
+				if pod.Spec.HostNetwork || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+					continue
+				}
+				if mesh.podOptedOut(pod) || hasContainer(pod, mesh.sidecar) {
+					continue
+				}
+
+				reason := SidecarNotInjected
+				if _, ok := pod.Annotations[mesh.statusAnnotation]; !ok {
+					reason = SidecarMissingAnnotation
+				}
+				result = append(result, MissingSidecar{
+					Pod:             pod.Name,
+					Namespace:       pod.Namespace,
+					ExpectedSidecar: mesh.sidecar,
+					Reason:          reason,
+				})
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Pod < result[j].Pod
+	})
+	return result, nil
+}
+
+// hasContainer reports whether a Pod has a container (or native sidecar init container) with the given name
+func hasContainer(pod *v1.Pod, name string) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == name {
+			return true
+		}
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,355 @@
+package k8s
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// unknownZone groups Pods that are unscheduled or on Nodes without a zone label
+const unknownZone = "unknown"
+
+// ZoneDistribution describes how a Deployment's Pods are spread across availability zones
+type ZoneDistribution struct {
+	Deployment      string         `json:"deployment"`
+	Namespace       string         `json:"namespace"`
+	Zones           map[string]int `json:"zones"` // zone -> pod count, includes empty zones
+	TotalPods       int            `json:"totalPods"`
+	ExpectedPerZone float64        `json:"expectedPerZone"` // Even share across known zones
+	ImbalancedZones []string       `json:"imbalancedZones"` // Zones more than one pod away from the even share
+}
+
+// GetZoneDistribution counts a Deployment's Pods per zone (topology.kubernetes.io/zone
+// Node label) and identifies zones with a disproportionate share, which increases
+// the blast radius of a zone outage
+func (c *Client) GetZoneDistribution(namespace, deploymentName string) (*ZoneDistribution, error) {
+	deployment, err := c.InformerFactory.Apps().V1().Deployments().Lister().Deployments(namespace).Get(deploymentName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deployment selector: %w", err)
+	}
+
+	nodes, err := c.InformerFactory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := c.InformerFactory.Core().V1().Pods().Lister().Pods(namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	result := &ZoneDistribution{
+		Deployment:      deploymentName,
+		Namespace:       namespace,
+		Zones:           make(map[string]int),
+		ImbalancedZones: []string{},
+	}
+
+	// Seed every known zone so zones with no Pods show up as 0
+	nodeZones := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		if zone := node.Labels[v1.LabelTopologyZone]; zone != "" {
+			nodeZones[node.Name] = zone
+			result.Zones[zone] = 0
+		}
+	}
+	knownZones := len(result.Zones)
+
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		zone, ok := nodeZones[pod.Spec.NodeName]
+		if !ok {
+			zone = unknownZone
+		}
+		result.Zones[zone]++
+		result.TotalPods++
+	}
+
+	if knownZones == 0 {
+		return result, nil
+	}
+	zonedPods := result.TotalPods - result.Zones[unknownZone]
+	result.ExpectedPerZone = float64(zonedPods) / float64(knownZones)
+	for zone, count := range result.Zones {
+		if zone != unknownZone && math.Abs(float64(count)-result.ExpectedPerZone) > 1 {
+			result.ImbalancedZones = append(result.ImbalancedZones, zone)
+		}
+	}
+	sort.Strings(result.ImbalancedZones)
+
+	return result, nil
+}
+
+// spotNodeLabels are Node labels (key -> value) marking spot/preemptible capacity
+var spotNodeLabels = []struct{ key, value string }{
+	{"cloud.google.com/gke-spot", "true"},
+	{"cloud.google.com/gke-preemptible", "true"},
+	{"eks.amazonaws.com/capacityType", "SPOT"},
+	{"karpenter.sh/capacity-type", "spot"},
+	{"kubernetes.azure.com/agentpool", "spot"},
+	{"kubernetes.azure.com/scalesetpriority", "spot"},
+}
+
+// SpotPod is a Pod scheduled on a spot/preemptible Node
+type SpotPod struct {
+	Pod       string `json:"pod"`
+	Namespace string `json:"namespace"`
+	Node      string `json:"node"`
+	SpotLabel string `json:"spotLabel"` // The matching Node label, e.g. "eks.amazonaws.com/capacityType=SPOT"
+}
+
+// spotLabel returns the spot label on a Node as "key=value", or "" for regular capacity
+func spotLabel(node *v1.Node) string {
+	for _, label := range spotNodeLabels {
+		if node.Labels[label.key] == label.value {
+			return label.key + "=" + label.value
+		}
+	}
+	return ""
+}
+
+// GetSpotInstancePods returns Pods in a namespace ("" for all) scheduled on spot or
+// preemptible Nodes, which can be reclaimed by the cloud provider at any time
+func (c *Client) GetSpotInstancePods(namespace string) ([]SpotPod, error) {
+	nodes, err := c.InformerFactory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	spotNodes := make(map[string]string)
+	for _, node := range nodes {
+		if label := spotLabel(node); label != "" {
+			spotNodes[node.Name] = label
+		}
+	}
+
+	result := []SpotPod{}
+	if len(spotNodes) == 0 {
+		return result, nil
+	}
+
+	pods, err := c.InformerFactory.Core().V1().Pods().Lister().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods {
+		if label, ok := spotNodes[pod.Spec.NodeName]; ok {
+			result = append(result, SpotPod{
+				Pod:       pod.Name,
+				Namespace: pod.Namespace,
+				Node:      pod.Spec.NodeName,
+				SpotLabel: label,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Pod < result[j].Pod
+	})
+	return result, nil
+}
+
+// nodePoolLabels are Node labels naming the node pool, in order of precedence
+var nodePoolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"kubernetes.azure.com/agentpool",
+	"karpenter.sh/nodepool",
+	"node.kubernetes.io/pool",
+}
+
+// unknownNodePool groups Pods that are unscheduled or on Nodes without a pool label
+const unknownNodePool = "unknown"
+
+// NodePoolPlacement summarizes the workloads running on a node pool
+type NodePoolPlacement struct {
+	PodCount   int      `json:"podCount"`
+	Namespaces []string `json:"namespaces"`
+	Workloads  []string `json:"workloads"` // "namespace/Kind/name" of each Pod's top-level controller
+}
+
+// nodePool returns the node pool name from Node labels
+func nodePool(node *v1.Node) string {
+	for _, key := range nodePoolLabels {
+		if pool := node.Labels[key]; pool != "" {
+			return pool
+		}
+	}
+	return unknownNodePool
+}
+
+// GetNodePoolDistribution groups Pods in a namespace ("" for all) by the node pool
+// they run on, surfacing workloads placed on unexpected (e.g. expensive) pools
+func (c *Client) GetNodePoolDistribution(namespace string) (map[string]*NodePoolPlacement, error) {
+	nodes, err := c.InformerFactory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodePools := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		nodePools[node.Name] = nodePool(node)
+	}
+
+	pods, err := c.InformerFactory.Core().V1().Pods().Lister().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	result := make(map[string]*NodePoolPlacement)
+	namespaceSets := make(map[string]map[string]bool)
+	workloadSets := make(map[string]map[string]bool)
+	for _, pod := range pods {
+		pool, ok := nodePools[pod.Spec.NodeName]
+		if !ok {
+			pool = unknownNodePool
+		}
+		if result[pool] == nil {
+			result[pool] = &NodePoolPlacement{}
+			namespaceSets[pool] = make(map[string]bool)
+			workloadSets[pool] = make(map[string]bool)
+		}
+		result[pool].PodCount++
+		namespaceSets[pool][pod.Namespace] = true
+		workloadSets[pool][c.podWorkload(pod)] = true
+	}
+
+	for pool, placement := range result {
+		placement.Namespaces = sortedKeys(namespaceSets[pool])
+		placement.Workloads = sortedKeys(workloadSets[pool])
+	}
+	return result, nil
+}
+
+// podWorkload returns "namespace/Kind/name" of a Pod's top-level controller, following
+// ReplicaSets up to their Deployment. Standalone Pods are reported as themselves.
+func (c *Client) podWorkload(pod *v1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return pod.Namespace + "/Pod/" + pod.Name
+	}
+	if owner.Kind == "ReplicaSet" {
+		rs, err := c.InformerFactory.Apps().V1().ReplicaSets().Lister().ReplicaSets(pod.Namespace).Get(owner.Name)
+		if err == nil {
+			if rsOwner := metav1.GetControllerOf(rs); rsOwner != nil {
+				return pod.Namespace + "/" + rsOwner.Kind + "/" + rsOwner.Name
+			}
+		}
+	}
+	return pod.Namespace + "/" + owner.Kind + "/" + owner.Name
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NodePodDensity describes the Pods running on a Node and how much of its capacity they request
+type NodePodDensity struct {
+	Node        string             `json:"node"`
+	PodCount    int                `json:"podCount"`
+	Pods        []string           `json:"pods"`        // "namespace/name", sorted
+	Requests    map[string]string  `json:"requests"`    // Summed container requests: cpu, memory
+	Allocatable map[string]string  `json:"allocatable"` // cpu, memory, pods
+	Utilization map[string]float64 `json:"utilization"` // Percent of allocatable: cpu, memory, pods
+}
+
+// percentOf returns used as a percentage of total, or 0 when total is zero
+func percentOf(used, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(used/total*1000) / 10
+}
+
+// GetPodsPerNode returns the Pod density of every Node (or only nodeName when set),
+// sorted by pod count descending. Completed Pods don't hold resources and are skipped.
+func (c *Client) GetPodsPerNode(nodeName string) ([]NodePodDensity, error) {
+	var nodes []*v1.Node
+	if nodeName != "" {
+		node, err := c.InformerFactory.Core().V1().Nodes().Lister().Get(nodeName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node: %w", err)
+		}
+		nodes = []*v1.Node{node}
+	} else {
+		var err error
+		nodes, err = c.InformerFactory.Core().V1().Nodes().Lister().List(labels.Everything())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+	}
+
+	pods, err := c.InformerFactory.Core().V1().Pods().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	podsByNode := make(map[string][]*v1.Pod)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	result := make([]NodePodDensity, 0, len(nodes))
+	for _, node := range nodes {
+		nodePods := podsByNode[node.Name]
+		cpu := resource.Quantity{}
+		memory := resource.Quantity{}
+		names := make([]string, 0, len(nodePods))
+		for _, pod := range nodePods {
+			names = append(names, pod.Namespace+"/"+pod.Name)
+			for _, container := range pod.Spec.Containers {
+				cpu.Add(*container.Resources.Requests.Cpu())
+				memory.Add(*container.Resources.Requests.Memory())
+			}
+		}
+		sort.Strings(names)
+
+		allocatable := node.Status.Allocatable
+		result = append(result, NodePodDensity{
+			Node:     node.Name,
+			PodCount: len(nodePods),
+			Pods:     names,
+			Requests: map[string]string{
+				"cpu":    cpu.String(),
+				"memory": memory.String(),
+			},
+			Allocatable: map[string]string{
+				"cpu":    allocatable.Cpu().String(),
+				"memory": allocatable.Memory().String(),
+				"pods":   allocatable.Pods().String(),
+			},
+			Utilization: map[string]float64{
+				"cpu":    percentOf(float64(cpu.MilliValue()), float64(allocatable.Cpu().MilliValue())),
+				"memory": percentOf(float64(memory.Value()), float64(allocatable.Memory().Value())),
+				"pods":   percentOf(float64(len(nodePods)), float64(allocatable.Pods().Value())),
+			},
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PodCount != result[j].PodCount {
+			return result[i].PodCount > result[j].PodCount
+		}
+		return result[i].Node < result[j].Node
+	})
+	return result, nil
+}
@@ -7,6 +7,9 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 
 	"github.com/user/k8v/internal/types"
@@ -35,18 +38,96 @@ func TransformPod(pod *v1.Pod, cache *ResourceCache) *types.Resource {
 			DependsOn:   append(ExtractConfigMapDeps(pod), ExtractSecretDeps(pod)...),
 			ExposedBy:   FindReverseRelationships(podID, types.RelExposes, cache),
 			ScheduledOn: ExtractPodNodeScheduling(pod),
+			ProtectedBy: FindReverseRelationships(podID, types.RelProtects, cache),
 		},
 
 		Labels:      pod.Labels,
 		Annotations: pod.Annotations,
 		CreatedAt:   pod.CreationTimestamp.Time,
-		Spec:        pod.Spec,
+		Spec:        types.NewSpec(types.NewPodSpec(pod.Spec, extractContainerInfo(pod))),
 		YAML:        marshalToYAML(pod),
+
+		ResourceVersion: pod.ResourceVersion,
+	}
+
+	if reason := getPodImagePolicyWarning(pod); reason != "" {
+		if resource.Health == types.HealthHealthy {
+			resource.Health = types.HealthWarning
+		}
+		// Copy before annotating so the informer's object is never mutated
+		resource.Annotations = copyAnnotations(pod.Annotations)
+		resource.SetAnnotation(HealthReasonAnnotation, reason)
 	}
 
 	return resource
 }
 
+// HealthReasonAnnotation is set on transformed resources to explain a k8v-computed health warning
+const HealthReasonAnnotation = "k8v.io/health-reason"
+
+// DisruptionBlockedAnnotation is set on Deployments whose pods are selected by a
+// PodDisruptionBudget allowing no disruptions, listing the budgets (comma-separated)
+const DisruptionBlockedAnnotation = "k8v.io/disruption-blocked"
+
+// copyAnnotations returns a copy of an object's annotations with room for one more
+func copyAnnotations(annotations map[string]string) map[string]string {
+	copied := make(map[string]string, len(annotations)+1)
+	for key, value := range annotations {
+		copied[key] = value
+	}
+	return copied
+}
+
+// extractContainerInfo combines container specs with their statuses
+func extractContainerInfo(pod *v1.Pod) []types.ContainerInfo {
+	statuses := make(map[string]v1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	containers := make([]types.ContainerInfo, 0, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		status := statuses[c.Name]
+		containers = append(containers, types.ContainerInfo{
+			Name:            c.Name,
+			Image:           c.Image,
+			ImagePullPolicy: string(c.ImagePullPolicy),
+			RestartCount:    status.RestartCount,
+			Ready:           status.Ready,
+		})
+	}
+	return containers
+}
+
+// getPodImagePolicyWarning flags containers that always pull a "latest" image, which makes
+// the running version depend on when the Pod was scheduled
+func getPodImagePolicyWarning(pod *v1.Pod) string {
+	var names []string
+	for _, c := range pod.Spec.Containers {
+		if c.ImagePullPolicy == v1.PullAlways && isLatestImage(c.Image) {
+			names = append(names, c.Name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "imagePullPolicy Always with latest tag: " + strings.Join(names, ", ")
+}
+
+// isLatestImage reports whether an image reference uses the "latest" tag, explicitly or
+// implicitly (no tag and no digest)
+func isLatestImage(image string) bool {
+	if strings.Contains(image, "@") {
+		return false // Pinned by digest
+	}
+	name := image
+	if slash := strings.LastIndex(image, "/"); slash >= 0 {
+		name = image[slash+1:] // Registry hosts may contain ":port"
+	}
+	colon := strings.LastIndex(name, ":")
+	return colon < 0 || name[colon+1:] == "latest"
+}
+
 // TransformDeployment converts a Kubernetes Deployment to our Resource model
 func TransformDeployment(deployment *appsv1.Deployment, cache *ResourceCache) *types.Resource {
 	deploymentID := types.BuildID("Deployment", deployment.Namespace, deployment.Name)
@@ -73,8 +154,19 @@ func TransformDeployment(deployment *appsv1.Deployment, cache *ResourceCache) *t
 		Labels:      deployment.Labels,
 		Annotations: deployment.Annotations,
 		CreatedAt:   deployment.CreationTimestamp.Time,
-		Spec:        deployment.Spec,
+		Spec:        types.NewSpec(types.DeploymentSpec{DeploymentSpec: deployment.Spec}),
 		YAML:        marshalToYAML(deployment),
+
+		ResourceVersion: deployment.ResourceVersion,
+	}
+
+	// A budget allowing no disruptions blocks node drains and upgrades from evicting the pods
+	if blockers := FindBlockingPDBs(deployment.Namespace, deployment.Spec.Template.Labels, cache); len(blockers) > 0 {
+		if resource.Health == types.HealthHealthy {
+			resource.Health = types.HealthWarning
+		}
+		resource.Annotations = copyAnnotations(deployment.Annotations)
+		resource.SetAnnotation(DisruptionBlockedAnnotation, strings.Join(blockers, ","))
 	}
 
 	return resource
@@ -106,8 +198,10 @@ func TransformReplicaSet(rs *appsv1.ReplicaSet, cache *ResourceCache) *types.Res
 		Labels:      rs.Labels,
 		Annotations: rs.Annotations,
 		CreatedAt:   rs.CreationTimestamp.Time,
-		Spec:        rs.Spec,
+		Spec:        types.NewSpec(types.ReplicaSetSpec{ReplicaSetSpec: rs.Spec}),
 		YAML:        marshalToYAML(rs),
+
+		ResourceVersion: rs.ResourceVersion,
 	}
 
 	return resource
@@ -140,8 +234,10 @@ func TransformService(service *v1.Service, cache *ResourceCache) *types.Resource
 		Labels:      service.Labels,
 		Annotations: service.Annotations,
 		CreatedAt:   service.CreationTimestamp.Time,
-		Spec:        service.Spec,
+		Spec:        types.NewSpec(types.ServiceSpec{ServiceSpec: service.Spec}),
 		YAML:        marshalToYAML(service),
+
+		ResourceVersion: service.ResourceVersion,
 	}
 
 	return resource
@@ -171,8 +267,10 @@ func TransformIngress(ingress *netv1.Ingress, cache *ResourceCache) *types.Resou
 		Labels:      ingress.Labels,
 		Annotations: ingress.Annotations,
 		CreatedAt:   ingress.CreationTimestamp.Time,
-		Spec:        ingress.Spec,
+		Spec:        types.NewSpec(types.IngressSpec{IngressSpec: ingress.Spec}),
 		YAML:        marshalToYAML(ingress),
+
+		ResourceVersion: ingress.ResourceVersion,
 	}
 
 	return resource
@@ -204,8 +302,10 @@ func TransformConfigMap(cm *v1.ConfigMap, cache *ResourceCache) *types.Resource
 		Labels:      cm.Labels,
 		Annotations: cm.Annotations,
 		CreatedAt:   cm.CreationTimestamp.Time,
-		Spec:        cm.Data,
+		Spec:        types.NewSpec(types.ConfigMapSpec(cm.Data)),
 		YAML:        marshalToYAML(cm),
+
+		ResourceVersion: cm.ResourceVersion,
 	}
 
 	return resource
@@ -238,10 +338,10 @@ func TransformSecret(secret *v1.Secret, cache *ResourceCache) *types.Resource {
 		Annotations: secret.Annotations,
 		CreatedAt:   secret.CreationTimestamp.Time,
 		// Don't include actual secret data in Spec
-		Spec: map[string]interface{}{
-			"type": string(secret.Type),
-		},
+		Spec: types.NewSpec(types.SecretSpec{Type: string(secret.Type)}),
 		YAML: marshalToYAML(secret),
+
+		ResourceVersion: secret.ResourceVersion,
 	}
 
 	return resource
@@ -396,8 +496,10 @@ func TransformNode(node *v1.Node, cache *ResourceCache) *types.Resource {
 		Labels:      node.Labels,
 		Annotations: node.Annotations,
 		CreatedAt:   node.CreationTimestamp.Time,
-		Spec:        extractNodeSpec(node),
+		Spec:        types.NewSpec(extractNodeSpec(node)),
 		YAML:        marshalToYAML(node),
+
+		ResourceVersion: node.ResourceVersion,
 	}
 
 	return resource
@@ -481,24 +583,272 @@ func computeNodeHealth(node *v1.Node) types.HealthState {
 }
 
 // extractNodeSpec extracts relevant node spec information for display
-func extractNodeSpec(node *v1.Node) map[string]interface{} {
-	return map[string]interface{}{
-		"capacity": map[string]string{
-			"cpu":    node.Status.Capacity.Cpu().String(),
-			"memory": node.Status.Capacity.Memory().String(),
-			"pods":   node.Status.Capacity.Pods().String(),
+func extractNodeSpec(node *v1.Node) types.NodeSpec {
+	return types.NodeSpec{
+		Capacity: types.NodeResources{
+			CPU:    node.Status.Capacity.Cpu().String(),
+			Memory: node.Status.Capacity.Memory().String(),
+			Pods:   node.Status.Capacity.Pods().String(),
+		},
+		Allocatable: types.NodeResources{
+			CPU:    node.Status.Allocatable.Cpu().String(),
+			Memory: node.Status.Allocatable.Memory().String(),
+			Pods:   node.Status.Allocatable.Pods().String(),
+		},
+		NodeInfo: types.NodeInfo{
+			OSImage:          node.Status.NodeInfo.OSImage,
+			KernelVersion:    node.Status.NodeInfo.KernelVersion,
+			KubeletVersion:   node.Status.NodeInfo.KubeletVersion,
+			ContainerRuntime: node.Status.NodeInfo.ContainerRuntimeVersion,
+		},
+		Unschedulable: node.Spec.Unschedulable,
+	}
+}
+
+// Annotations marking the cluster's default StorageClass (the beta one is still honored by Kubernetes)
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// noDefaultStorageClassMessage explains the warning set on StorageClasses when none is the default
+const noDefaultStorageClassMessage = "No default StorageClass in cluster: PVCs without storageClassName will stay Pending"
+
+// TransformStorageClass converts a Kubernetes StorageClass to our Resource model
+func TransformStorageClass(sc *storagev1.StorageClass, cache *ResourceCache) *types.Resource {
+	scID := types.BuildID("StorageClass", "", sc.Name) // StorageClasses are cluster-scoped
+
+	isDefault := IsDefaultStorageClass(sc.Annotations)
+	hasDefault := isDefault
+	if !hasDefault {
+		for _, other := range cache.ListByType("StorageClass") {
+			if other.ID != scID && IsDefaultStorageClass(other.Annotations) {
+				hasDefault = true
+				break
+			}
+		}
+	}
+
+	resource := &types.Resource{
+		ID:        scID,
+		Type:      "StorageClass",
+		Name:      sc.Name,
+		Namespace: "", // StorageClasses are cluster-scoped
+
+		Status: types.ResourceStatus{
+			Phase:   "Active",
+			Ready:   "",
+			Message: "",
+		},
+
+		Health: types.HealthHealthy,
+
+		Relationships: types.Relationships{
+			UsedBy: FindReverseRelationships(scID, types.RelDependsOn, cache),
+		},
+
+		Labels:      sc.Labels,
+		Annotations: sc.Annotations,
+		CreatedAt:   sc.CreationTimestamp.Time,
+		Spec:        types.NewSpec(extractStorageClassSpec(sc, isDefault)),
+		YAML:        marshalToYAML(sc),
+
+		ResourceVersion: sc.ResourceVersion,
+	}
+
+	if isDefault {
+		resource.Status.Phase = "Default"
+	}
+	if !hasDefault {
+		resource.Health = types.HealthWarning
+		resource.Status.Message = noDefaultStorageClassMessage
+	}
+
+	return resource
+}
+
+// IsDefaultStorageClass reports whether StorageClass annotations mark it as the cluster default
+func IsDefaultStorageClass(annotations map[string]string) bool {
+	return annotations[defaultStorageClassAnnotation] == "true" ||
+		annotations[betaDefaultStorageClassAnnotation] == "true"
+}
+
+// extractStorageClassSpec extracts relevant StorageClass fields for display
+func extractStorageClassSpec(sc *storagev1.StorageClass, isDefault bool) types.StorageClassSpec {
+	reclaimPolicy := string(v1.PersistentVolumeReclaimDelete) // API server default
+	if sc.ReclaimPolicy != nil {
+		reclaimPolicy = string(*sc.ReclaimPolicy)
+	}
+	volumeBindingMode := string(storagev1.VolumeBindingImmediate) // API server default
+	if sc.VolumeBindingMode != nil {
+		volumeBindingMode = string(*sc.VolumeBindingMode)
+	}
+	allowVolumeExpansion := sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion
+
+	return types.StorageClassSpec{
+		Provisioner:          sc.Provisioner,
+		ReclaimPolicy:        reclaimPolicy,
+		VolumeBindingMode:    volumeBindingMode,
+		AllowVolumeExpansion: allowVolumeExpansion,
+		IsDefault:            isDefault,
+		Parameters:           sc.Parameters,
+	}
+}
+
+// TransformPersistentVolumeClaim converts a Kubernetes PersistentVolumeClaim to our Resource model
+func TransformPersistentVolumeClaim(pvc *v1.PersistentVolumeClaim, cache *ResourceCache) *types.Resource {
+	pvcID := types.BuildID("PersistentVolumeClaim", pvc.Namespace, pvc.Name)
+
+	resource := &types.Resource{
+		ID:        pvcID,
+		Type:      "PersistentVolumeClaim",
+		Name:      pvc.Name,
+		Namespace: pvc.Namespace,
+
+		Status: types.ResourceStatus{
+			Phase:   string(pvc.Status.Phase),
+			Ready:   "",
+			Message: "",
 		},
-		"allocatable": map[string]string{
-			"cpu":    node.Status.Allocatable.Cpu().String(),
-			"memory": node.Status.Allocatable.Memory().String(),
-			"pods":   node.Status.Allocatable.Pods().String(),
+
+		Health: computePVCHealth(pvc),
+
+		Relationships: types.Relationships{
+			OwnedBy:   ExtractOwners(pvc),
+			DependsOn: ExtractPVCStorageClassDeps(pvc),
+			UsedBy:    FindReverseRelationships(pvcID, types.RelDependsOn, cache),
 		},
-		"nodeInfo": map[string]string{
-			"osImage":          node.Status.NodeInfo.OSImage,
-			"kernelVersion":    node.Status.NodeInfo.KernelVersion,
-			"kubeletVersion":   node.Status.NodeInfo.KubeletVersion,
-			"containerRuntime": node.Status.NodeInfo.ContainerRuntimeVersion,
+
+		Labels:      pvc.Labels,
+		Annotations: pvc.Annotations,
+		CreatedAt:   pvc.CreationTimestamp.Time,
+		Spec:        types.NewSpec(extractPVCSpec(pvc)),
+		YAML:        marshalToYAML(pvc),
+
+		ResourceVersion: pvc.ResourceVersion,
+	}
+
+	return resource
+}
+
+// computePVCHealth determines health state based on the claim phase
+func computePVCHealth(pvc *v1.PersistentVolumeClaim) types.HealthState {
+	switch pvc.Status.Phase {
+	case v1.ClaimBound:
+		return types.HealthHealthy
+	case v1.ClaimPending:
+		return types.HealthWarning
+	case v1.ClaimLost:
+		return types.HealthError
+	default:
+		return types.HealthUnknown
+	}
+}
+
+// extractPVCSpec extracts relevant PersistentVolumeClaim fields for display
+func extractPVCSpec(pvc *v1.PersistentVolumeClaim) types.PersistentVolumeClaimSpec {
+	storageClass := ""
+	if pvc.Spec.StorageClassName != nil {
+		storageClass = *pvc.Spec.StorageClassName
+	}
+	accessModes := make([]string, 0, len(pvc.Spec.AccessModes))
+	for _, mode := range pvc.Spec.AccessModes {
+		accessModes = append(accessModes, string(mode))
+	}
+	capacity := ""
+	if storage, ok := pvc.Status.Capacity[v1.ResourceStorage]; ok {
+		capacity = storage.String()
+	}
+
+	return types.PersistentVolumeClaimSpec{
+		StorageClassName: storageClass,
+		VolumeName:       pvc.Spec.VolumeName,
+		AccessModes:      accessModes,
+		Capacity:         capacity,
+	}
+}
+
+// TransformPDB converts a Kubernetes PodDisruptionBudget to our Resource model
+func TransformPDB(pdb *policyv1.PodDisruptionBudget, cache *ResourceCache) *types.Resource {
+	pdbID := types.BuildID("PodDisruptionBudget", pdb.Namespace, pdb.Name)
+
+	resource := &types.Resource{
+		ID:        pdbID,
+		Type:      "PodDisruptionBudget",
+		Name:      pdb.Name,
+		Namespace: pdb.Namespace,
+
+		Status: types.ResourceStatus{
+			Phase:   getPDBPhase(pdb),
+			Ready:   fmt.Sprintf("%d/%d", pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy),
+			Message: getPDBMessage(pdb),
+		},
+
+		Health: computePDBHealth(pdb),
+
+		Relationships: types.Relationships{
+			OwnedBy:  ExtractOwners(pdb),
+			Protects: FindProtectedPods(pdb, cache),
 		},
-		"unschedulable": node.Spec.Unschedulable,
+
+		Labels:      pdb.Labels,
+		Annotations: pdb.Annotations,
+		CreatedAt:   pdb.CreationTimestamp.Time,
+		Spec:        types.NewSpec(extractPDBSpec(pdb)),
+		YAML:        marshalToYAML(pdb),
+
+		ResourceVersion: pdb.ResourceVersion,
+	}
+
+	return resource
+}
+
+// getPDBPhase returns a short summary of whether the budget currently allows disruptions
+func getPDBPhase(pdb *policyv1.PodDisruptionBudget) string {
+	if pdb.Status.DisruptionsAllowed == 0 {
+		return "Blocking"
+	}
+	return "Allowing"
+}
+
+// getPDBMessage explains why a budget blocks or is below its minimum
+func getPDBMessage(pdb *policyv1.PodDisruptionBudget) string {
+	if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+		return fmt.Sprintf("%d healthy pods, %d required", pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy)
+	}
+	if pdb.Status.DisruptionsAllowed == 0 {
+		return "No disruptions allowed: node drains and upgrades will block"
+	}
+	return ""
+}
+
+// computePDBHealth determines health state based on allowed disruptions
+func computePDBHealth(pdb *policyv1.PodDisruptionBudget) types.HealthState {
+	if pdb.Status.DisruptionsAllowed == 0 {
+		return types.HealthError
+	}
+	if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+		return types.HealthWarning
+	}
+	return types.HealthHealthy
+}
+
+// extractPDBSpec extracts relevant PodDisruptionBudget fields for display
+func extractPDBSpec(pdb *policyv1.PodDisruptionBudget) types.PodDisruptionBudgetSpec {
+	spec := types.PodDisruptionBudgetSpec{
+		DisruptionsAllowed: pdb.Status.DisruptionsAllowed,
+		CurrentHealthy:     pdb.Status.CurrentHealthy,
+		DesiredHealthy:     pdb.Status.DesiredHealthy,
+		ExpectedPods:       pdb.Status.ExpectedPods,
+	}
+	if pdb.Spec.MinAvailable != nil {
+		spec.MinAvailable = pdb.Spec.MinAvailable.String()
+	}
+	if pdb.Spec.MaxUnavailable != nil {
+		spec.MaxUnavailable = pdb.Spec.MaxUnavailable.String()
+	}
+	if pdb.Spec.Selector != nil {
+		spec.Selector = metav1.FormatLabelSelector(pdb.Spec.Selector)
 	}
+	return spec
 }
@@ -0,0 +1,692 @@
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// healthyDeployment returns an available Deployment whose pods are labeled app=web
+func healthyDeployment() *appsv1.Deployment {
+	replicas := int32(2)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: map[string]string{"team": "a"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 2, ReadyReplicas: 2, AvailableReplicas: 2, UpdatedReplicas: 2},
+	}
+}
+
+func TestTransformDeploymentDisruptionBlocked(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		pdb         *policyv1.PodDisruptionBudget
+		wantBlocked string
+		wantHealth  types.HealthState
+	}{
+		{
+			name:       "no budget",
+			wantHealth: types.HealthHealthy,
+		},
+		{
+			name: "budget allowing disruptions",
+			pdb: &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-pdb", Namespace: "default"},
+				Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+				Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+			},
+			wantHealth: types.HealthHealthy,
+		},
+		{
+			name: "blocking budget",
+			pdb: &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-pdb", Namespace: "default"},
+				Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			},
+			wantBlocked: "web-pdb",
+			wantHealth:  types.HealthWarning,
+		},
+		{
+			name: "blocking budget selecting every pod",
+			pdb: &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "all-pdb", Namespace: "default"},
+				Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{}},
+			},
+			wantBlocked: "all-pdb",
+			wantHealth:  types.HealthWarning,
+		},
+		{
+			name: "blocking budget without a selector",
+			pdb: &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "none-pdb", Namespace: "default"},
+			},
+			wantHealth: types.HealthHealthy,
+		},
+		{
+			name: "blocking budget for other pods",
+			pdb: &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "db-pdb", Namespace: "default"},
+				Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}},
+			},
+			wantHealth: types.HealthHealthy,
+		},
+		{
+			name: "blocking budget in another namespace",
+			pdb: &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-pdb", Namespace: "other"},
+				Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			},
+			wantHealth: types.HealthHealthy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cache := NewResourceCache()
+			defer cache.Close()
+			if tt.pdb != nil {
+				cache.Set(TransformPDB(tt.pdb, cache))
+			}
+
+			deployment := healthyDeployment()
+			resource := TransformDeployment(deployment, cache)
+
+			if got := resource.Annotations[DisruptionBlockedAnnotation]; got != tt.wantBlocked {
+				t.Errorf("%s = %q, want %q", DisruptionBlockedAnnotation, got, tt.wantBlocked)
+			}
+			if resource.Health != tt.wantHealth {
+				t.Errorf("Health = %q, want %q", resource.Health, tt.wantHealth)
+			}
+			if _, ok := deployment.Annotations[DisruptionBlockedAnnotation]; ok {
+				t.Error("TransformDeployment annotated the informer's Deployment")
+			}
+		})
+	}
+}
+
+func TestComputePodHealth(t *testing.T) {
+	t.Parallel()
+
+	waiting := func(reason string) v1.ContainerStatus {
+		return v1.ContainerStatus{Name: "app", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason}}}
+	}
+	tests := []struct {
+		name     string
+		phase    v1.PodPhase
+		statuses []v1.ContainerStatus
+		want     types.HealthState
+	}{
+		{"running and ready", v1.PodRunning, []v1.ContainerStatus{{Name: "app", Ready: true}}, types.HealthHealthy},
+		{"running, not ready", v1.PodRunning, []v1.ContainerStatus{{Name: "app"}}, types.HealthUnknown},
+		{"pending", v1.PodPending, nil, types.HealthWarning},
+		{"pending on a pull error", v1.PodPending, []v1.ContainerStatus{waiting("ErrImagePull")}, types.HealthError},
+		{"image pull backoff", v1.PodPending, []v1.ContainerStatus{waiting("ImagePullBackOff")}, types.HealthError},
+		{"crash loop", v1.PodRunning, []v1.ContainerStatus{waiting("CrashLoopBackOff")}, types.HealthError},
+		{"creating the container", v1.PodPending, []v1.ContainerStatus{waiting("ContainerCreating")}, types.HealthWarning},
+		{
+			"container exited with an error", v1.PodRunning,
+			[]v1.ContainerStatus{{Name: "app", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1}}}},
+			types.HealthError,
+		},
+		{"failed", v1.PodFailed, nil, types.HealthError},
+		{"succeeded", v1.PodSucceeded, nil, types.HealthUnknown},
+		{"unknown phase", v1.PodUnknown, nil, types.HealthUnknown},
+		{"no phase yet", "", nil, types.HealthUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pod := testPod("default", "web")
+			pod.Status = v1.PodStatus{Phase: tt.phase, ContainerStatuses: tt.statuses}
+			if got := computePodHealth(pod); got != tt.want {
+				t.Errorf("computePodHealth() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformPod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		mutate     func(pod *v1.Pod)
+		wantHealth types.HealthState
+		wantReady  string
+		wantReason string
+	}{
+		{
+			name:       "ready",
+			mutate:     func(pod *v1.Pod) {},
+			wantHealth: types.HealthHealthy,
+			wantReady:  "1/1",
+		},
+		{
+			name: "always pulling latest",
+			mutate: func(pod *v1.Pod) {
+				pod.Spec.Containers[0].Image = "nginx"
+				pod.Spec.Containers[0].ImagePullPolicy = v1.PullAlways
+			},
+			wantHealth: types.HealthWarning,
+			wantReady:  "1/1",
+			wantReason: "imagePullPolicy Always with latest tag: app",
+		},
+		{
+			name: "crash looping",
+			mutate: func(pod *v1.Pod) {
+				pod.Status.ContainerStatuses[0] = v1.ContainerStatus{
+					Name: "app", RestartCount: 5,
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}
+			},
+			wantHealth: types.HealthError,
+			wantReady:  "0/1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cache := NewResourceCache()
+			defer cache.Close()
+
+			pod := testPod("default", "web-abc")
+			pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web"}}
+			pod.Spec.NodeName = "node-1"
+			pod.Spec.Volumes = []v1.Volume{{Name: "config", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: "web-config"}}}}}
+			pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", Ready: true}}
+			tt.mutate(pod)
+			original := pod.DeepCopy()
+
+			resource := TransformPod(pod, cache)
+
+			if resource.ID != "Pod:default:web-abc" || resource.Type != "Pod" || resource.Namespace != "default" {
+				t.Errorf("identity = %s %s %s, want Pod:default:web-abc", resource.ID, resource.Type, resource.Namespace)
+			}
+			if resource.Health != tt.wantHealth {
+				t.Errorf("Health = %q, want %q", resource.Health, tt.wantHealth)
+			}
+			if resource.Status.Ready != tt.wantReady {
+				t.Errorf("Status.Ready = %q, want %q", resource.Status.Ready, tt.wantReady)
+			}
+			if got := resource.Annotations[HealthReasonAnnotation]; got != tt.wantReason {
+				t.Errorf("%s = %q, want %q", HealthReasonAnnotation, got, tt.wantReason)
+			}
+			if owners := resource.Relationships.OwnedBy; len(owners) != 1 || owners[0].ID != "ReplicaSet:default:web" {
+				t.Errorf("OwnedBy = %+v, want ReplicaSet:default:web", owners)
+			}
+			if deps := resource.Relationships.DependsOn; len(deps) != 1 || deps[0].ID != "ConfigMap:default:web-config" {
+				t.Errorf("DependsOn = %+v, want ConfigMap:default:web-config", deps)
+			}
+			if nodes := resource.Relationships.ScheduledOn; len(nodes) != 1 || nodes[0].ID != types.BuildID("Node", "", "node-1") {
+				t.Errorf("ScheduledOn = %+v, want node-1", nodes)
+			}
+
+			spec, err := resource.TypedSpec()
+			if err != nil {
+				t.Fatalf("TypedSpec() error = %v", err)
+			}
+			containers := spec.(*types.PodSpec).Containers
+			if len(containers) != 1 || containers[0].Image != pod.Spec.Containers[0].Image {
+				t.Errorf("Containers = %+v, want the app container", containers)
+			}
+			if len(pod.Annotations) != len(original.Annotations) {
+				t.Error("TransformPod annotated the informer's Pod")
+			}
+		})
+	}
+}
+
+func TestTransformDeployment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		status      appsv1.DeploymentStatus
+		wantPhase   string
+		wantReady   string
+		wantMessage string
+		wantHealth  types.HealthState
+	}{
+		{"available", appsv1.DeploymentStatus{Replicas: 2, ReadyReplicas: 2}, "Available", "2/2", "", types.HealthHealthy},
+		{"partly ready", appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 1}, "Progressing", "1/3", "2 replicas unavailable", types.HealthWarning},
+		{"none ready", appsv1.DeploymentStatus{Replicas: 2}, "Progressing", "0/2", "2 replicas unavailable", types.HealthError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cache := NewResourceCache()
+			defer cache.Close()
+			replicaSet := TransformReplicaSet(&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+				Name: "web-abc", Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+			}}, cache)
+			cache.Set(replicaSet)
+
+			deployment := healthyDeployment()
+			deployment.Status = tt.status
+			resource := TransformDeployment(deployment, cache)
+
+			if resource.Status.Phase != tt.wantPhase || resource.Status.Ready != tt.wantReady || resource.Status.Message != tt.wantMessage {
+				t.Errorf("Status = %+v, want phase %q, ready %q, message %q", resource.Status, tt.wantPhase, tt.wantReady, tt.wantMessage)
+			}
+			if resource.Health != tt.wantHealth {
+				t.Errorf("Health = %q, want %q", resource.Health, tt.wantHealth)
+			}
+			if owns := resource.Relationships.Owns; len(owns) != 1 || owns[0].ID != "ReplicaSet:default:web-abc" {
+				t.Errorf("Owns = %+v, want the cached ReplicaSet", owns)
+			}
+		})
+	}
+}
+
+func TestComputeDeploymentHealth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status appsv1.DeploymentStatus
+		want   types.HealthState
+	}{
+		{"all ready", appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 3}, types.HealthHealthy},
+		{"some ready", appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 2}, types.HealthWarning},
+		{"one of many ready", appsv1.DeploymentStatus{Replicas: 10, ReadyReplicas: 1}, types.HealthWarning},
+		{"none ready", appsv1.DeploymentStatus{Replicas: 3}, types.HealthError},
+		{"scaled to zero", appsv1.DeploymentStatus{}, types.HealthError},
+		{"surging during a rollout", appsv1.DeploymentStatus{Replicas: 4, ReadyReplicas: 3}, types.HealthWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			deployment := healthyDeployment()
+			deployment.Status = tt.status
+			if got := computeDeploymentHealth(deployment); got != tt.want {
+				t.Errorf("computeDeploymentHealth() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeReplicaSetHealth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		namespace string
+		status    appsv1.ReplicaSetStatus
+		want      types.HealthState
+	}{
+		{"all ready", "default", appsv1.ReplicaSetStatus{Replicas: 2, ReadyReplicas: 2}, types.HealthHealthy},
+		{"some ready", "default", appsv1.ReplicaSetStatus{Replicas: 2, ReadyReplicas: 1}, types.HealthWarning},
+		{"none ready", "default", appsv1.ReplicaSetStatus{Replicas: 2}, types.HealthError},
+		{"scaled down after a rollout", "default", appsv1.ReplicaSetStatus{}, types.HealthHealthy},
+		{"without a namespace", "", appsv1.ReplicaSetStatus{Replicas: 1, ReadyReplicas: 1}, types.HealthHealthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: tt.namespace}, Status: tt.status}
+			if got := computeReplicaSetHealth(rs); got != tt.want {
+				t.Errorf("computeReplicaSetHealth() = %q, want %q", got, tt.want)
+			}
+			cache := NewResourceCache()
+			defer cache.Close()
+			if resource := TransformReplicaSet(rs, cache); resource.Health != tt.want {
+				t.Errorf("TransformReplicaSet() Health = %q, want %q", resource.Health, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeNodeHealth(t *testing.T) {
+	t.Parallel()
+
+	condition := func(conditionType v1.NodeConditionType, status v1.ConditionStatus) v1.NodeCondition {
+		return v1.NodeCondition{Type: conditionType, Status: status}
+	}
+	ready := condition(v1.NodeReady, v1.ConditionTrue)
+	tests := []struct {
+		name          string
+		unschedulable bool
+		conditions    []v1.NodeCondition
+		want          types.HealthState
+	}{
+		{"ready", false, []v1.NodeCondition{ready}, types.HealthHealthy},
+		{"not ready", false, []v1.NodeCondition{condition(v1.NodeReady, v1.ConditionFalse)}, types.HealthError},
+		{"readiness unknown", false, []v1.NodeCondition{condition(v1.NodeReady, v1.ConditionUnknown)}, types.HealthError},
+		{"no conditions", false, nil, types.HealthError},
+		{"memory pressure", false, []v1.NodeCondition{ready, condition(v1.NodeMemoryPressure, v1.ConditionTrue)}, types.HealthWarning},
+		{"disk pressure", false, []v1.NodeCondition{ready, condition(v1.NodeDiskPressure, v1.ConditionTrue)}, types.HealthWarning},
+		{"PID pressure", false, []v1.NodeCondition{ready, condition(v1.NodePIDPressure, v1.ConditionTrue)}, types.HealthWarning},
+		{
+			"pressures cleared", false,
+			[]v1.NodeCondition{
+				ready,
+				condition(v1.NodeMemoryPressure, v1.ConditionFalse),
+				condition(v1.NodeDiskPressure, v1.ConditionFalse),
+				condition(v1.NodePIDPressure, v1.ConditionFalse),
+			},
+			types.HealthHealthy,
+		},
+		{"network unavailable", false, []v1.NodeCondition{ready, condition(v1.NodeNetworkUnavailable, v1.ConditionTrue)}, types.HealthHealthy},
+		{"not ready under pressure", false, []v1.NodeCondition{condition(v1.NodeReady, v1.ConditionFalse), condition(v1.NodeDiskPressure, v1.ConditionTrue)}, types.HealthError},
+		{"cordoned", true, []v1.NodeCondition{ready}, types.HealthWarning},
+		{"cordoned and not ready", true, []v1.NodeCondition{condition(v1.NodeReady, v1.ConditionFalse)}, types.HealthWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			node := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Spec:       v1.NodeSpec{Unschedulable: tt.unschedulable},
+				Status:     v1.NodeStatus{Conditions: tt.conditions},
+			}
+			if got := computeNodeHealth(node); got != tt.want {
+				t.Errorf("computeNodeHealth() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputePVCHealth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		phase v1.PersistentVolumeClaimPhase
+		want  types.HealthState
+	}{
+		{v1.ClaimBound, types.HealthHealthy},
+		{v1.ClaimPending, types.HealthWarning},
+		{v1.ClaimLost, types.HealthError},
+		{"", types.HealthUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			t.Parallel()
+			pvc := &v1.PersistentVolumeClaim{Status: v1.PersistentVolumeClaimStatus{Phase: tt.phase}}
+			if got := computePVCHealth(pvc); got != tt.want {
+				t.Errorf("computePVCHealth() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputePDBHealth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status policyv1.PodDisruptionBudgetStatus
+		want   types.HealthState
+	}{
+		{"disruptions allowed", policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1, CurrentHealthy: 3, DesiredHealthy: 2}, types.HealthHealthy},
+		{"no disruptions allowed", policyv1.PodDisruptionBudgetStatus{CurrentHealthy: 2, DesiredHealthy: 2}, types.HealthError},
+		{"below the desired healthy pods", policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1, CurrentHealthy: 1, DesiredHealthy: 2}, types.HealthWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pdb := &policyv1.PodDisruptionBudget{Status: tt.status}
+			if got := computePDBHealth(pdb); got != tt.want {
+				t.Errorf("computePDBHealth() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformNode(t *testing.T) {
+	t.Parallel()
+
+	notReady := v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionFalse, Message: "kubelet stopped posting node status"}
+	ready := v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue}
+	tests := []struct {
+		name          string
+		unschedulable bool
+		conditions    []v1.NodeCondition
+		wantPhase     string
+		wantReady     string
+		wantMessage   string
+	}{
+		{"ready", false, []v1.NodeCondition{ready}, "Ready", "True", ""},
+		{"not ready", false, []v1.NodeCondition{notReady}, "NotReady", "False", "kubelet stopped posting node status"},
+		{
+			"under pressure", false,
+			[]v1.NodeCondition{ready, {Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue}, {Type: v1.NodeDiskPressure, Status: v1.ConditionTrue}},
+			"Ready", "True", "MemoryPressure; DiskPressure",
+		},
+		{"cordoned", true, []v1.NodeCondition{ready}, "Unschedulable", "True", ""},
+		{"no conditions", false, nil, "Unknown", "Unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cache := NewResourceCache()
+			defer cache.Close()
+			node := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Spec:       v1.NodeSpec{Unschedulable: tt.unschedulable},
+				Status: v1.NodeStatus{
+					Conditions: tt.conditions,
+					Capacity:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("16Gi"), v1.ResourcePods: resource.MustParse("110")},
+					NodeInfo:   v1.NodeSystemInfo{KubeletVersion: "v1.31.0", ContainerRuntimeVersion: "containerd://1.7.0"},
+				},
+			}
+
+			got := TransformNode(node, cache)
+			if got.ID != types.BuildID("Node", "", "node-1") || got.Namespace != "" {
+				t.Errorf("identity = %s in %q, want a cluster-scoped Node", got.ID, got.Namespace)
+			}
+			if got.Status.Phase != tt.wantPhase || got.Status.Ready != tt.wantReady || got.Status.Message != tt.wantMessage {
+				t.Errorf("Status = %+v, want phase %q, ready %q, message %q", got.Status, tt.wantPhase, tt.wantReady, tt.wantMessage)
+			}
+			spec, err := got.TypedSpec()
+			if err != nil {
+				t.Fatalf("TypedSpec() error = %v", err)
+			}
+			nodeSpec := spec.(*types.NodeSpec)
+			if nodeSpec.Capacity.CPU != "4" || nodeSpec.Capacity.Memory != "16Gi" || nodeSpec.NodeInfo.ContainerRuntime != "containerd://1.7.0" {
+				t.Errorf("Spec = %+v, want the Node's capacity and runtime", nodeSpec)
+			}
+			if nodeSpec.Unschedulable != tt.unschedulable {
+				t.Errorf("Spec.Unschedulable = %v, want %v", nodeSpec.Unschedulable, tt.unschedulable)
+			}
+		})
+	}
+}
+
+func TestTransformStorageClass(t *testing.T) {
+	t.Parallel()
+
+	retain := v1.PersistentVolumeReclaimRetain
+	waitForConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	expand := true
+	storageClass := func(name string, annotations map[string]string) *storagev1.StorageClass {
+		return &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations}, Provisioner: "ebs.csi.aws.com"}
+	}
+	tests := []struct {
+		name        string
+		class       *storagev1.StorageClass
+		cached      *storagev1.StorageClass // Another StorageClass already in the cache
+		wantPhase   string
+		wantHealth  types.HealthState
+		wantReclaim string
+		wantBinding string
+	}{
+		{
+			name:        "default",
+			class:       storageClass("gp3", map[string]string{defaultStorageClassAnnotation: "true"}),
+			wantPhase:   "Default",
+			wantHealth:  types.HealthHealthy,
+			wantReclaim: "Delete",
+			wantBinding: "Immediate",
+		},
+		{
+			name:        "beta default",
+			class:       storageClass("gp2", map[string]string{betaDefaultStorageClassAnnotation: "true"}),
+			wantPhase:   "Default",
+			wantHealth:  types.HealthHealthy,
+			wantReclaim: "Delete",
+			wantBinding: "Immediate",
+		},
+		{
+			name: "another class is the default",
+			class: func() *storagev1.StorageClass {
+				sc := storageClass("retained", nil)
+				sc.ReclaimPolicy, sc.VolumeBindingMode, sc.AllowVolumeExpansion = &retain, &waitForConsumer, &expand
+				return sc
+			}(),
+			cached:      storageClass("gp3", map[string]string{defaultStorageClassAnnotation: "true"}),
+			wantPhase:   "Active",
+			wantHealth:  types.HealthHealthy,
+			wantReclaim: "Retain",
+			wantBinding: "WaitForFirstConsumer",
+		},
+		{
+			name:        "no default in the cluster",
+			class:       storageClass("gp3", map[string]string{defaultStorageClassAnnotation: "false"}),
+			wantPhase:   "Active",
+			wantHealth:  types.HealthWarning,
+			wantReclaim: "Delete",
+			wantBinding: "Immediate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cache := NewResourceCache()
+			defer cache.Close()
+			if tt.cached != nil {
+				cache.Set(TransformStorageClass(tt.cached, cache))
+			}
+
+			resource := TransformStorageClass(tt.class, cache)
+			if resource.Status.Phase != tt.wantPhase || resource.Health != tt.wantHealth {
+				t.Errorf("phase %q, health %q, want %q, %q", resource.Status.Phase, resource.Health, tt.wantPhase, tt.wantHealth)
+			}
+			if tt.wantHealth == types.HealthWarning && resource.Status.Message != noDefaultStorageClassMessage {
+				t.Errorf("Status.Message = %q, want the missing default warning", resource.Status.Message)
+			}
+			spec, err := resource.TypedSpec()
+			if err != nil {
+				t.Fatalf("TypedSpec() error = %v", err)
+			}
+			scSpec := spec.(*types.StorageClassSpec)
+			if scSpec.ReclaimPolicy != tt.wantReclaim || scSpec.VolumeBindingMode != tt.wantBinding {
+				t.Errorf("Spec = %+v, want reclaim %q and binding %q", scSpec, tt.wantReclaim, tt.wantBinding)
+			}
+			if scSpec.IsDefault != (tt.wantPhase == "Default") || scSpec.AllowVolumeExpansion != (tt.class.AllowVolumeExpansion != nil) {
+				t.Errorf("Spec = %+v, want IsDefault %v", scSpec, tt.wantPhase == "Default")
+			}
+		})
+	}
+}
+
+func TestTransformPersistentVolumeClaim(t *testing.T) {
+	t.Parallel()
+
+	gp3 := "gp3"
+	tests := []struct {
+		name         string
+		pvc          *v1.PersistentVolumeClaim
+		wantHealth   types.HealthState
+		wantDeps     int
+		wantCapacity string
+	}{
+		{
+			name: "bound",
+			pvc: &v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+				Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &gp3, VolumeName: "pv-1", AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}},
+				Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound, Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")}},
+			},
+			wantHealth:   types.HealthHealthy,
+			wantDeps:     1,
+			wantCapacity: "10Gi",
+		},
+		{
+			name: "pending without a class",
+			pvc: &v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+				Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimPending},
+			},
+			wantHealth: types.HealthWarning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cache := NewResourceCache()
+			defer cache.Close()
+
+			got := TransformPersistentVolumeClaim(tt.pvc, cache)
+			if got.Health != tt.wantHealth || got.Status.Phase != string(tt.pvc.Status.Phase) {
+				t.Errorf("health %q, phase %q, want %q, %q", got.Health, got.Status.Phase, tt.wantHealth, tt.pvc.Status.Phase)
+			}
+			if deps := got.Relationships.DependsOn; len(deps) != tt.wantDeps {
+				t.Errorf("DependsOn = %+v, want %d StorageClasses", deps, tt.wantDeps)
+			}
+			spec, err := got.TypedSpec()
+			if err != nil {
+				t.Fatalf("TypedSpec() error = %v", err)
+			}
+			if got := spec.(*types.PersistentVolumeClaimSpec).Capacity; got != tt.wantCapacity {
+				t.Errorf("Spec.Capacity = %q, want %q", got, tt.wantCapacity)
+			}
+		})
+	}
+}
+
+func TestTransformServiceAndIngress(t *testing.T) {
+	t.Parallel()
+
+	cache := NewResourceCache()
+	defer cache.Close()
+	cache.Set(TransformPod(testPod("default", "web"), cache))
+	cache.Set(TransformPod(testPod("default", "db"), cache))
+	cache.Set(TransformPod(testPod("other", "web"), cache))
+
+	service := TransformService(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	}, cache)
+	if exposes := service.Relationships.Exposes; len(exposes) != 1 || exposes[0].ID != "Pod:default:web" {
+		t.Errorf("Service Exposes = %+v, want only Pod:default:web", exposes)
+	}
+
+	backend := func(name string) netv1.IngressBackend {
+		return netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: name}}
+	}
+	defaultBackend := backend("web")
+	ingress := TransformIngress(&netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: netv1.IngressSpec{
+			DefaultBackend: &defaultBackend,
+			Rules: []netv1.IngressRule{
+				{IngressRuleValue: netv1.IngressRuleValue{HTTP: &netv1.HTTPIngressRuleValue{Paths: []netv1.HTTPIngressPath{
+					{Path: "/", Backend: backend("web")},
+					{Path: "/api", Backend: backend("api")},
+				}}}},
+				{Host: "no-http.example.com"},
+			},
+		},
+	}, cache)
+	routes := ingress.Relationships.RoutesTo
+	if len(routes) != 2 || routes[0].ID != "Service:default:web" || routes[1].ID != "Service:default:api" {
+		t.Errorf("Ingress RoutesTo = %+v, want Service:default:web and Service:default:api once each", routes)
+	}
+	if service.Health != types.HealthHealthy || ingress.Health != types.HealthHealthy {
+		t.Errorf("health = %q, %q, want both healthy", service.Health, ingress.Health)
+	}
+}
@@ -2,18 +2,32 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"sort"
+	"sync"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/user/k8v/internal/metrics"
 	"github.com/user/k8v/internal/types"
 )
 
+// eventHandlerTimeout bounds how long the event handler may block an informer goroutine
+const eventHandlerTimeout = 5 * time.Second
+
+// eventHandlerTimeouts counts events dropped because the event handler timed out
+var eventHandlerTimeouts = metrics.NewCounter("k8v_event_handler_timeouts_total", "Resource events dropped because the event handler didn't accept them within 5s")
+
 // EventType represents the type of Kubernetes event
 type EventType string
 
@@ -22,12 +36,23 @@ const (
 	EventModified   EventType = "MODIFIED"
 	EventDeleted    EventType = "DELETED"
 	EventSyncStatus EventType = "SYNC_STATUS"
+
+	// EventSnapshotRequired tells a reconnecting WebSocket client that its ?since generation
+	// fell outside the replay window, so a full snapshot follows
+	EventSnapshotRequired EventType = "SNAPSHOT_REQUIRED"
+
+	// EventQuotaPressure reports a ResourceQuota crossing DefaultQuotaPressureThreshold
+	// It isn't a resource change: the resource only describes the quota and isn't cached
+	EventQuotaPressure EventType = "QUOTA_PRESSURE"
 )
 
 // ResourceEvent represents a resource change event
 type ResourceEvent struct {
 	Type     EventType       `json:"type"`
 	Resource *types.Resource `json:"resource"`
+
+	// Generation is the hub sequence number of the event, used by clients to resume with ?since
+	Generation int64 `json:"generation,omitempty"`
 }
 
 // SyncStatusEvent represents sync status update
@@ -40,21 +65,158 @@ type SyncStatusEvent struct {
 }
 
 // EventHandler is a callback function for resource events
-type EventHandler func(event ResourceEvent)
+// It runs in the informer's goroutine and must give up with ctx.Err() once ctx is done,
+// dropping the event, rather than block the informer (e.g. on a slow WebSocket client).
+type EventHandler func(ctx context.Context, event ResourceEvent) error
+
+// EventFilter decides whether a resource event is passed to the event handler
+// Filters only suppress live ADDED and MODIFIED events; the cache and snapshots still hold
+// every resource, so DELETED events always pass, or clients would keep deleted resources
+type EventFilter func(event ResourceEvent) bool
+
+// SystemNamespaces are the namespaces created by Kubernetes itself
+var SystemNamespaces = []string{"kube-system", "kube-node-lease", "kube-public"}
+
+// IgnoreSystemNamespacesFilter suppresses events for resources in SystemNamespaces
+func IgnoreSystemNamespacesFilter() EventFilter {
+	system := make(map[string]bool)
+	for _, ns := range SystemNamespaces {
+		system[ns] = true
+	}
+	return func(event ResourceEvent) bool {
+		return !system[event.Resource.Namespace]
+	}
+}
+
+// IgnoreResourceTypeFilter suppresses events for resources of the given types
+func IgnoreResourceTypeFilter(resourceTypes ...string) EventFilter {
+	ignored := make(map[string]bool)
+	for _, t := range resourceTypes {
+		ignored[t] = true
+	}
+	return func(event ResourceEvent) bool {
+		return !ignored[event.Resource.Type]
+	}
+}
+
+// WatcherOptions configures optional watcher behavior
+type WatcherOptions struct {
+	// EnforceNetworkPolicyCoverage marks Pods not selected by any NetworkPolicy as warnings
+	EnforceNetworkPolicyCoverage bool
+
+	// WatchResourceQuotas watches ResourceQuotas for GetQuotaPressure and QUOTA_PRESSURE events
+	WatchResourceQuotas bool
+
+	// RequiredLabels marks Deployments whose pod template lacks any of these labels as warnings
+	RequiredLabels []string
+
+	// WatchResourceTypes limits which resource types are watched (default: DefaultWatchResourceTypes)
+	WatchResourceTypes []string
+
+	// WatchAllAPIs additionally watches every listable resource found by API discovery
+	// using dynamic informers, capped at maxDynamicResourcesPerGVR objects per resource
+	WatchAllAPIs bool
+
+	// EnableHelmGrouping maintains a synthetic HelmRelease resource per Helm release, owning
+	// every resource labeled with the release's app.kubernetes.io/instance
+	EnableHelmGrouping bool
+
+	// ExcludeNamespaces hides resources in these namespaces from snapshots, live events and
+	// the namespace list. They are still watched and cached, so relationships to them remain intact.
+	ExcludeNamespaces []string
+}
 
 // Watcher manages all Kubernetes resource watchers using Informers
 type Watcher struct {
 	client  *Client
 	cache   *ResourceCache
 	handler EventHandler
+	options WatcherOptions
+
+	// Initial load batching: while loading, added resources are buffered and stored in
+	// batches with LoadSnapshot instead of taking the cache write lock once per resource.
+	// flushTimer stores a partial batch after initialLoadFlushDelay.
+	loadMu     sync.Mutex
+	loading    bool
+	pending    []*types.Resource
+	flushTimer *time.Timer
+
+	// Object counts per dynamically watched resource, enforcing maxDynamicResourcesPerGVR.
+	// dynamicIDs maps each counted resource to its GVR, so deletes and LRU evictions,
+	// both seen through a cache subscription, can uncount it.
+	dynamicMu        sync.Mutex
+	dynamicCounts    map[schema.GroupVersionResource]int
+	dynamicIDs       map[string]schema.GroupVersionResource
+	dynamicCapWarned map[schema.GroupVersionResource]bool
+
+	filtersMu sync.RWMutex
+	filters   []EventFilter
+
+	helm *helmGrouping // nil unless EnableHelmGrouping
+
+	watchErrorHandler WatchErrorHandler
+	watchErrMu        sync.Mutex
+	watchFailures     map[string]int // resource type -> consecutive watch failures
 }
 
 // NewWatcher creates a new watcher with the given client and cache
 func NewWatcher(client *Client, resourceCache *ResourceCache, handler EventHandler) *Watcher {
+	return NewWatcherWithOptions(client, resourceCache, handler, WatcherOptions{})
+}
+
+// NewWatcherWithOptions creates a new watcher with the given client, cache and options
+func NewWatcherWithOptions(client *Client, resourceCache *ResourceCache, handler EventHandler, options WatcherOptions) *Watcher {
 	return &Watcher{
 		client:  client,
 		cache:   resourceCache,
 		handler: handler,
+		options: options,
+
+		dynamicCounts:    make(map[schema.GroupVersionResource]int),
+		dynamicIDs:       make(map[string]schema.GroupVersionResource),
+		dynamicCapWarned: make(map[schema.GroupVersionResource]bool),
+		watchFailures:    make(map[string]int),
+	}
+}
+
+// AddEventFilter registers a filter evaluated, in registration order, before every ADDED
+// and MODIFIED event is passed to the handler. An event is suppressed as soon as one
+// filter returns false.
+func (w *Watcher) AddEventFilter(f EventFilter) {
+	w.filtersMu.Lock()
+	defer w.filtersMu.Unlock()
+	w.filters = append(w.filters, f)
+}
+
+// emit passes an event to the handler unless its namespace is excluded or a filter
+// suppresses it. The handler gets eventHandlerTimeout to accept the event before it is dropped.
+// Only the handler is timed out: UpdateBidirectionalRelationships, run before emit, is
+// in-memory cache work that never blocks, and stopping it halfway would leave one-sided
+// relationships.
+func (w *Watcher) emit(event ResourceEvent) {
+	if w.helm != nil {
+		w.observeHelmMember(event)
+	}
+	if w.isNamespaceExcluded(event.Resource.Namespace) {
+		return
+	}
+
+	if event.Type != EventDeleted {
+		w.filtersMu.RLock()
+		filters := w.filters
+		w.filtersMu.RUnlock()
+		for _, f := range filters {
+			if !f(event) {
+				return
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventHandlerTimeout)
+	defer cancel()
+	if err := w.handler(ctx, event); errors.Is(err, context.DeadlineExceeded) {
+		eventHandlerTimeouts.Inc()
+		log.Printf("Warning: event handler timed out, dropped %s event for %s %s", event.Type, event.Resource.Type, event.Resource.ID)
 	}
 }
 
@@ -63,168 +225,367 @@ func (w *Watcher) GetClient() *Client {
 	return w.client
 }
 
-// Start registers all informer event handlers and starts watching
+// DefaultWatchResourceTypes lists every resource type the watcher supports, in registration order
+var DefaultWatchResourceTypes = []string{
+	"Pod", "Deployment", "ReplicaSet", "Service", "Ingress", "ConfigMap", "Secret", "Node",
+	"StorageClass", "PersistentVolumeClaim", "PodDisruptionBudget",
+}
+
+// watchedInformer pairs a resource type with its informer and event handlers
+type watchedInformer struct {
+	resourceType string
+	syncName     string // reported by WaitForCacheSync
+	informer     func() cache.SharedIndexInformer
+	handlers     cache.ResourceEventHandlerFuncs
+}
+
+// informers returns the informers for every supported resource type
+// Informers are created lazily so types that aren't watched never LIST the API server
+func (w *Watcher) informers() []watchedInformer {
+	factory := w.client.InformerFactory
+	return []watchedInformer{
+		{"Pod", "Pods", factory.Core().V1().Pods().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handlePodAdd, UpdateFunc: w.handlePodUpdate, DeleteFunc: w.handlePodDelete,
+		}},
+		{"Deployment", "Deployments", factory.Apps().V1().Deployments().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handleDeploymentAdd, UpdateFunc: w.handleDeploymentUpdate, DeleteFunc: w.handleDeploymentDelete,
+		}},
+		{"ReplicaSet", "ReplicaSets", factory.Apps().V1().ReplicaSets().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handleReplicaSetAdd, UpdateFunc: w.handleReplicaSetUpdate, DeleteFunc: w.handleReplicaSetDelete,
+		}},
+		{"Service", "Services", factory.Core().V1().Services().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handleServiceAdd, UpdateFunc: w.handleServiceUpdate, DeleteFunc: w.handleServiceDelete,
+		}},
+		{"Ingress", "Ingresses", factory.Networking().V1().Ingresses().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handleIngressAdd, UpdateFunc: w.handleIngressUpdate, DeleteFunc: w.handleIngressDelete,
+		}},
+		{"ConfigMap", "ConfigMaps", factory.Core().V1().ConfigMaps().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handleConfigMapAdd, UpdateFunc: w.handleConfigMapUpdate, DeleteFunc: w.handleConfigMapDelete,
+		}},
+		{"Secret", "Secrets", factory.Core().V1().Secrets().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handleSecretAdd, UpdateFunc: w.handleSecretUpdate, DeleteFunc: w.handleSecretDelete,
+		}},
+		{"Node", "Nodes", factory.Core().V1().Nodes().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handleNodeAdd, UpdateFunc: w.handleNodeUpdate, DeleteFunc: w.handleNodeDelete,
+		}},
+		{"StorageClass", "StorageClasses", factory.Storage().V1().StorageClasses().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handleStorageClassAdd, UpdateFunc: w.handleStorageClassUpdate, DeleteFunc: w.handleStorageClassDelete,
+		}},
+		{"PersistentVolumeClaim", "PersistentVolumeClaims", factory.Core().V1().PersistentVolumeClaims().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handlePersistentVolumeClaimAdd, UpdateFunc: w.handlePersistentVolumeClaimUpdate, DeleteFunc: w.handlePersistentVolumeClaimDelete,
+		}},
+		{"PodDisruptionBudget", "PodDisruptionBudgets", factory.Policy().V1().PodDisruptionBudgets().Informer, cache.ResourceEventHandlerFuncs{
+			AddFunc: w.handlePDBAdd, UpdateFunc: w.handlePDBUpdate, DeleteFunc: w.handlePDBDelete,
+		}},
+	}
+}
+
+// initialLoadBatchSize is the number of resources buffered before a batch is stored during the initial load
+const initialLoadBatchSize = 500
+
+// initialLoadFlushDelay bounds how long a resource stays buffered during the initial load
+// An informer that never syncs (e.g. a forbidden resource type) delays FinishInitialLoad
+// indefinitely, and must not keep what the other informers listed out of the cache.
+const initialLoadFlushDelay = 250 * time.Millisecond
+
+// watchTypes returns the resource types with a typed informer
+func (w *Watcher) watchTypes() []string {
+	if len(w.options.WatchResourceTypes) == 0 {
+		return DefaultWatchResourceTypes
+	}
+	return w.options.WatchResourceTypes
+}
+
+// Watches reports whether resources of a type are watched with a typed informer
+func (w *Watcher) Watches(resourceType string) bool {
+	return slices.Contains(w.watchTypes(), resourceType)
+}
+
+// Start registers informer event handlers for the watched resource types and starts watching
+// Informers for types not in WatcherOptions.WatchResourceTypes are never created
+// Callers must then start the informers and wait for the sync themselves; StartAsync does
+// both and is preferred over this Start + Client.WaitForCacheSync pattern.
 func (w *Watcher) Start() error {
-	// Register Pod handlers
-	podInformer := w.client.InformerFactory.Core().V1().Pods().Informer()
-	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.handlePodAdd,
-		UpdateFunc: w.handlePodUpdate,
-		DeleteFunc: w.handlePodDelete,
-	})
+	watchTypes := w.watchTypes()
 
-	// Register Deployment handlers
-	deploymentInformer := w.client.InformerFactory.Apps().V1().Deployments().Informer()
-	deploymentInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.handleDeploymentAdd,
-		UpdateFunc: w.handleDeploymentUpdate,
-		DeleteFunc: w.handleDeploymentDelete,
-	})
+	available := make(map[string]watchedInformer)
+	for _, wi := range w.informers() {
+		available[wi.resourceType] = wi
+	}
+	for _, resourceType := range watchTypes {
+		if _, ok := available[resourceType]; !ok {
+			return fmt.Errorf("unknown resource type: %s", resourceType)
+		}
+	}
 
-	// Register ReplicaSet handlers
-	replicaSetInformer := w.client.InformerFactory.Apps().V1().ReplicaSets().Informer()
-	replicaSetInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.handleReplicaSetAdd,
-		UpdateFunc: w.handleReplicaSetUpdate,
-		DeleteFunc: w.handleReplicaSetDelete,
-	})
+	if w.options.EnableHelmGrouping {
+		w.helm = newHelmGrouping()
+	}
 
-	// Register Service handlers
-	serviceInformer := w.client.InformerFactory.Core().V1().Services().Informer()
-	serviceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.handleServiceAdd,
-		UpdateFunc: w.handleServiceUpdate,
-		DeleteFunc: w.handleServiceDelete,
-	})
+	// Batch adds until FinishInitialLoad, once the initial lists have been delivered
+	w.loadMu.Lock()
+	w.loading = true
+	w.loadMu.Unlock()
 
-	// Register Ingress handlers
-	ingressInformer := w.client.InformerFactory.Networking().V1().Ingresses().Informer()
-	ingressInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.handleIngressAdd,
-		UpdateFunc: w.handleIngressUpdate,
-		DeleteFunc: w.handleIngressDelete,
-	})
+	for _, resourceType := range watchTypes {
+		wi := available[resourceType]
+		informer := wi.informer()
+		if err := informer.SetWatchErrorHandler(w.onWatchError(resourceType)); err != nil {
+			return fmt.Errorf("failed to register %s watch error handler: %w", resourceType, err)
+		}
+		registration, err := informer.AddEventHandler(wi.handlers)
+		if err != nil {
+			return fmt.Errorf("failed to register %s handlers: %w", resourceType, err)
+		}
+		// The registration syncs once the initial list has been delivered to our handlers,
+		// not just stored by the informer
+		w.client.trackInformer(wi.syncName, registration.HasSynced)
+	}
 
-	// Register ConfigMap handlers
-	configMapInformer := w.client.InformerFactory.Core().V1().ConfigMaps().Informer()
-	configMapInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.handleConfigMapAdd,
-		UpdateFunc: w.handleConfigMapUpdate,
-		DeleteFunc: w.handleConfigMapDelete,
-	})
+	// Watch NetworkPolicies only when Pod health depends on them
+	if w.options.EnforceNetworkPolicyCoverage {
+		w.registerNetworkPolicyCoverage()
+	}
 
-	// Register Secret handlers
-	secretInformer := w.client.InformerFactory.Core().V1().Secrets().Informer()
-	secretInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.handleSecretAdd,
-		UpdateFunc: w.handleSecretUpdate,
-		DeleteFunc: w.handleSecretDelete,
-	})
+	if w.options.WatchResourceQuotas {
+		w.registerQuotaPressure()
+	}
 
-	// Register Node handlers
-	nodeInformer := w.client.InformerFactory.Core().V1().Nodes().Informer()
-	nodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.handleNodeAdd,
-		UpdateFunc: w.handleNodeUpdate,
-		DeleteFunc: w.handleNodeDelete,
-	})
+	if w.options.WatchAllAPIs {
+		if err := w.registerDynamicInformers(context.Background()); err != nil {
+			return fmt.Errorf("failed to register dynamic informers: %w", err)
+		}
+	}
 
-	log.Println("All informer handlers registered")
+	log.Printf("Informer handlers registered for: %v", watchTypes)
 	return nil
 }
 
-// Pod event handlers
+// StartAsync registers the informer handlers like Start, starts the informers until ctx
+// is done and waits for their caches to sync in the background. The channel receives nil
+// once they have synced and the initial load is stored, or an error if ctx ends first.
+// It replaces calling Start, Client.Start, Client.WaitForCacheSync and FinishInitialLoad.
+func (w *Watcher) StartAsync(ctx context.Context) (<-chan error, error) {
+	if err := w.Start(); err != nil {
+		return nil, err
+	}
+	w.client.Start(ctx.Done())
 
-func (w *Watcher) handlePodAdd(obj interface{}) {
-	pod, ok := obj.(*v1.Pod)
-	if !ok {
+	synced := make(chan error, 1)
+	go func() {
+		if !w.client.WaitForCacheSync(ctx.Done()) {
+			synced <- fmt.Errorf("informer caches didn't sync: %w", ctx.Err())
+			return
+		}
+		// Store resources still batched by the initial load before reporting synced
+		w.FinishInitialLoad()
+		synced <- nil
+	}()
+	return synced, nil
+}
+
+// addResource stores a newly added resource, links its relationships and notifies the handler
+// During the initial load the resource is buffered and stored with the next batch instead
+func (w *Watcher) addResource(resource *types.Resource) {
+	w.loadMu.Lock()
+	if w.loading {
+		w.pending = append(w.pending, resource)
+		var batch []*types.Resource
+		if len(w.pending) >= initialLoadBatchSize {
+			batch, w.pending = w.pending, nil
+		} else if w.flushTimer == nil {
+			w.flushTimer = time.AfterFunc(initialLoadFlushDelay, w.flushPending)
+		}
+		w.loadMu.Unlock()
+		if batch != nil {
+			w.LoadSnapshot(batch)
+		}
 		return
 	}
+	w.loadMu.Unlock()
 
-	resource := TransformPod(pod, w.cache)
-	w.cache.Set(resource)
+	if !w.cache.Set(resource) {
+		return // Older than the cached version
+	}
 	UpdateBidirectionalRelationships(w.cache, resource)
 
 	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventAdded, Resource: resource})
+		w.emit(ResourceEvent{Type: EventAdded, Resource: resource})
 	}
 }
 
-func (w *Watcher) handlePodUpdate(oldObj, newObj interface{}) {
-	pod, ok := newObj.(*v1.Pod)
-	if !ok {
+// updateResource stores an updated resource and emits a MODIFIED event, unless the
+// update is older than the cached version: informers can redeliver a stale object
+// (e.g. on relist), and clients must not be sent what the cache rejected
+func (w *Watcher) updateResource(resource *types.Resource) {
+	if !w.cache.Set(resource) {
 		return
 	}
-
-	resource := TransformPod(pod, w.cache)
-	w.cache.Set(resource)
 	UpdateBidirectionalRelationships(w.cache, resource)
 
 	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventModified, Resource: resource})
+		w.emit(ResourceEvent{Type: EventModified, Resource: resource})
 	}
 }
 
-func (w *Watcher) handlePodDelete(obj interface{}) {
-	pod, ok := obj.(*v1.Pod)
-	if !ok {
-		return
+// deletedObject returns the last known state of a deleted object, unwrapping the
+// DeletedFinalStateUnknown tombstone informers deliver when they missed the delete
+// (e.g. across a watch reconnect)
+func deletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
+
+// deleteResource removes a resource (including one still buffered by the initial load)
+// and notifies the handler
+func (w *Watcher) deleteResource(id string) {
+	w.loadMu.Lock()
+	for i, r := range w.pending {
+		if r.ID == id {
+			w.pending = append(w.pending[:i], w.pending[i+1:]...)
+			break
+		}
 	}
+	w.loadMu.Unlock()
 
-	id := types.BuildID("Pod", pod.Namespace, pod.Name)
 	resource, _ := w.cache.Get(id)
 	w.cache.Delete(id)
 
 	if w.handler != nil && resource != nil {
-		w.handler(ResourceEvent{Type: EventDeleted, Resource: resource})
+		w.emit(ResourceEvent{Type: EventDeleted, Resource: resource})
 	}
 }
 
-// Deployment event handlers
+// LoadSnapshot stores resources under a single cache write lock, then links their
+// relationships and notifies the handler of each one
+func (w *Watcher) LoadSnapshot(resources []*types.Resource) {
+	w.cache.BulkSet(resources)
+	pdbNamespaces := make(map[string]bool)
+	for _, resource := range resources {
+		UpdateBidirectionalRelationships(w.cache, resource)
+		if resource.Type == "PodDisruptionBudget" {
+			pdbNamespaces[resource.Namespace] = true
+		}
+	}
 
-func (w *Watcher) handleDeploymentAdd(obj interface{}) {
-	deployment, ok := obj.(*appsv1.Deployment)
+	if w.handler != nil {
+		for _, resource := range resources {
+			w.emit(ResourceEvent{Type: EventAdded, Resource: resource})
+		}
+	}
+
+	// Deployments batched with a budget were transformed before it was cached
+	for namespace := range pdbNamespaces {
+		w.refreshDisruptionBlocked(namespace)
+	}
+}
+
+// flushPending stores the resources buffered by the initial load so far, without
+// ending it
+func (w *Watcher) flushPending() {
+	w.loadMu.Lock()
+	batch := w.pending
+	w.pending = nil
+	w.flushTimer = nil
+	w.loadMu.Unlock()
+
+	if len(batch) > 0 {
+		w.LoadSnapshot(batch)
+	}
+}
+
+// FinishInitialLoad stores the resources still buffered by the initial load and stops
+// batching; subsequent adds are stored immediately. Call it once informers have synced.
+func (w *Watcher) FinishInitialLoad() {
+	w.loadMu.Lock()
+	batch := w.pending
+	w.pending = nil
+	w.loading = false
+	if w.flushTimer != nil {
+		w.flushTimer.Stop()
+		w.flushTimer = nil
+	}
+	w.loadMu.Unlock()
+
+	w.LoadSnapshot(batch)
+}
+
+// transformPod converts a Pod, applying watcher options that affect its health
+func (w *Watcher) transformPod(pod *v1.Pod) *types.Resource {
+	resource := TransformPod(pod, w.cache)
+	if w.options.EnforceNetworkPolicyCoverage {
+		w.applyNetworkPolicyCoverage(resource, pod)
+	}
+	return resource
+}
+
+func (w *Watcher) handlePodAdd(obj interface{}) {
+	pod, ok := obj.(*v1.Pod)
 	if !ok {
 		return
 	}
 
-	resource := TransformDeployment(deployment, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
+	w.addResource(w.transformPod(pod))
+}
 
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventAdded, Resource: resource})
+func (w *Watcher) handlePodUpdate(oldObj, newObj interface{}) {
+	pod, ok := newObj.(*v1.Pod)
+	if !ok {
+		return
 	}
+
+	resource := w.transformPod(pod)
+	w.updateResource(resource)
 }
 
-func (w *Watcher) handleDeploymentUpdate(oldObj, newObj interface{}) {
-	deployment, ok := newObj.(*appsv1.Deployment)
+func (w *Watcher) handlePodDelete(obj interface{}) {
+	pod, ok := deletedObject(obj).(*v1.Pod)
 	if !ok {
 		return
 	}
 
-	resource := TransformDeployment(deployment, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
+	w.deleteResource(types.BuildID("Pod", pod.Namespace, pod.Name))
+}
 
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventModified, Resource: resource})
+// Deployment event handlers
+
+// transformDeployment converts a Deployment, applying watcher options that affect its health
+func (w *Watcher) transformDeployment(deployment *appsv1.Deployment) *types.Resource {
+	resource := TransformDeployment(deployment, w.cache)
+	if len(w.options.RequiredLabels) > 0 {
+		w.applyRequiredLabels(resource, deployment)
 	}
+	return resource
 }
 
-func (w *Watcher) handleDeploymentDelete(obj interface{}) {
+func (w *Watcher) handleDeploymentAdd(obj interface{}) {
 	deployment, ok := obj.(*appsv1.Deployment)
 	if !ok {
 		return
 	}
 
-	id := types.BuildID("Deployment", deployment.Namespace, deployment.Name)
-	resource, _ := w.cache.Get(id)
-	w.cache.Delete(id)
+	w.addResource(w.transformDeployment(deployment))
+}
 
-	if w.handler != nil && resource != nil {
-		w.handler(ResourceEvent{Type: EventDeleted, Resource: resource})
+func (w *Watcher) handleDeploymentUpdate(oldObj, newObj interface{}) {
+	deployment, ok := newObj.(*appsv1.Deployment)
+	if !ok {
+		return
+	}
+
+	resource := w.transformDeployment(deployment)
+	w.updateResource(resource)
+}
+
+func (w *Watcher) handleDeploymentDelete(obj interface{}) {
+	deployment, ok := deletedObject(obj).(*appsv1.Deployment)
+	if !ok {
+		return
 	}
+
+	w.deleteResource(types.BuildID("Deployment", deployment.Namespace, deployment.Name))
 }
 
 // ReplicaSet event handlers
@@ -235,13 +596,7 @@ func (w *Watcher) handleReplicaSetAdd(obj interface{}) {
 		return
 	}
 
-	resource := TransformReplicaSet(rs, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventAdded, Resource: resource})
-	}
+	w.addResource(TransformReplicaSet(rs, w.cache))
 }
 
 func (w *Watcher) handleReplicaSetUpdate(oldObj, newObj interface{}) {
@@ -251,27 +606,16 @@ func (w *Watcher) handleReplicaSetUpdate(oldObj, newObj interface{}) {
 	}
 
 	resource := TransformReplicaSet(rs, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventModified, Resource: resource})
-	}
+	w.updateResource(resource)
 }
 
 func (w *Watcher) handleReplicaSetDelete(obj interface{}) {
-	rs, ok := obj.(*appsv1.ReplicaSet)
+	rs, ok := deletedObject(obj).(*appsv1.ReplicaSet)
 	if !ok {
 		return
 	}
 
-	id := types.BuildID("ReplicaSet", rs.Namespace, rs.Name)
-	resource, _ := w.cache.Get(id)
-	w.cache.Delete(id)
-
-	if w.handler != nil && resource != nil {
-		w.handler(ResourceEvent{Type: EventDeleted, Resource: resource})
-	}
+	w.deleteResource(types.BuildID("ReplicaSet", rs.Namespace, rs.Name))
 }
 
 // Service event handlers
@@ -282,13 +626,7 @@ func (w *Watcher) handleServiceAdd(obj interface{}) {
 		return
 	}
 
-	resource := TransformService(service, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventAdded, Resource: resource})
-	}
+	w.addResource(TransformService(service, w.cache))
 }
 
 func (w *Watcher) handleServiceUpdate(oldObj, newObj interface{}) {
@@ -298,27 +636,16 @@ func (w *Watcher) handleServiceUpdate(oldObj, newObj interface{}) {
 	}
 
 	resource := TransformService(service, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventModified, Resource: resource})
-	}
+	w.updateResource(resource)
 }
 
 func (w *Watcher) handleServiceDelete(obj interface{}) {
-	service, ok := obj.(*v1.Service)
+	service, ok := deletedObject(obj).(*v1.Service)
 	if !ok {
 		return
 	}
 
-	id := types.BuildID("Service", service.Namespace, service.Name)
-	resource, _ := w.cache.Get(id)
-	w.cache.Delete(id)
-
-	if w.handler != nil && resource != nil {
-		w.handler(ResourceEvent{Type: EventDeleted, Resource: resource})
-	}
+	w.deleteResource(types.BuildID("Service", service.Namespace, service.Name))
 }
 
 // Ingress event handlers
@@ -329,13 +656,7 @@ func (w *Watcher) handleIngressAdd(obj interface{}) {
 		return
 	}
 
-	resource := TransformIngress(ingress, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventAdded, Resource: resource})
-	}
+	w.addResource(TransformIngress(ingress, w.cache))
 }
 
 func (w *Watcher) handleIngressUpdate(oldObj, newObj interface{}) {
@@ -345,27 +666,16 @@ func (w *Watcher) handleIngressUpdate(oldObj, newObj interface{}) {
 	}
 
 	resource := TransformIngress(ingress, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventModified, Resource: resource})
-	}
+	w.updateResource(resource)
 }
 
 func (w *Watcher) handleIngressDelete(obj interface{}) {
-	ingress, ok := obj.(*netv1.Ingress)
+	ingress, ok := deletedObject(obj).(*netv1.Ingress)
 	if !ok {
 		return
 	}
 
-	id := types.BuildID("Ingress", ingress.Namespace, ingress.Name)
-	resource, _ := w.cache.Get(id)
-	w.cache.Delete(id)
-
-	if w.handler != nil && resource != nil {
-		w.handler(ResourceEvent{Type: EventDeleted, Resource: resource})
-	}
+	w.deleteResource(types.BuildID("Ingress", ingress.Namespace, ingress.Name))
 }
 
 // ConfigMap event handlers
@@ -376,13 +686,7 @@ func (w *Watcher) handleConfigMapAdd(obj interface{}) {
 		return
 	}
 
-	resource := TransformConfigMap(cm, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventAdded, Resource: resource})
-	}
+	w.addResource(TransformConfigMap(cm, w.cache))
 }
 
 func (w *Watcher) handleConfigMapUpdate(oldObj, newObj interface{}) {
@@ -392,27 +696,16 @@ func (w *Watcher) handleConfigMapUpdate(oldObj, newObj interface{}) {
 	}
 
 	resource := TransformConfigMap(cm, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventModified, Resource: resource})
-	}
+	w.updateResource(resource)
 }
 
 func (w *Watcher) handleConfigMapDelete(obj interface{}) {
-	cm, ok := obj.(*v1.ConfigMap)
+	cm, ok := deletedObject(obj).(*v1.ConfigMap)
 	if !ok {
 		return
 	}
 
-	id := types.BuildID("ConfigMap", cm.Namespace, cm.Name)
-	resource, _ := w.cache.Get(id)
-	w.cache.Delete(id)
-
-	if w.handler != nil && resource != nil {
-		w.handler(ResourceEvent{Type: EventDeleted, Resource: resource})
-	}
+	w.deleteResource(types.BuildID("ConfigMap", cm.Namespace, cm.Name))
 }
 
 // Secret event handlers
@@ -423,13 +716,7 @@ func (w *Watcher) handleSecretAdd(obj interface{}) {
 		return
 	}
 
-	resource := TransformSecret(secret, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventAdded, Resource: resource})
-	}
+	w.addResource(TransformSecret(secret, w.cache))
 }
 
 func (w *Watcher) handleSecretUpdate(oldObj, newObj interface{}) {
@@ -439,27 +726,16 @@ func (w *Watcher) handleSecretUpdate(oldObj, newObj interface{}) {
 	}
 
 	resource := TransformSecret(secret, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventModified, Resource: resource})
-	}
+	w.updateResource(resource)
 }
 
 func (w *Watcher) handleSecretDelete(obj interface{}) {
-	secret, ok := obj.(*v1.Secret)
+	secret, ok := deletedObject(obj).(*v1.Secret)
 	if !ok {
 		return
 	}
 
-	id := types.BuildID("Secret", secret.Namespace, secret.Name)
-	resource, _ := w.cache.Get(id)
-	w.cache.Delete(id)
-
-	if w.handler != nil && resource != nil {
-		w.handler(ResourceEvent{Type: EventDeleted, Resource: resource})
-	}
+	w.deleteResource(types.BuildID("Secret", secret.Namespace, secret.Name))
 }
 
 // Node event handlers
@@ -470,13 +746,7 @@ func (w *Watcher) handleNodeAdd(obj interface{}) {
 		return
 	}
 
-	resource := TransformNode(node, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
-
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventAdded, Resource: resource})
-	}
+	w.addResource(TransformNode(node, w.cache))
 }
 
 func (w *Watcher) handleNodeUpdate(oldObj, newObj interface{}) {
@@ -486,32 +756,228 @@ func (w *Watcher) handleNodeUpdate(oldObj, newObj interface{}) {
 	}
 
 	resource := TransformNode(node, w.cache)
-	w.cache.Set(resource)
-	UpdateBidirectionalRelationships(w.cache, resource)
+	w.updateResource(resource)
+}
 
-	if w.handler != nil {
-		w.handler(ResourceEvent{Type: EventModified, Resource: resource})
+func (w *Watcher) handleNodeDelete(obj interface{}) {
+	node, ok := deletedObject(obj).(*v1.Node)
+	if !ok {
+		return
 	}
+
+	w.deleteResource(types.BuildID("Node", "", node.Name))
 }
 
-func (w *Watcher) handleNodeDelete(obj interface{}) {
-	node, ok := obj.(*v1.Node)
+// StorageClass event handlers
+
+func (w *Watcher) handleStorageClassAdd(obj interface{}) {
+	sc, ok := obj.(*storagev1.StorageClass)
 	if !ok {
 		return
 	}
 
-	id := types.BuildID("Node", "", node.Name)
-	resource, _ := w.cache.Get(id)
-	w.cache.Delete(id)
+	w.addResource(TransformStorageClass(sc, w.cache))
+	w.refreshStorageClassHealth()
+}
 
-	if w.handler != nil && resource != nil {
-		w.handler(ResourceEvent{Type: EventDeleted, Resource: resource})
+func (w *Watcher) handleStorageClassUpdate(oldObj, newObj interface{}) {
+	sc, ok := newObj.(*storagev1.StorageClass)
+	if !ok {
+		return
+	}
+
+	resource := TransformStorageClass(sc, w.cache)
+	w.updateResource(resource)
+	w.refreshStorageClassHealth()
+}
+
+func (w *Watcher) handleStorageClassDelete(obj interface{}) {
+	sc, ok := deletedObject(obj).(*storagev1.StorageClass)
+	if !ok {
+		return
+	}
+
+	w.deleteResource(types.BuildID("StorageClass", "", sc.Name))
+	w.refreshStorageClassHealth()
+}
+
+// refreshStorageClassHealth re-evaluates the "no default StorageClass" warning on all
+// cached StorageClasses, since adding or removing the default affects every other class
+func (w *Watcher) refreshStorageClassHealth() {
+	storageClasses := w.cache.ListByType("StorageClass")
+
+	hasDefault := false
+	for _, sc := range storageClasses {
+		if IsDefaultStorageClass(sc.Annotations) {
+			hasDefault = true
+			break
+		}
+	}
+
+	for _, sc := range storageClasses {
+		health, message := types.HealthHealthy, ""
+		if !hasDefault {
+			health, message = types.HealthWarning, noDefaultStorageClassMessage
+		}
+		if sc.Health == health && sc.Status.Message == message {
+			continue
+		}
+
+		updated := *sc
+		updated.Health = health
+		updated.Status.Message = message
+		w.cache.Set(&updated)
+
+		if w.handler != nil {
+			w.emit(ResourceEvent{Type: EventModified, Resource: &updated})
+		}
+	}
+}
+
+// PersistentVolumeClaim event handlers
+
+func (w *Watcher) handlePersistentVolumeClaimAdd(obj interface{}) {
+	pvc, ok := obj.(*v1.PersistentVolumeClaim)
+	if !ok {
+		return
+	}
+
+	w.addResource(TransformPersistentVolumeClaim(pvc, w.cache))
+}
+
+func (w *Watcher) handlePersistentVolumeClaimUpdate(oldObj, newObj interface{}) {
+	pvc, ok := newObj.(*v1.PersistentVolumeClaim)
+	if !ok {
+		return
+	}
+
+	resource := TransformPersistentVolumeClaim(pvc, w.cache)
+	w.updateResource(resource)
+}
+
+func (w *Watcher) handlePersistentVolumeClaimDelete(obj interface{}) {
+	pvc, ok := deletedObject(obj).(*v1.PersistentVolumeClaim)
+	if !ok {
+		return
 	}
+
+	w.deleteResource(types.BuildID("PersistentVolumeClaim", pvc.Namespace, pvc.Name))
+}
+
+// PodDisruptionBudget event handlers
+
+func (w *Watcher) handlePDBAdd(obj interface{}) {
+	pdb, ok := obj.(*policyv1.PodDisruptionBudget)
+	if !ok {
+		return
+	}
+
+	w.addResource(TransformPDB(pdb, w.cache))
+	w.refreshDisruptionBlocked(pdb.Namespace)
+}
+
+func (w *Watcher) handlePDBUpdate(oldObj, newObj interface{}) {
+	pdb, ok := newObj.(*policyv1.PodDisruptionBudget)
+	if !ok {
+		return
+	}
+
+	resource := TransformPDB(pdb, w.cache)
+	w.updateResource(resource)
+	w.refreshDisruptionBlocked(pdb.Namespace)
+}
+
+func (w *Watcher) handlePDBDelete(obj interface{}) {
+	pdb, ok := deletedObject(obj).(*policyv1.PodDisruptionBudget)
+	if !ok {
+		return
+	}
+
+	w.deleteResource(types.BuildID("PodDisruptionBudget", pdb.Namespace, pdb.Name))
+	w.refreshDisruptionBlocked(pdb.Namespace)
+}
+
+// refreshDisruptionBlocked re-transforms the cached Deployments in namespace whose
+// DisruptionBlockedAnnotation a PodDisruptionBudget change made stale
+func (w *Watcher) refreshDisruptionBlocked(namespace string) {
+	lister := w.client.InformerFactory.Apps().V1().Deployments().Lister()
+	for _, cached := range w.cache.ListByNamespace(namespace) {
+		if cached.Type != "Deployment" {
+			continue
+		}
+		deployment, err := lister.Deployments(namespace).Get(cached.Name)
+		if err != nil {
+			continue
+		}
+		resource := w.transformDeployment(deployment)
+		if resource.Annotations[DisruptionBlockedAnnotation] == cached.Annotations[DisruptionBlockedAnnotation] {
+			continue
+		}
+		w.updateResource(resource)
+	}
+}
+
+// PruneRestored deletes the restored resources no informer delivered during the initial
+// sync (see ResourceCache.PruneRestored), emitting a DELETED event for each
+func (w *Watcher) PruneRestored() {
+	for _, resource := range w.cache.PruneRestored() {
+		if w.handler != nil {
+			w.emit(ResourceEvent{Type: EventDeleted, Resource: resource})
+		}
+	}
+}
+
+// GetRequiredLabels returns the labels configured in WatcherOptions.RequiredLabels
+func (w *Watcher) GetRequiredLabels() []string {
+	return w.options.RequiredLabels
+}
+
+// isNamespaceExcluded reports whether a namespace is hidden by WatcherOptions.ExcludeNamespaces
+func (w *Watcher) isNamespaceExcluded(namespace string) bool {
+	for _, excluded := range w.options.ExcludeNamespaces {
+		if namespace == excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// listVisible returns all cached resources outside excluded namespaces
+func (w *Watcher) listVisible() []*types.Resource {
+	return w.visible(w.cache.Snapshot().List())
+}
+
+// visible drops the resources in excluded namespaces
+func (w *Watcher) visible(resources []*types.Resource) []*types.Resource {
+	if len(w.options.ExcludeNamespaces) == 0 {
+		return resources
+	}
+
+	visible := make([]*types.Resource, 0, len(resources))
+	for _, r := range resources {
+		if !w.isNamespaceExcluded(r.Namespace) {
+			visible = append(visible, r)
+		}
+	}
+	return visible
+}
+
+// GetResourcesByAnnotation returns the resources annotated with key=value, sorted by ID
+// Resources in excluded namespaces are left out, as in snapshots.
+func (w *Watcher) GetResourcesByAnnotation(key, value string) []*types.Resource {
+	resources := []*types.Resource{}
+	for _, r := range w.cache.ListByAnnotation(key, value) {
+		if !w.isNamespaceExcluded(r.Namespace) {
+			resources = append(resources, r)
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ID < resources[j].ID })
+	return resources
 }
 
 // GetSnapshot returns all current resources in the cache
 func (w *Watcher) GetSnapshot() []ResourceEvent {
-	resources := w.cache.List()
+	resources := w.listVisible()
 	events := make([]ResourceEvent, len(resources))
 
 	for i, resource := range resources {
@@ -528,7 +994,7 @@ func (w *Watcher) GetSnapshot() []ResourceEvent {
 // GetNamespaces returns all unique namespaces from cached resources
 func (w *Watcher) GetNamespaces() []string {
 	nsMap := make(map[string]bool)
-	resources := w.cache.List()
+	resources := w.listVisible()
 	for _, r := range resources {
 		if r.Namespace != "" {
 			nsMap[r.Namespace] = true
@@ -546,18 +1012,15 @@ func (w *Watcher) GetNamespaces() []string {
 // GetSnapshotFiltered returns resources filtered by namespace
 // Cluster-scoped resources (empty namespace) are always included
 func (w *Watcher) GetSnapshotFiltered(namespace string) []ResourceEvent {
-	allResources := w.cache.List()
+	snapshot := w.cache.Snapshot()
 	var resources []*types.Resource
 
 	if namespace == "" || namespace == "all" {
-		resources = allResources
+		resources = w.visible(snapshot.List())
 	} else {
 		// Filter by namespace, but always include cluster-scoped resources (empty namespace)
-		for _, r := range allResources {
-			if r.Namespace == "" || r.Namespace == namespace {
-				resources = append(resources, r)
-			}
-		}
+		resources = append(snapshot.ListByNamespace(""), snapshot.ListByNamespace(namespace)...)
+		resources = w.visible(resources)
 	}
 
 	events := make([]ResourceEvent, len(resources))
@@ -578,8 +1041,56 @@ func (w *Watcher) GetResourceCount() int {
 	return w.cache.Count()
 }
 
-// GetResourceCounts returns counts by resource type
-func (w *Watcher) GetResourceCounts(namespace string) map[string]int {
+// ResourceCountReport summarizes resource counts for a namespace
+type ResourceCountReport struct {
+	Total    int            `json:"total"`
+	ByType   map[string]int `json:"byType"`   // e.g. "Pod" -> 12
+	ByHealth map[string]int `json:"byHealth"` // keyed by HealthState, e.g. "warning" -> 3
+}
+
+// GetCacheMetrics returns the statistics of the watcher's cache
+func (w *Watcher) GetCacheMetrics() CacheMetrics {
+	return w.cache.Metrics()
+}
+
+// ResetCacheMetrics zeroes the counters of the watcher's cache
+func (w *Watcher) ResetCacheMetrics() {
+	w.cache.ResetMetrics()
+}
+
+// RelationshipError is a relationship whose target isn't in the cache
+type RelationshipError struct {
+	SourceID string                 `json:"sourceId"`
+	RelType  types.RelationshipType `json:"relType"`
+	TargetID string                 `json:"targetId"`
+	Reason   string                 `json:"reason"`
+}
+
+// ValidateRelationships returns the relationships of cached resources pointing at
+// resources that aren't cached, e.g. an owner deleted before the watcher started or of a
+// type that isn't watched. It is meant for debugging and walks the whole cache.
+func (w *Watcher) ValidateRelationships() []RelationshipError {
+	errs := []RelationshipError{}
+	for _, resource := range w.cache.List() {
+		for _, relType := range types.RelationshipTypes {
+			for _, ref := range resource.GetRelationship(relType) {
+				if w.cache.Contains(ref.ID) {
+					continue
+				}
+				errs = append(errs, RelationshipError{
+					SourceID: resource.ID,
+					RelType:  relType,
+					TargetID: ref.ID,
+					Reason:   "target not found in cache",
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// GetResourceCounts returns resource counts by type and by health state
+func (w *Watcher) GetResourceCounts(namespace string) ResourceCountReport {
 	var resources []*types.Resource
 	if namespace == "" || namespace == "all" {
 		resources = w.cache.List()
@@ -587,39 +1098,31 @@ func (w *Watcher) GetResourceCounts(namespace string) map[string]int {
 		resources = w.cache.ListByNamespace(namespace)
 	}
 
-	counts := make(map[string]int)
+	report := ResourceCountReport{
+		Total:    len(resources),
+		ByType:   make(map[string]int),
+		ByHealth: make(map[string]int),
+	}
 	for _, r := range resources {
-		counts[r.Type]++
+		report.ByType[r.Type]++
+		report.ByHealth[string(r.Health)]++
 	}
-	counts["total"] = len(resources)
 
-	return counts
+	return report
 }
 
 // GetSnapshotFilteredByType returns resources filtered by namespace and type
 // Cluster-scoped resources (empty namespace) are always included
 func (w *Watcher) GetSnapshotFilteredByType(namespace string, resourceType string) []ResourceEvent {
-	allResources := w.cache.List()
-	var resources []*types.Resource
-
-	if namespace == "" || namespace == "all" {
-		resources = allResources
-	} else {
-		// Filter by namespace, but always include cluster-scoped resources (empty namespace)
-		for _, r := range allResources {
-			if r.Namespace == "" || r.Namespace == namespace {
-				resources = append(resources, r)
-			}
-		}
-	}
-
-	// Filter by resource type
-	filtered := []*types.Resource{}
-	for _, r := range resources {
-		if resourceType == "" || resourceType == "all" || r.Type == resourceType {
-			filtered = append(filtered, r)
+	allNamespaces := namespace == "" || namespace == "all"
+	allTypes := resourceType == "" || resourceType == "all"
+	filtered := w.cache.ListWithFilter(func(r *types.Resource) bool {
+		// Cluster-scoped resources (empty namespace) are included in every namespace
+		if !allNamespaces && r.Namespace != "" && r.Namespace != namespace {
+			return false
 		}
-	}
+		return (allTypes || r.Type == resourceType) && !w.isNamespaceExcluded(r.Namespace)
+	})
 
 	events := make([]ResourceEvent, len(filtered))
 	for i, resource := range filtered {
@@ -634,11 +1137,109 @@ func (w *Watcher) GetSnapshotFilteredByType(namespace string, resourceType strin
 	return events
 }
 
+// GetResourcesByOwner returns the resources owned by ownerID (through ownerReferences),
+// sorted by ID. It is fast when the cache indexes relationships, see
+// CacheOptions.IndexRelationships.
+func (w *Watcher) GetResourcesByOwner(ownerID string) []*types.Resource {
+	resources := w.cache.ListByOwner(ownerID)
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ID < resources[j].ID })
+	return resources
+}
+
 // GetResource retrieves a single resource from the cache by ID
 func (w *Watcher) GetResource(id string) (*types.Resource, bool) {
 	return w.cache.Get(id)
 }
 
+// watchResourceBuffer is the number of undelivered events WatchResource keeps per watch;
+// when full the oldest is dropped, since watchers care about the latest state
+const watchResourceBuffer = 16
+
+// streamEventsBuffer is the number of undelivered events StreamResourceEvents and
+// StreamNamespaceEvents keep; larger than watchResourceBuffer as their callers usually
+// want every intermediate state
+const streamEventsBuffer = 256
+
+// WatchResource streams the changes to one cached resource until ctx is done, when the
+// channel is closed. Stores are reported as EventModified (the resource must already be
+// cached, EventAdded if it is re-created) and removals, including evictions, as EventDeleted.
+// Events bypass the watcher's event filters.
+func (w *Watcher) WatchResource(ctx context.Context, id string) (<-chan ResourceEvent, error) {
+	if id == "" || id == SubscribeAll {
+		return nil, fmt.Errorf("invalid resource id %q", id)
+	}
+	if !w.cache.Contains(id) {
+		return nil, fmt.Errorf("resource not found: %s", id)
+	}
+	return w.subscribeEvents(ctx, id, nil, map[string]bool{id: true}, watchResourceBuffer), nil
+}
+
+// StreamResourceEvents streams the events of one resource until ctx is done, when the
+// channel is closed. Unlike WatchResource the resource doesn't need to exist yet, so
+// callers can wait for it to be created.
+// Events bypass the watcher's event filters; when the consumer falls streamEventsBuffer
+// events behind, the oldest are dropped.
+func (w *Watcher) StreamResourceEvents(ctx context.Context, id string) <-chan ResourceEvent {
+	known := map[string]bool{}
+	if w.cache.Contains(id) {
+		known[id] = true
+	}
+	return w.subscribeEvents(ctx, id, nil, known, streamEventsBuffer)
+}
+
+// StreamNamespaceEvents streams the events of every resource in a namespace until ctx
+// is done, with the same semantics as StreamResourceEvents
+func (w *Watcher) StreamNamespaceEvents(ctx context.Context, namespace string) <-chan ResourceEvent {
+	known := map[string]bool{}
+	for _, r := range w.cache.ListByNamespace(namespace) {
+		known[r.ID] = true
+	}
+	inNamespace := func(r *types.Resource) bool { return r.Namespace == namespace }
+	return w.subscribeEvents(ctx, SubscribeAll, inNamespace, known, streamEventsBuffer)
+}
+
+// subscribeEvents turns the cache changes of id (or SubscribeAll) into resource events,
+// delivered on a channel closed once ctx is done. match, if set, selects the resources
+// reported. known holds the IDs already cached, whose stores are EventModified rather
+// than EventAdded; it is only touched by the handler, which the cache runs serially.
+func (w *Watcher) subscribeEvents(ctx context.Context, id string, match func(*types.Resource) bool, known map[string]bool, buffer int) <-chan ResourceEvent {
+	events := make(chan ResourceEvent, buffer)
+	subscription := w.cache.Subscribe(id, func(resource *types.Resource, op CacheOp) {
+		if match != nil && !match(resource) {
+			return
+		}
+		event := ResourceEvent{Type: EventModified, Resource: resource}
+		switch {
+		case op == CacheOpDelete:
+			event.Type = EventDeleted
+			delete(known, resource.ID)
+		case !known[resource.ID]:
+			event.Type = EventAdded
+			known[resource.ID] = true
+		}
+		// Runs under the cache lock, so it must never block
+		for {
+			select {
+			case events <- event:
+				return
+			default:
+			}
+			select {
+			case <-events:
+			default:
+			}
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+		// Once Unsubscribe returns the handler can't run again, so closing is safe
+		w.cache.Unsubscribe(subscription)
+		close(events)
+	}()
+	return events
+}
+
 // StreamPodLogs delegates to the client's StreamPodLogs method
 func (w *Watcher) StreamPodLogs(ctx context.Context, namespace, podName, containerName string, opts LogOptions, broadcast chan<- LogMessage) error {
 	return w.client.StreamPodLogs(ctx, namespace, podName, containerName, opts, broadcast)
@@ -0,0 +1,381 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/user/k8v/internal/types"
+)
+
+// eventRecorder is an EventHandler collecting the events it receives
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []ResourceEvent
+}
+
+func (r *eventRecorder) handle(ctx context.Context, event ResourceEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// received returns the "TYPE id" of each event received so far, in order
+func (r *eventRecorder) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	received := make([]string, len(r.events))
+	for i, event := range r.events {
+		received[i] = string(event.Type) + " " + event.Resource.ID
+	}
+	return received
+}
+
+// newTestWatcher returns a watcher over a fake clientset holding objects, and the
+// recorder its events go to. Informers aren't started, so tests call handlers directly.
+func newTestWatcher(t *testing.T, options WatcherOptions, objects ...runtime.Object) (*Watcher, *eventRecorder) {
+	t.Helper()
+	cache := NewResourceCache()
+	t.Cleanup(cache.Close)
+	recorder := &eventRecorder{}
+	client := NewClientWithFake(fake.NewSimpleClientset(objects...))
+	return NewWatcherWithOptions(client, cache, recorder.handle, options), recorder
+}
+
+func testPod(namespace, name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": name}},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "nginx:1.27"}}},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestWatcherExcludedNamespaceEvents(t *testing.T) {
+	t.Parallel()
+
+	w, recorder := newTestWatcher(t, WatcherOptions{ExcludeNamespaces: []string{"kube-system"}})
+
+	w.handlePodAdd(testPod("kube-system", "coredns"))
+	w.handlePodAdd(testPod("default", "web"))
+	w.handlePodDelete(testPod("kube-system", "coredns"))
+
+	got := recorder.received()
+	want := []string{"ADDED Pod:default:web"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if w.cache.Contains("Pod:kube-system:coredns") {
+		t.Error("excluded Pod is still cached after its delete")
+	}
+}
+
+func TestWatcherPruneRestoredSkipsExcludedNamespaces(t *testing.T) {
+	t.Parallel()
+
+	w, recorder := newTestWatcher(t, WatcherOptions{ExcludeNamespaces: []string{"kube-system"}})
+	path := filepath.Join(t.TempDir(), "cache.json.gz")
+	source := NewResourceCache()
+	defer source.Close()
+	for _, pod := range []*v1.Pod{testPod("kube-system", "coredns"), testPod("default", "web")} {
+		source.Set(TransformPod(pod, source))
+	}
+	if err := source.Dump(path); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	if err := w.cache.Restore(path); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	w.PruneRestored()
+
+	got := recorder.received()
+	if len(got) != 1 || got[0] != "DELETED Pod:default:web" {
+		t.Errorf("events = %v, want [DELETED Pod:default:web]", got)
+	}
+	if w.cache.Count() != 0 {
+		t.Errorf("cache holds %d resources after pruning, want 0", w.cache.Count())
+	}
+}
+
+func TestWatcherStaleUpdateNotEmitted(t *testing.T) {
+	t.Parallel()
+
+	w, recorder := newTestWatcher(t, WatcherOptions{})
+	current := testPod("default", "web")
+	current.ResourceVersion = "10"
+	stale := current.DeepCopy()
+	stale.ResourceVersion = "9"
+
+	w.handlePodAdd(current)
+	w.handlePodUpdate(stale, stale)
+
+	got := recorder.received()
+	if len(got) != 1 || got[0] != "ADDED Pod:default:web" {
+		t.Errorf("events = %v, want only the ADDED event", got)
+	}
+	if cached, _ := w.cache.Get("Pod:default:web"); cached.ResourceVersion != "10" {
+		t.Errorf("cached ResourceVersion = %q, want 10", cached.ResourceVersion)
+	}
+}
+
+func TestWatcherFlushesInitialLoadBeforeSync(t *testing.T) {
+	t.Parallel()
+
+	w, recorder := newTestWatcher(t, WatcherOptions{WatchResourceTypes: []string{"Pod"}})
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// The informers never sync here, as when one of them may not list its resource type
+	w.handlePodAdd(testPod("default", "web"))
+	if w.cache.Contains("Pod:default:web") {
+		t.Fatal("Pod was stored before its batch was flushed")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !w.cache.Contains("Pod:default:web") {
+		if time.Now().After(deadline) {
+			t.Fatal("buffered Pod wasn't stored without FinishInitialLoad")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := recorder.received(); len(got) != 1 || got[0] != "ADDED Pod:default:web" {
+		t.Errorf("events = %v, want [ADDED Pod:default:web]", got)
+	}
+}
+
+func TestHandlePodDeleteTombstone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		deleted  func(pod *v1.Pod) interface{}
+		wantGone bool
+	}{
+		{"pod", func(pod *v1.Pod) interface{} { return pod }, true},
+		{"tombstone", func(pod *v1.Pod) interface{} {
+			return cache.DeletedFinalStateUnknown{Key: "default/web", Obj: pod}
+		}, true},
+		{"tombstone of another type", func(pod *v1.Pod) interface{} {
+			return cache.DeletedFinalStateUnknown{Key: "default/web", Obj: &v1.Service{}}
+		}, false},
+		{"empty tombstone", func(pod *v1.Pod) interface{} {
+			return cache.DeletedFinalStateUnknown{Key: "default/web"}
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, recorder := newTestWatcher(t, WatcherOptions{})
+			pod := testPod("default", "web")
+			w.handlePodAdd(pod)
+			w.handlePodDelete(tt.deleted(pod))
+
+			want := []string{"ADDED Pod:default:web"}
+			if tt.wantGone {
+				want = append(want, "DELETED Pod:default:web")
+			}
+			if got := recorder.received(); !slices.Equal(got, want) {
+				t.Errorf("events = %v, want %v", got, want)
+			}
+			if gone := !w.cache.Contains("Pod:default:web"); gone != tt.wantGone {
+				t.Errorf("Pod removed from the cache = %v, want %v", gone, tt.wantGone)
+			}
+		})
+	}
+}
+
+func TestWatcherEventFiltersPassDeletes(t *testing.T) {
+	t.Parallel()
+
+	w, recorder := newTestWatcher(t, WatcherOptions{})
+	w.AddEventFilter(IgnoreSystemNamespacesFilter())
+
+	pod := testPod("kube-system", "coredns")
+	w.handlePodAdd(pod)
+	updated := pod.DeepCopy()
+	updated.Status.Phase = v1.PodFailed
+	w.handlePodUpdate(pod, updated)
+	w.handlePodDelete(updated)
+
+	// Clients saw the Pod in their snapshot, so they must see it go
+	got := recorder.received()
+	if len(got) != 1 || got[0] != "DELETED Pod:kube-system:coredns" {
+		t.Errorf("events = %v, want only [DELETED Pod:kube-system:coredns]", got)
+	}
+}
+
+// waitForEvents fails unless the recorder receives want, in order, within a few seconds
+func waitForEvents(t *testing.T, recorder *eventRecorder, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got := recorder.received()
+		if slices.Equal(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("events = %v, want %v", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatcherFollowsFakeClientset(t *testing.T) {
+	t.Parallel()
+
+	clientset := fake.NewSimpleClientset(testPod("default", "existing"))
+	cache := NewResourceCache()
+	t.Cleanup(cache.Close)
+	recorder := &eventRecorder{}
+	client := NewClientWithFake(clientset)
+	client.SetLogger(log.New(io.Discard, "", 0))
+	w := NewWatcherWithOptions(client, cache, recorder.handle, WatcherOptions{WatchResourceTypes: []string{"Pod"}})
+
+	// Objects created before the informer's watch starts are only seen by its list
+	watching := make(chan struct{})
+	var once sync.Once
+	clientset.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		once.Do(func() { close(watching) })
+		return false, nil, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	synced, err := w.StartAsync(ctx)
+	if err != nil {
+		t.Fatalf("StartAsync() error = %v", err)
+	}
+	if err := <-synced; err != nil {
+		t.Fatalf("sync error = %v", err)
+	}
+	<-watching
+	waitForEvents(t, recorder, "ADDED Pod:default:existing")
+
+	pods := clientset.CoreV1().Pods("default")
+	if _, err := pods.Create(ctx, testPod("default", "web"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	updated := testPod("default", "web")
+	updated.Status.Phase = v1.PodFailed
+	if _, err := pods.UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	if err := pods.Delete(ctx, "existing", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	waitForEvents(t, recorder,
+		"ADDED Pod:default:existing",
+		"ADDED Pod:default:web",
+		"MODIFIED Pod:default:web",
+		"DELETED Pod:default:existing",
+	)
+	if cached, ok := w.GetResource("Pod:default:web"); !ok || cached.Health != types.HealthError {
+		t.Errorf("cached Pod = %+v, want the failed update", cached)
+	}
+	if w.cache.Contains("Pod:default:existing") {
+		t.Error("deleted Pod is still cached")
+	}
+}
+
+// newSnapshotWatcher returns a watcher excluding kube-system whose cache holds two Pods
+// in each of namespace-a, namespace-b and kube-system, and two Nodes
+func newSnapshotWatcher(t *testing.T) *Watcher {
+	t.Helper()
+	w, _ := newTestWatcher(t, WatcherOptions{ExcludeNamespaces: []string{"kube-system"}})
+	for _, namespace := range []string{"namespace-a", "namespace-b", "kube-system"} {
+		w.cache.Set(testResource("Pod", namespace, "web", "1"))
+		w.cache.Set(testResource("Pod", namespace, "worker", "1"))
+	}
+	w.cache.Set(testResource("Node", "", "node-1", "1"))
+	w.cache.Set(testResource("Node", "", "node-2", "1"))
+	return w
+}
+
+// snapshotIDs returns the sorted IDs of the resources in a snapshot
+func snapshotIDs(events []ResourceEvent) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.Resource.ID
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestGetSnapshotFiltered(t *testing.T) {
+	t.Parallel()
+
+	w := newSnapshotWatcher(t)
+	nodes := []string{"Node::node-1", "Node::node-2"}
+	all := append([]string{
+		"Pod:namespace-a:web", "Pod:namespace-a:worker", "Pod:namespace-b:web", "Pod:namespace-b:worker",
+	}, nodes...)
+	slices.Sort(all)
+
+	tests := []struct {
+		namespace string
+		want      []string
+	}{
+		{"namespace-a", []string{"Node::node-1", "Node::node-2", "Pod:namespace-a:web", "Pod:namespace-a:worker"}},
+		{"all", all},
+		{"", all},
+		{"kube-system", nodes}, // Excluded, but cluster-scoped resources still pass
+		{"namespace-c", nodes}, // Unknown namespaces still see cluster-scoped resources
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			t.Parallel()
+			events := w.GetSnapshotFiltered(tt.namespace)
+			if got := snapshotIDs(events); !slices.Equal(got, tt.want) {
+				t.Errorf("GetSnapshotFiltered(%q) = %v, want %v", tt.namespace, got, tt.want)
+			}
+			for _, event := range events {
+				if event.Type != EventAdded {
+					t.Errorf("event for %s has type %s, want %s", event.Resource.ID, event.Type, EventAdded)
+				}
+			}
+		})
+	}
+}
+
+func TestGetSnapshotFilteredByType(t *testing.T) {
+	t.Parallel()
+
+	w := newSnapshotWatcher(t)
+	tests := []struct {
+		namespace    string
+		resourceType string
+		want         []string
+	}{
+		{"namespace-a", "Pod", []string{"Pod:namespace-a:web", "Pod:namespace-a:worker"}},
+		{"namespace-a", "Node", []string{"Node::node-1", "Node::node-2"}},
+		{"namespace-a", "Service", []string{}},
+		{"all", "Pod", []string{"Pod:namespace-a:web", "Pod:namespace-a:worker", "Pod:namespace-b:web", "Pod:namespace-b:worker"}},
+		{"kube-system", "Pod", []string{}},
+		{"kube-system", "Node", []string{"Node::node-1", "Node::node-2"}},
+		{"namespace-b", "all", []string{"Node::node-1", "Node::node-2", "Pod:namespace-b:web", "Pod:namespace-b:worker"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace+"/"+tt.resourceType, func(t *testing.T) {
+			t.Parallel()
+			got := snapshotIDs(w.GetSnapshotFilteredByType(tt.namespace, tt.resourceType))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetSnapshotFilteredByType(%q, %q) = %v, want %v", tt.namespace, tt.resourceType, got, tt.want)
+			}
+		})
+	}
+}
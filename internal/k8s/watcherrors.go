@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/user/k8v/internal/metrics"
+)
+
+// watchErrors counts failed informer list/watch calls, by resource type
+var watchErrors = metrics.NewCounterVec("k8v_watch_errors_total", "Informer list/watch calls that failed, by resource type", "resource")
+
+// Backoff after a watch error before the watch is considered recovered, doubling with
+// each consecutive failure
+const (
+	watchErrorInitialBackoff = time.Second
+	watchErrorMaxBackoff     = 60 * time.Second
+)
+
+// WatchErrorHandler is notified when an informer's list/watch fails, and called again
+// with a nil error once every failing watch has recovered
+// Informers reconnect on their own; this only lets the application report it.
+type WatchErrorHandler func(resource string, err error)
+
+// SetWatchErrorHandler sets the handler notified of watch failures
+// Must be called before Start
+func (w *Watcher) SetWatchErrorHandler(handler WatchErrorHandler) {
+	w.watchErrorHandler = handler
+}
+
+// watchErrorBackoff returns the backoff after the given number of consecutive failures
+func watchErrorBackoff(failures int) time.Duration {
+	backoff := watchErrorInitialBackoff
+	for i := 1; i < failures && backoff < watchErrorMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, watchErrorMaxBackoff)
+}
+
+// onWatchError returns the informer watch error handler of a resource type
+// Expired resource versions and closed watches are part of normal operation and ignored.
+func (w *Watcher) onWatchError(resourceType string) cache.WatchErrorHandler {
+	return func(r *cache.Reflector, err error) {
+		cache.DefaultWatchErrorHandler(r, err)
+		if errors.Is(err, io.EOF) || apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			return
+		}
+
+		watchErrors.Inc(resourceType)
+		w.watchErrMu.Lock()
+		w.watchFailures[resourceType]++
+		failures := w.watchFailures[resourceType]
+		w.watchErrMu.Unlock()
+
+		backoff := watchErrorBackoff(failures)
+		log.Printf("Warning: %s watch failed (%d in a row), reconnecting: %v", resourceType, failures, err)
+		if w.watchErrorHandler != nil {
+			w.watchErrorHandler(resourceType, err)
+		}
+		go w.awaitWatchRecovery(resourceType, failures, backoff)
+	}
+}
+
+// awaitWatchRecovery considers a watch recovered when no further failure occurred within
+// its backoff, notifying the handler once no watch is failing anymore
+func (w *Watcher) awaitWatchRecovery(resourceType string, failures int, backoff time.Duration) {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-w.client.stopCh:
+		return
+	case <-timer.C:
+	}
+
+	w.watchErrMu.Lock()
+	recovered := w.watchFailures[resourceType] == failures
+	if recovered {
+		delete(w.watchFailures, resourceType)
+	}
+	allRecovered := recovered && len(w.watchFailures) == 0
+	w.watchErrMu.Unlock()
+
+	if recovered {
+		log.Printf("%s watch recovered", resourceType)
+	}
+	if allRecovered && w.watchErrorHandler != nil {
+		w.watchErrorHandler(resourceType, nil)
+	}
+}
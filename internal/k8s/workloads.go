@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// UnmanagedPod is a Pod created directly rather than by a controller
+type UnmanagedPod struct {
+	Name       string    `json:"name"`
+	Namespace  string    `json:"namespace"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Containers []string  `json:"containers"`
+}
+
+// GetUnmanagedPods returns Pods in a namespace ("" for all) without ownerReferences.
+// Nothing recreates these Pods if they are evicted or their Node fails.
+func (c *Client) GetUnmanagedPods(namespace string) ([]UnmanagedPod, error) {
+	pods, err := c.InformerFactory.Core().V1().Pods().Lister().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	result := []UnmanagedPod{}
+	for _, pod := range pods {
+		if len(pod.OwnerReferences) > 0 {
+			continue
+		}
+		containers := make([]string, 0, len(pod.Spec.Containers))
+		for _, container := range pod.Spec.Containers {
+			containers = append(containers, container.Name)
+		}
+		result = append(result, UnmanagedPod{
+			Name:       pod.Name,
+			Namespace:  pod.Namespace,
+			CreatedAt:  pod.CreationTimestamp.Time,
+			Containers: containers,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// registry holds every metric created with NewCounter/NewCounterVec
+var registry = struct {
+	mu      sync.Mutex
+	metrics []metric
+}{}
+
+// metric is anything that can render itself in the Prometheus text exposition format
+type metric interface {
+	name() string
+	write(b *strings.Builder)
+}
+
+func register(m metric) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.metrics = append(registry.metrics, m)
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	metricName string
+	help       string
+	value      atomic.Uint64
+}
+
+// NewCounter creates and registers a counter
+func NewCounter(name, help string) *Counter {
+	c := &Counter{metricName: name, help: help}
+	register(c)
+	return c
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Value returns the current counter value
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+func (c *Counter) name() string { return c.metricName }
+
+func (c *Counter) write(b *strings.Builder) {
+	writeHeader(b, c.metricName, c.help)
+	fmt.Fprintf(b, "%s %d\n", c.metricName, c.Value())
+}
+
+// CounterVec is a set of counters partitioned by the value of a single label
+type CounterVec struct {
+	metricName string
+	help       string
+	label      string
+
+	mu     sync.Mutex
+	values map[string]*atomic.Uint64 // label value -> count
+}
+
+// NewCounterVec creates and registers a counter with one label (e.g. "resource")
+func NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{
+		metricName: name,
+		help:       help,
+		label:      label,
+		values:     make(map[string]*atomic.Uint64),
+	}
+	register(c)
+	return c
+}
+
+// Inc increments the counter for the given label value by one
+func (c *CounterVec) Inc(labelValue string) {
+	c.mu.Lock()
+	v, ok := c.values[labelValue]
+	if !ok {
+		v = &atomic.Uint64{}
+		c.values[labelValue] = v
+	}
+	c.mu.Unlock()
+	v.Add(1)
+}
+
+func (c *CounterVec) name() string { return c.metricName }
+
+func (c *CounterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	labelValues := make([]string, 0, len(c.values))
+	for labelValue := range c.values {
+		labelValues = append(labelValues, labelValue)
+	}
+	sort.Strings(labelValues)
+
+	writeHeader(b, c.metricName, c.help)
+	for _, labelValue := range labelValues {
+		fmt.Fprintf(b, "%s{%s=%q} %d\n", c.metricName, c.label, labelValue, c.values[labelValue].Load())
+	}
+}
+
+func writeHeader(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", name)
+}
+
+// Handler serves all registered metrics in the Prometheus text exposition format
+func Handler(w http.ResponseWriter, r *http.Request) {
+	registry.mu.Lock()
+	metrics := append([]metric(nil), registry.metrics...)
+	registry.mu.Unlock()
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name() < metrics[j].name() })
+
+	var b strings.Builder
+	for _, m := range metrics {
+		m.write(&b)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
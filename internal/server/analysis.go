@@ -0,0 +1,410 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+// handleServiceAccountPermissions returns the RBAC bindings and rules that apply to a ServiceAccount
+func (s *Server) handleServiceAccountPermissions(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	name := r.URL.Query().Get("name")
+	if namespace == "" || name == "" {
+		http.Error(w, "missing required parameters: namespace, name", http.StatusBadRequest)
+		return
+	}
+	if !namespaceAllowed(r, namespace) {
+		http.Error(w, "namespace not allowed", http.StatusForbidden)
+		return
+	}
+
+	permissions, err := s.watcherProvider.GetWatcher().GetClient().GetServiceAccountPermissions(r.Context(), namespace, name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("failed to get service account permissions: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(permissions)
+}
+
+// handleClusterAdminBindings returns all bindings that grant the cluster-admin ClusterRole
+func (s *Server) handleClusterAdminBindings(w http.ResponseWriter, r *http.Request) {
+	if _, ok := scopedNamespace(r); ok {
+		http.Error(w, "cluster-wide RBAC audit is not allowed for namespace-scoped tokens", http.StatusForbidden)
+		return
+	}
+
+	bindings, err := s.watcherProvider.GetWatcher().GetClient().GetClusterAdminBindings(r.Context())
+	if err != nil {
+		s.logger.Printf("[RBAC] Failed to list cluster-admin bindings: %v", err)
+		http.Error(w, fmt.Sprintf("failed to list cluster-admin bindings: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bindings": bindings,
+		"count":    len(bindings),
+	})
+}
+
+// handleWildcardRBAC returns Roles and ClusterRoles that grant wildcard verbs, resources or
+// API groups. Namespace-scoped tokens only see ClusterRoles bound in their namespace.
+func (s *Server) handleWildcardRBAC(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+
+	client := s.watcherProvider.GetWatcher().GetClient()
+	var roles []k8s.WildcardRole
+	var err error
+	if scoped, ok := scopedNamespace(r); ok {
+		roles, err = client.GetNamespaceWildcardRoles(r.Context(), scoped)
+	} else {
+		roles, err = client.GetWildcardRoles(r.Context(), namespace)
+	}
+	if err != nil {
+		s.logger.Printf("[RBAC] Failed to list wildcard roles: %v", err)
+		http.Error(w, fmt.Sprintf("failed to list wildcard roles: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"roles": roles,
+		"count": len(roles),
+	})
+}
+
+// handlePodSecurityStandards returns Pods that violate the baseline or restricted Pod Security Standards
+func (s *Server) handlePodSecurityStandards(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	violations, err := s.watcherProvider.GetWatcher().GetClient().GetPodSecurityViolations(namespace)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to check pod security standards: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pods":  violations,
+		"count": len(violations),
+	})
+}
+
+// handleNetworkPolicyCoverage returns Pods not selected by any NetworkPolicy in their namespace
+func (s *Server) handleNetworkPolicyCoverage(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	pods, err := s.watcherProvider.GetWatcher().GetClient().GetUncoveredPods(r.Context(), namespace)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to check network policy coverage: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pods":  pods,
+		"count": len(pods),
+	})
+}
+
+// handleMultiZoneDistribution returns a Deployment's Pod count per availability zone
+func (s *Server) handleMultiZoneDistribution(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	deployment := r.URL.Query().Get("deployment")
+	if namespace == "" || deployment == "" {
+		http.Error(w, "missing required parameters: namespace, deployment", http.StatusBadRequest)
+		return
+	}
+	if !namespaceAllowed(r, namespace) {
+		http.Error(w, "namespace not allowed", http.StatusForbidden)
+		return
+	}
+
+	distribution, err := s.watcherProvider.GetWatcher().GetClient().GetZoneDistribution(namespace, deployment)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("failed to get zone distribution: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(distribution)
+}
+
+// handleServiceMeshSidecar returns Pods missing their Istio or Linkerd sidecar
+func (s *Server) handleServiceMeshSidecar(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	pods, err := s.watcherProvider.GetWatcher().GetClient().GetMissingSidecars(r.Context(), namespace)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("failed to check service mesh sidecars: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pods":  pods,
+		"count": len(pods),
+	})
+}
+
+// handlePodLabelsCompliance returns Pods missing any of the required labels
+// required-labels defaults to the labels configured with -required-labels
+func (s *Server) handlePodLabelsCompliance(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	watcher := s.watcherProvider.GetWatcher()
+	required := k8s.ParseList(r.URL.Query().Get("required-labels"))
+	if len(required) == 0 {
+		required = watcher.GetRequiredLabels()
+	}
+	if len(required) == 0 {
+		http.Error(w, "missing required parameter: required-labels", http.StatusBadRequest)
+		return
+	}
+
+	pods := watcher.GetLabelComplianceViolations(namespace, required)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requiredLabels": required,
+		"pods":           pods,
+		"count":          len(pods),
+	})
+}
+
+// handleSpotInstancePods returns Pods running on spot or preemptible Nodes
+func (s *Server) handleSpotInstancePods(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	pods, err := s.watcherProvider.GetWatcher().GetClient().GetSpotInstancePods(namespace)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list spot instance pods: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pods":  pods,
+		"count": len(pods),
+	})
+}
+
+// handleNodePoolDistribution returns Pods grouped by the node pool they run on
+func (s *Server) handleNodePoolDistribution(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	distribution, err := s.watcherProvider.GetWatcher().GetClient().GetNodePoolDistribution(namespace)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to get node pool distribution: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(distribution)
+}
+
+// handleStaleSecrets returns Secrets not updated within olderThan (default 90d)
+func (s *Server) handleStaleSecrets(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	olderThanParam := r.URL.Query().Get("olderThan")
+	if olderThanParam == "" {
+		olderThanParam = "90d"
+	}
+	olderThan, err := k8s.ParseDurationWithDays(olderThanParam)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid olderThan: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	secrets, err := s.watcherProvider.GetWatcher().GetClient().GetStaleSecrets(namespace, olderThan)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list stale secrets: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"olderThan": olderThanParam,
+		"secrets":   secrets,
+		"count":     len(secrets),
+	})
+}
+
+// handlePodsPerNode returns Pod counts and requested capacity per Node, busiest first
+func (s *Server) handlePodsPerNode(w http.ResponseWriter, r *http.Request) {
+	if _, ok := scopedNamespace(r); ok {
+		http.Error(w, "node pod density is not allowed for namespace-scoped tokens", http.StatusForbidden)
+		return
+	}
+
+	nodes, err := s.watcherProvider.GetWatcher().GetClient().GetPodsPerNode(r.URL.Query().Get("node"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("failed to get pods per node: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"nodes": nodes,
+		"count": len(nodes),
+	})
+}
+
+// handleUnmanagedPods returns Pods not owned by any controller
+func (s *Server) handleUnmanagedPods(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	pods, err := s.watcherProvider.GetWatcher().GetClient().GetUnmanagedPods(namespace)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list unmanaged pods: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pods":  pods,
+		"count": len(pods),
+	})
+}
+
+// handleExpiringCertificates returns cert-manager Certificates expiring within a window
+// (default 30d)
+func (s *Server) handleExpiringCertificates(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	withinParam := r.URL.Query().Get("within")
+	if withinParam == "" {
+		withinParam = "30d"
+	}
+	within, err := k8s.ParseDurationWithDays(withinParam)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid within: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	certificates := s.watcherProvider.GetWatcher().GetExpiringCertificates(namespace, within)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"within":       withinParam,
+		"certificates": certificates,
+		"count":        len(certificates),
+	})
+}
+
+// handleQuotaPressure returns ResourceQuota resources used at or above a threshold
+// percentage of their hard limit (default 75, requires -watch-resource-quotas)
+func (s *Server) handleQuotaPressure(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "all" {
+		namespace = ""
+	}
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
+	threshold := k8s.DefaultQuotaPressureThreshold
+	if param := r.URL.Query().Get("threshold"); param != "" {
+		value, err := strconv.ParseFloat(param, 64)
+		if err != nil || value < 0 {
+			http.Error(w, "invalid threshold: must be a percentage", http.StatusBadRequest)
+			return
+		}
+		threshold = value
+	}
+
+	pressures, err := s.watcherProvider.GetWatcher().GetQuotaPressure(namespace, threshold)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"threshold": threshold,
+		"quotas":    pressures,
+		"count":     len(pressures),
+	})
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// SetAuthToken configures the bearer token required for admin endpoints
+// An empty token disables authentication
+func (s *Server) SetAuthToken(token string) {
+	s.authToken = token
+}
+
+// SetAdminKey configures the X-Admin-Key value required for admin-key endpoints, such as
+// the exec broadcast. Unlike the auth token, an empty key disables those endpoints.
+func (s *Server) SetAdminKey(key string) {
+	s.adminKey = key
+}
+
+// requireAdminKey returns a middleware that rejects requests without an X-Admin-Key
+// header matching the admin key, and every request when no key is configured
+func (s *Server) requireAdminKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminKey == "" {
+			http.Error(w, "forbidden: -admin-key is not set", http.StatusForbidden)
+			return
+		}
+
+		key := r.Header.Get("X-Admin-Key")
+		if subtle.ConstantTimeCompare([]byte(key), []byte(s.adminKey)) != 1 {
+			s.logger.Printf("[Auth] Rejected request without a valid admin key to %s from %s", r.URL.Path, r.RemoteAddr)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// requireAuth returns a middleware that rejects requests without a valid
+// "Authorization: Bearer <token>" header when an auth token is configured
+func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.authToken == "" {
+			next(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.authToken)) != 1 {
+			s.logger.Printf("[Auth] Rejected unauthenticated request to %s from %s", r.URL.Path, r.RemoteAddr)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MutationResponse is the JSON response returned by mutating API endpoints
+type MutationResponse struct {
+	DryRun           bool        `json:"dryRun"`
+	WouldHaveChanged string      `json:"wouldHaveChanged,omitempty"` // Human-readable description, set in dry-run mode
+	Result           interface{} `json:"result,omitempty"`           // Object returned by the Kubernetes API
+}
+
+// SetDryRun enables server-side dry-run for all mutating API endpoints
+// In dry-run mode the API server validates and admits requests without persisting them,
+// mirroring "kubectl --dry-run=server"
+func (s *Server) SetDryRun(enabled bool) {
+	s.dryRun = enabled
+}
+
+// SetReadOnly disables every mutating endpoint, including context switches and shells:
+// they answer 405 Method Not Allowed, and the exec WebSockets 403 before the upgrade
+// Must be called before Handler or Start.
+func (s *Server) SetReadOnly(enabled bool) {
+	s.readOnly = enabled
+}
+
+// errReadOnly is the error mutating endpoints return in read-only mode
+const errReadOnly = "k8v is running in read-only mode"
+
+// readOnlyHandler rejects the requests of a mutating route in read-only mode
+func readOnlyHandler(rt route) http.HandlerFunc {
+	status := http.StatusMethodNotAllowed
+	if rt.WebSocket {
+		status = http.StatusForbidden
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, errReadOnly, status)
+	}
+}
+
+// dryRunOptions returns the DryRun value to pass in Create/Update/Patch/Delete options
+func (s *Server) dryRunOptions() []string {
+	if s.dryRun {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
+
+// writeMutationResponse writes the result of a mutating operation. description
+// explains the change (e.g. "scale Deployment default/web from 2 to 5 replicas")
+// and is only included when running in dry-run mode.
+func (s *Server) writeMutationResponse(w http.ResponseWriter, description string, result interface{}) {
+	response := MutationResponse{
+		DryRun: s.dryRun,
+		Result: result,
+	}
+	if s.dryRun {
+		response.WouldHaveChanged = description
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -2,14 +2,18 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
+	"github.com/user/k8v/internal/audit"
 	"github.com/user/k8v/internal/k8s"
 )
 
@@ -20,10 +24,34 @@ type ExecClient struct {
 	done       chan struct{} // closed when client is shutting down
 	hub        *ExecHub
 	podKey     string // "namespace/pod/container"
+	sessionID  string // unique ID for audit records
 	logger     *Logger
 	cancelFunc context.CancelFunc
 	sizeQueue  *k8s.TerminalSizeQueue
-	stdinPipe  io.WriteCloser
+	stdinPipe  *countingWriter // counts input bytes for ActiveSessions
+	startedAt  time.Time
+	remoteAddr string
+	recorder   *AsciinemaRecorder // nil unless sessions are recorded
+	lastInput  atomic.Int64       // UnixNano of the last input message, or of the session start
+	bytesOut   atomic.Int64       // output bytes sent to the browser
+	output     *AuditRingBuffer   // tail of the session output
+	audit      *audit.AuditLogger // records pastes; nil disables it
+	actor      string             // client IP address for audit records
+	resource   audit.ResourceInfo // the exec'd Pod, for audit records
+	binary     bool               // ?protocol=binary: exchange binary frames instead of JSON
+}
+
+// DefaultExecIdleTTL is how long an exec session may go without input before it is evicted
+const DefaultExecIdleTTL = 30 * time.Minute
+
+// execIdleCheckInterval is how often the hub looks for idle sessions
+const execIdleCheckInterval = 60 * time.Second
+
+// ExecHubOptions configures optional exec hub behavior
+type ExecHubOptions struct {
+	// IdleTTL is how long a session may go without input before it is closed, e.g. when
+	// left open in a forgotten browser tab (default DefaultExecIdleTTL)
+	IdleTTL time.Duration
 }
 
 // ExecHub manages all active exec WebSocket connections
@@ -31,24 +59,41 @@ type ExecHub struct {
 	clients    map[*ExecClient]bool
 	register   chan *ExecClient
 	unregister chan *ExecClient
+	idleTTL    time.Duration
 	mu         sync.RWMutex
 	logger     *Logger
 }
 
 // NewExecHub creates a new ExecHub
 func NewExecHub(logger *Logger) *ExecHub {
+	return NewExecHubWithOptions(logger, ExecHubOptions{})
+}
+
+// NewExecHubWithOptions creates a new ExecHub with the given options
+func NewExecHubWithOptions(logger *Logger, options ExecHubOptions) *ExecHub {
+	idleTTL := options.IdleTTL
+	if idleTTL <= 0 {
+		idleTTL = DefaultExecIdleTTL
+	}
 	return &ExecHub{
 		clients:    make(map[*ExecClient]bool),
 		register:   make(chan *ExecClient),
 		unregister: make(chan *ExecClient),
+		idleTTL:    idleTTL,
 		logger:     logger,
 	}
 }
 
 // Run starts the exec hub's main loop
 func (h *ExecHub) Run() {
+	idleTicker := time.NewTicker(execIdleCheckInterval)
+	defer idleTicker.Stop()
+
 	for {
 		select {
+		case <-idleTicker.C:
+			h.evictIdleSessions()
+
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
@@ -78,6 +123,35 @@ func (h *ExecHub) Run() {
 	}
 }
 
+// evictIdleSessions closes the sessions that received no input for longer than idleTTL
+// Called from Run, so unregistering goes through a goroutine instead of blocking the loop
+func (h *ExecHub) evictIdleSessions() {
+	now := time.Now()
+	var idle []*ExecClient
+	h.mu.RLock()
+	for client := range h.clients {
+		if now.Sub(time.Unix(0, client.lastInput.Load())) > h.idleTTL {
+			idle = append(idle, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range idle {
+		h.logger.Printf("[ExecHub] Evicting idle session %s (session: %s, duration: %s, bytes in: %d, bytes out: %d)",
+			client.podKey, client.sessionID, now.Sub(client.startedAt).Round(time.Second),
+			client.stdinPipe.BytesWritten(), client.bytesOut.Load())
+		if client.cancelFunc != nil {
+			client.cancelFunc()
+		}
+		// Queued before send is closed, so writePump still delivers it
+		client.trySend(k8s.ExecMessage{
+			Type: k8s.ExecMessageClose,
+			Data: "session evicted: idle timeout",
+		})
+		go func(c *ExecClient) { h.unregister <- c }(client)
+	}
+}
+
 // DisconnectAll forcefully disconnects all exec clients
 func (h *ExecHub) DisconnectAll() {
 	h.mu.Lock()
@@ -108,12 +182,36 @@ func (s *Server) handleExecWebSocket(w http.ResponseWriter, r *http.Request) {
 	namespace := r.URL.Query().Get("namespace")
 	pod := r.URL.Query().Get("pod")
 	container := r.URL.Query().Get("container")
+	// Optional command override (repeat cmd for arguments); empty uses the shell fallback chain
+	execCommand := r.URL.Query()["cmd"]
 
 	if namespace == "" || pod == "" || container == "" {
 		http.Error(w, "missing required parameters: namespace, pod, container", http.StatusBadRequest)
 		return
 	}
 
+	// The audit record is written once the outcome is known: when a shell starts, or
+	// when the session is refused or fails before one does
+	record := audit.Record{
+		Operation: "exec.pod",
+		Actor:     audit.ActorFromRequest(r),
+		Resource:  audit.ResourceInfo{Type: "Pod", Namespace: namespace, Name: pod},
+		Command:   execCommand,
+	}
+	var recorded atomic.Bool
+	logAudit := func(result string) {
+		if recorded.CompareAndSwap(false, true) {
+			record.Result = result
+			s.audit.Log(record)
+		}
+	}
+
+	if !namespaceAllowed(r, namespace) {
+		logAudit(audit.ResultDenied)
+		http.Error(w, "namespace not allowed", http.StatusForbidden)
+		return
+	}
+
 	// Upgrade connection
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -122,7 +220,9 @@ func (s *Server) handleExecWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	podKey := fmt.Sprintf("%s/%s/%s", namespace, pod, container)
-	s.logger.Printf("[ExecStream] New connection: %s", podKey)
+	sessionID := uuid.NewString()
+	record.SessionID = sessionID
+	s.logger.Printf("[ExecStream] New connection: %s (session: %s)", podKey, sessionID)
 
 	// Create context for this exec session
 	ctx, cancel := context.WithCancel(context.Background())
@@ -140,17 +240,53 @@ func (s *Server) handleExecWebSocket(w http.ResponseWriter, r *http.Request) {
 		done:       make(chan struct{}),
 		hub:        s.execHub,
 		podKey:     podKey,
+		sessionID:  sessionID,
 		logger:     s.logger,
 		cancelFunc: cancel,
 		sizeQueue:  sizeQueue,
-		stdinPipe:  stdinWriter,
+		stdinPipe:  newCountingWriter(stdinWriter),
+		startedAt:  time.Now(),
+		remoteAddr: r.RemoteAddr,
+		output:     NewAuditRingBuffer(auditRingBufferSize),
+		audit:      s.audit,
+		actor:      record.Actor,
+		resource:   record.Resource,
+		binary:     binaryExecProtocol(r),
 	}
+	client.lastInput.Store(client.startedAt.UnixNano())
+
+	// Create stdout writer that sends to WebSocket, recording it when enabled
+	var stdoutWriter io.Writer = &execOutputWriter{
+		client:     client,
+		outputType: k8s.ExecMessageOutput,
+	}
+	recorder, err := s.newSessionRecorder(stdoutWriter, sessionID)
+	if err != nil {
+		// Sessions must not run unrecorded when recording is required
+		s.logger.Printf("[ExecStream] %v", err)
+		logAudit(audit.ResultDenied)
+		writeExecMessage(conn, k8s.ExecMessage{Type: k8s.ExecMessageError, Data: err.Error()}, client.binary)
+		conn.Close()
+		cancel()
+		return
+	}
+	if recorder != nil {
+		client.recorder = recorder
+		stdoutWriter = recorder
+	}
+	// Keep the output tail for audit records; the buffer never fails, so it goes first
+	stdoutWriter = io.MultiWriter(client.output, stdoutWriter)
 
 	s.execHub.register <- client
 
 	// Detect shell and start exec session
 	go func() {
 		defer cancel() // Always cancel context when this goroutine exits
+		if recorder != nil {
+			defer recorder.Close()
+		}
+		// A session that ends before any shell started never ran a command
+		defer logAudit(audit.ResultDenied)
 
 		watcher := s.watcherProvider.GetWatcher()
 		if watcher == nil {
@@ -163,41 +299,28 @@ func (s *Server) handleExecWebSocket(w http.ResponseWriter, r *http.Request) {
 
 		k8sClient := watcher.GetClient()
 
-		// Detect available shell
-		shell, err := k8sClient.DetectShell(ctx, namespace, pod, container)
-		if err != nil {
-			client.safeSend(k8s.ExecMessage{
-				Type: k8s.ExecMessageError,
-				Data: fmt.Sprintf("shell detection failed: %v", err),
-			})
-			return
-		}
-
-		// Notify client that we're connected
-		if !client.safeSend(k8s.ExecMessage{
-			Type: k8s.ExecMessageConnected,
-			Data: shell[0],
-		}) {
-			return // Client disconnected
-		}
-
-		// Create stdout writer that sends to WebSocket
-		stdoutWriter := &execOutputWriter{
-			client:     client,
-			outputType: k8s.ExecMessageOutput,
-		}
-
-		// Start exec session
-		err = k8sClient.ExecPodShell(
+		// Start exec session, with the first shell that runs unless ?cmd overrides it
+		err := k8sClient.ExecPodShell(
 			ctx,
 			namespace,
 			pod,
 			container,
-			shell,
+			execCommand,
 			stdinReader,
 			stdoutWriter,
 			stdoutWriter, // stderr goes to same output
 			sizeQueue,
+			func(command []string, protocol string) {
+				s.logger.Printf("[ExecStream] Session %s connected over %s", sessionID, protocol)
+				logAudit(audit.ResultAllowed)
+				// Notify client that we're connected
+				client.safeSend(k8s.ExecMessage{
+					Type:      k8s.ExecMessageConnected,
+					Data:      strings.Join(command, " "),
+					SessionID: sessionID,
+					Protocol:  protocol,
+				})
+			},
 		)
 
 		if err != nil {
@@ -241,6 +364,7 @@ func (w *execOutputWriter) Write(p []byte) (n int, err error) {
 		Type: w.outputType,
 		Data: string(p),
 	}:
+		w.client.bytesOut.Add(int64(len(p)))
 		return len(p), nil
 	default:
 		// Channel full, drop message
@@ -248,6 +372,11 @@ func (w *execOutputWriter) Write(p []byte) (n int, err error) {
 	}
 }
 
+// GetOutputBuffer returns the last output bytes of the session
+func (c *ExecClient) GetOutputBuffer() []byte {
+	return c.output.Bytes()
+}
+
 // safeSend sends a message to the client, returns false if client is shutting down
 func (c *ExecClient) safeSend(msg k8s.ExecMessage) (sent bool) {
 	defer func() {
@@ -265,51 +394,126 @@ func (c *ExecClient) safeSend(msg k8s.ExecMessage) (sent bool) {
 	}
 }
 
-// readPump pumps messages from the WebSocket connection
+// trySend sends a message unless the client is shutting down or its buffer is full
+// Unlike safeSend it never blocks, for callers such as the hub loop
+func (c *ExecClient) trySend(msg k8s.ExecMessage) {
+	defer func() {
+		recover() // Channel was closed, that's okay
+	}()
+
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+	}
+}
+
+// inputCoalesceWindow is how long readPump waits for more INPUT/PASTE messages before
+// writing the input received so far to stdin in a single call
+const inputCoalesceWindow = 5 * time.Millisecond
+
+// readPump reads messages from the WebSocket connection
+// Input arriving within inputCoalesceWindow of the first pending input is combined, so
+// characters of a paste split over many messages reach the shell in one write.
 func (c *ExecClient) readPump() {
 	defer func() {
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
 
+	messages := make(chan k8s.ExecMessage)
+	go c.readMessages(messages)
+
+	var pending []byte
+	var flush <-chan time.Time
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				c.writeInput(pending)
+				return
+			}
+
+			switch msg.Type {
+			case k8s.ExecMessageInput, k8s.ExecMessagePaste:
+				c.lastInput.Store(time.Now().UnixNano())
+				if msg.Type == k8s.ExecMessagePaste {
+					c.auditPaste(len(msg.Data))
+				}
+				if len(pending) == 0 {
+					flush = time.After(inputCoalesceWindow)
+				}
+				pending = append(pending, msg.Data...)
+
+			case k8s.ExecMessageResize:
+				// Input typed before the resize goes first
+				c.writeInput(pending)
+				pending, flush = nil, nil
+				// Send resize to terminal size queue
+				if c.sizeQueue != nil {
+					c.sizeQueue.Send(msg.Cols, msg.Rows)
+				}
+				if c.recorder != nil {
+					c.recorder.Resize(msg.Cols, msg.Rows)
+				}
+			}
+
+		case <-flush:
+			c.writeInput(pending)
+			pending, flush = nil, nil
+		}
+	}
+}
+
+// readMessages parses messages from the WebSocket connection into messages, closing it
+// once the connection fails or is closed
+func (c *ExecClient) readMessages(messages chan<- k8s.ExecMessage) {
+	defer close(messages)
 	for {
-		_, message, err := c.conn.ReadMessage()
+		messageType, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
 				c.logger.Printf("[ExecStream] Read error for %s: %v", c.podKey, err)
 			}
-			break
+			return
 		}
 
 		// Parse the message
-		var msg k8s.ExecMessage
-		if err := json.Unmarshal(message, &msg); err != nil {
+		msg, err := parseExecMessage(messageType, message)
+		if err != nil {
 			c.logger.Printf("[ExecStream] Invalid message for %s: %v", c.podKey, err)
 			continue
 		}
+		messages <- msg
+	}
+}
 
-		switch msg.Type {
-		case k8s.ExecMessageInput:
-			// Write to stdin pipe
-			if c.stdinPipe != nil {
-				c.stdinPipe.Write([]byte(msg.Data))
-			}
-
-		case k8s.ExecMessageResize:
-			// Send resize to terminal size queue
-			if c.sizeQueue != nil {
-				c.sizeQueue.Send(msg.Cols, msg.Rows)
-			}
-		}
+// writeInput writes input to the stdin pipe
+func (c *ExecClient) writeInput(input []byte) {
+	if len(input) > 0 && c.stdinPipe != nil {
+		c.stdinPipe.Write(input)
 	}
 }
 
+// auditPaste records a paste into the session; the pasted text itself is never logged,
+// as it may hold credentials
+func (c *ExecClient) auditPaste(size int) {
+	c.logger.Printf("[ExecStream] Paste of %d bytes into %s (session: %s)", size, c.podKey, c.sessionID)
+	c.audit.Log(audit.Record{
+		Operation: "exec.pod.paste",
+		Actor:     c.actor,
+		Resource:  c.resource,
+		Result:    audit.ResultAllowed,
+		SessionID: c.sessionID,
+	})
+}
+
 // writePump pumps messages to the WebSocket connection
 func (c *ExecClient) writePump() {
 	defer c.conn.Close()
 
 	for message := range c.send {
-		if err := c.conn.WriteJSON(message); err != nil {
+		if err := writeExecMessage(c.conn, message, c.binary); err != nil {
 			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
 				c.logger.Printf("[ExecStream] Write error for %s: %v", c.podKey, err)
 			}
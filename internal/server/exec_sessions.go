@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/user/k8v/internal/audit"
+	"github.com/user/k8v/internal/k8s"
+)
+
+// SessionInfo describes an open exec session
+type SessionInfo struct {
+	PodKey       string    `json:"podKey"` // "namespace/pod/container", or the node name for node shells
+	StartedAt    time.Time `json:"startedAt"`
+	RemoteAddr   string    `json:"remoteAddr"`
+	BytesWritten int64     `json:"bytesWritten"` // Bytes of input sent to the shell
+}
+
+// SessionLister is implemented by hubs that track exec sessions
+type SessionLister interface {
+	ActiveSessions() []SessionInfo
+}
+
+// countingWriter wraps a stdin pipe and counts the bytes written through it
+type countingWriter struct {
+	io.WriteCloser
+	written atomic.Int64
+}
+
+func newCountingWriter(w io.WriteCloser) *countingWriter {
+	return &countingWriter{WriteCloser: w}
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.written.Add(int64(n))
+	return n, err
+}
+
+// BytesWritten returns the number of bytes written so far
+func (w *countingWriter) BytesWritten() int64 {
+	return w.written.Load()
+}
+
+// ActiveSessions returns metadata for every open pod exec session
+func (h *ExecHub) ActiveSessions() []SessionInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sessions := make([]SessionInfo, 0, len(h.clients))
+	for client := range h.clients {
+		sessions = append(sessions, SessionInfo{
+			PodKey:       client.podKey,
+			StartedAt:    client.startedAt,
+			RemoteAddr:   client.remoteAddr,
+			BytesWritten: client.stdinPipe.BytesWritten(),
+		})
+	}
+	return sessions
+}
+
+// ActiveSessions returns metadata for every open node exec session
+func (h *NodeExecHub) ActiveSessions() []SessionInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sessions := make([]SessionInfo, 0, len(h.clients))
+	for client := range h.clients {
+		sessions = append(sessions, SessionInfo{
+			PodKey:       client.nodeName,
+			StartedAt:    client.startedAt,
+			RemoteAddr:   client.remoteAddr,
+			BytesWritten: client.stdinPipe.BytesWritten(),
+		})
+	}
+	return sessions
+}
+
+// BroadcastToAll sends a message to every open pod exec session and returns the number
+// of sessions it reached. The clients are collected under the read lock and sent to
+// after releasing it, so a session with a full buffer can't stall the hub loop.
+func (h *ExecHub) BroadcastToAll(msg k8s.ExecMessage) int {
+	h.mu.RLock()
+	clients := make([]*ExecClient, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	sent := 0
+	for _, client := range clients {
+		if client.safeSend(msg) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// BroadcastToAll sends a message to every open node exec session, like
+// ExecHub.BroadcastToAll
+func (h *NodeExecHub) BroadcastToAll(msg k8s.ExecMessage) int {
+	h.mu.RLock()
+	clients := make([]*NodeExecClient, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	sent := 0
+	for _, client := range clients {
+		if client.safeSend(msg) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// ExecBroadcastRequest is the body of POST /api/v1/exec/broadcast
+type ExecBroadcastRequest struct {
+	Message string `json:"message"`
+}
+
+// handleExecBroadcast shows a message in every open pod and node shell, as an ERROR
+// message since that's what terminals display
+func (s *Server) handleExecBroadcast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ExecBroadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		http.Error(w, "message is required", http.StatusBadRequest)
+		return
+	}
+
+	msg := k8s.ExecMessage{Type: k8s.ExecMessageError, Data: req.Message}
+	sent := s.execHub.BroadcastToAll(msg) + s.nodeExecHub.BroadcastToAll(msg)
+	s.audit.Log(audit.Record{
+		Operation: "exec.broadcast",
+		Actor:     audit.ActorFromRequest(r),
+		Result:    audit.ResultAllowed,
+	})
+	s.logger.Printf("[ExecHub] Broadcast message to %d sessions", sent)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"sessions": sent})
+}
+
+// handleExecSessions returns the open pod and node exec sessions
+func (s *Server) handleExecSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions":     s.execHub.ActiveSessions(),
+		"nodeSessions": s.nodeExecHub.ActiveSessions(),
+	})
+}
+
+// handleExecSessionCount returns the total number of open exec sessions as a bare integer
+func (s *Server) handleExecSessionCount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(len(s.execHub.ActiveSessions()) + len(s.nodeExecHub.ActiveSessions()))
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+// newTestExecSession serves one exec session running only readPump, with stdin going to
+// the returned channel. It returns the browser's end of the connection.
+func newTestExecSession(t *testing.T, hub *ExecHub) (*websocket.Conn, <-chan string) {
+	t.Helper()
+	stdin := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		stdinReader, stdinWriter := io.Pipe()
+		go func() {
+			buf := make([]byte, 1024)
+			for {
+				n, err := stdinReader.Read(buf)
+				if err != nil {
+					close(stdin)
+					return
+				}
+				stdin <- string(buf[:n])
+			}
+		}()
+		client := &ExecClient{
+			conn:      conn,
+			send:      make(chan k8s.ExecMessage, 10),
+			done:      make(chan struct{}),
+			hub:       hub,
+			podKey:    "default/web/app",
+			logger:    hub.logger,
+			stdinPipe: newCountingWriter(stdinWriter),
+			startedAt: time.Now(),
+		}
+		hub.register <- client
+		client.readPump()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, stdin
+}
+
+func TestExecClientReadPumpMalformedJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		messageType int
+		data        string
+	}{
+		{"garbage json", websocket.TextMessage, "[garbage json]"},
+		{"truncated json", websocket.TextMessage, `{"type":"INPUT","data":`},
+		{"empty text", websocket.TextMessage, ""},
+		{"empty binary", websocket.BinaryMessage, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hub := NewExecHub(newTestLogger())
+			go hub.Run()
+			conn, stdin := newTestExecSession(t, hub)
+
+			if err := conn.WriteMessage(tt.messageType, []byte(tt.data)); err != nil {
+				t.Fatalf("WriteMessage() error = %v", err)
+			}
+			if err := conn.WriteJSON(k8s.ExecMessage{Type: k8s.ExecMessageInput, Data: "ls\n"}); err != nil {
+				t.Fatalf("WriteJSON() error = %v", err)
+			}
+
+			// The bad message is dropped, and the next one still reaches the shell
+			select {
+			case got := <-stdin:
+				if got != "ls\n" {
+					t.Errorf("stdin received %q, want %q", got, "ls\n")
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("valid input after the bad message never reached stdin")
+			}
+			// The pipe hands the bytes over before the write is counted
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				sessions := hub.ActiveSessions()
+				if len(sessions) != 1 {
+					t.Fatalf("ActiveSessions() = %+v, want the session still open", sessions)
+				}
+				if sessions[0].BytesWritten == 3 {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("BytesWritten = %d, want 3", sessions[0].BytesWritten)
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			// Closing the browser's end ends the session and stdin with it
+			conn.Close()
+			select {
+			case _, ok := <-stdin:
+				if ok {
+					t.Error("stdin received more input after the connection closed")
+				}
+			case <-time.After(2 * time.Second):
+				t.Error("stdin wasn't closed after the connection closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+// execProtocolBinary is the ?protocol value selecting binary exec frames
+// A binary frame is one byte of message type followed by the message's Data as raw
+// bytes, saving the JSON envelope and escaping on every OUTPUT and INPUT write. RESIZE
+// carries cols and rows as big-endian uint16s, and CONNECTED its JSON message.
+const execProtocolBinary = "binary"
+
+// execFrameTypes maps exec message types to their binary frame type byte
+var execFrameTypes = map[string]byte{
+	k8s.ExecMessageOutput:    0x01,
+	k8s.ExecMessageError:     0x02,
+	k8s.ExecMessageClose:     0x03,
+	k8s.ExecMessageInput:     0x04,
+	k8s.ExecMessagePaste:     0x05,
+	k8s.ExecMessageResize:    0x06,
+	k8s.ExecMessageConnected: 0x07,
+	k8s.ExecMessageCreating:  0x08,
+	k8s.ExecMessageWaiting:   0x09,
+}
+
+// execFrameNames is the reverse of execFrameTypes
+var execFrameNames = func() map[byte]string {
+	names := make(map[byte]string, len(execFrameTypes))
+	for name, b := range execFrameTypes {
+		names[b] = name
+	}
+	return names
+}()
+
+// binaryExecProtocol reports whether an exec WebSocket request negotiated binary frames
+func binaryExecProtocol(r *http.Request) bool {
+	return r.URL.Query().Get("protocol") == execProtocolBinary
+}
+
+// encodeExecFrame encodes a message as a binary frame
+func encodeExecFrame(msg k8s.ExecMessage) ([]byte, error) {
+	frameType, ok := execFrameTypes[msg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown exec message type %q", msg.Type)
+	}
+	switch msg.Type {
+	case k8s.ExecMessageResize:
+		frame := []byte{frameType, 0, 0, 0, 0}
+		binary.BigEndian.PutUint16(frame[1:], msg.Cols)
+		binary.BigEndian.PutUint16(frame[3:], msg.Rows)
+		return frame, nil
+	case k8s.ExecMessageConnected:
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{frameType}, data...), nil
+	default:
+		return append([]byte{frameType}, msg.Data...), nil
+	}
+}
+
+// decodeExecFrame decodes a binary frame
+func decodeExecFrame(frame []byte) (k8s.ExecMessage, error) {
+	if len(frame) == 0 {
+		return k8s.ExecMessage{}, fmt.Errorf("empty exec frame")
+	}
+	msgType, ok := execFrameNames[frame[0]]
+	if !ok {
+		return k8s.ExecMessage{}, fmt.Errorf("unknown exec frame type 0x%02x", frame[0])
+	}
+	payload := frame[1:]
+	switch msgType {
+	case k8s.ExecMessageResize:
+		if len(payload) != 4 {
+			return k8s.ExecMessage{}, fmt.Errorf("invalid RESIZE frame length %d", len(payload))
+		}
+		return k8s.ExecMessage{
+			Type: msgType,
+			Cols: binary.BigEndian.Uint16(payload),
+			Rows: binary.BigEndian.Uint16(payload[2:]),
+		}, nil
+	case k8s.ExecMessageConnected:
+		var msg k8s.ExecMessage
+		err := json.Unmarshal(payload, &msg)
+		msg.Type = msgType
+		return msg, err
+	default:
+		return k8s.ExecMessage{Type: msgType, Data: string(payload)}, nil
+	}
+}
+
+// writeExecMessage writes a message as a binary frame or as JSON text
+func writeExecMessage(conn *websocket.Conn, msg k8s.ExecMessage, binaryFrames bool) error {
+	if !binaryFrames {
+		return conn.WriteJSON(msg)
+	}
+	frame, err := encodeExecFrame(msg)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.BinaryMessage, frame)
+}
+
+// parseExecMessage parses a message read from an exec WebSocket
+// Binary clients may still send JSON text messages, which keep their meaning.
+func parseExecMessage(messageType int, data []byte) (k8s.ExecMessage, error) {
+	if messageType == websocket.BinaryMessage {
+		return decodeExecFrame(data)
+	}
+	var msg k8s.ExecMessage
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+func TestExecFrameRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []k8s.ExecMessage{
+		{Type: k8s.ExecMessageOutput, Data: "\x1b[1;32mready\x1b[0m\r\n"},
+		{Type: k8s.ExecMessageInput, Data: "ls -la\r"},
+		{Type: k8s.ExecMessageClose},
+		{Type: k8s.ExecMessageResize, Cols: 120, Rows: 40},
+		{Type: k8s.ExecMessageConnected, SessionID: "abc", Protocol: k8s.ExecProtocolWebSocket},
+	}
+	for _, msg := range tests {
+		t.Run(msg.Type, func(t *testing.T) {
+			t.Parallel()
+			frame, err := encodeExecFrame(msg)
+			if err != nil {
+				t.Fatalf("encodeExecFrame() error = %v", err)
+			}
+			got, err := parseExecMessage(websocket.BinaryMessage, frame)
+			if err != nil {
+				t.Fatalf("parseExecMessage() error = %v", err)
+			}
+			if got != msg {
+				t.Errorf("round trip = %+v, want %+v", got, msg)
+			}
+		})
+	}
+}
+
+func TestExecFrameInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{"empty", nil},
+		{"unknown type", []byte{0xff, 'x'}},
+		{"short resize", []byte{0x06, 0, 80}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if msg, err := decodeExecFrame(tt.frame); err == nil {
+				t.Errorf("decodeExecFrame(%v) = %+v, want an error", tt.frame, msg)
+			}
+		})
+	}
+	if _, err := encodeExecFrame(k8s.ExecMessage{Type: "UNKNOWN"}); err == nil {
+		t.Error("encodeExecFrame() of an unknown type returned no error")
+	}
+}
+
+// BenchmarkExecOutput writes typical terminal output through a WebSocket in each
+// protocol. At 1000 writes/second, a session spends 1000 × ns/op of CPU per second and
+// sends 1000 × wire-B/write bytes.
+func BenchmarkExecOutput(b *testing.B) {
+	// A colored prompt line: JSON escapes each ESC as \u001b on top of the envelope
+	msg := k8s.ExecMessage{Type: k8s.ExecMessageOutput, Data: "\x1b[1;32muser@web\x1b[0m:\x1b[1;34m/app\x1b[0m$ ls\r\n"}
+
+	for _, binaryFrames := range []bool{false, true} {
+		name := "JSON"
+		wireBytes := 0
+		if binaryFrames {
+			name = "Binary"
+			frame, _ := encodeExecFrame(msg)
+			wireBytes = len(frame)
+		} else {
+			data, _ := json.Marshal(msg)
+			wireBytes = len(data) + 1 // WriteJSON ends the message with a newline
+		}
+
+		b.Run(name, func(b *testing.B) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				for {
+					if _, _, err := conn.NextReader(); err != nil {
+						return
+					}
+				}
+			}))
+			defer server.Close()
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			if err != nil {
+				b.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := writeExecMessage(conn, msg, binaryFrames); err != nil {
+					b.Fatalf("writeExecMessage() error = %v", err)
+				}
+			}
+			b.ReportMetric(float64(wireBytes), "wire-B/write")
+		})
+	}
+}
@@ -2,20 +2,32 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/user/k8v/internal/k8s"
+	"github.com/user/k8v/internal/templates"
+	"github.com/user/k8v/internal/types"
 )
 
+// templateRegistry holds the YAML starters served by handleTemplate
+var templateRegistry = templates.NewTemplateRegistry()
+
 // handleIndex serves the main HTML page
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
-	// Try to serve from embedded static files
-	staticFS, err := fs.Sub(staticFiles, "static")
-	if err != nil {
-		http.Error(w, "Failed to load static files", http.StatusInternalServerError)
-		return
+	// Serve from -static-dir when set, otherwise from the embedded static files
+	var staticFS fs.FS = os.DirFS(s.staticDir)
+	if s.staticDir == "" {
+		var err error
+		staticFS, err = fs.Sub(staticFiles, "static")
+		if err != nil {
+			http.Error(w, "Failed to load static files", http.StatusInternalServerError)
+			return
+		}
 	}
 
 	// Serve index.html for root path
@@ -30,27 +42,58 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 // handleHealth returns the health status of the server
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	counts := s.watcherProvider.GetWatcher().GetResourceCounts("")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":    "healthy",
-		"clients":   len(s.hub.clients),
-		"resources": s.watcherProvider.GetWatcher().GetResourceCount(),
-		"context":   s.watcherProvider.GetCurrentContext(),
+		"status":         "healthy",
+		"clients":        s.hub.ClientCount(),
+		"resources":      counts.Total,
+		"resourceHealth": counts.ByHealth,
+		"context":        s.watcherProvider.GetCurrentContext(),
 	})
 }
 
+// handleCacheMetrics returns the resource cache's size and hit, miss, set and delete counters
+func (s *Server) handleCacheMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.watcherProvider.GetWatcher().GetCacheMetrics())
+}
+
+// handleValidateRelationships lists relationships pointing at uncached resources
+func (s *Server) handleValidateRelationships(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.watcherProvider.GetWatcher().ValidateRelationships())
+}
+
+// handleResetCacheMetrics zeroes the resource cache's counters
+func (s *Server) handleResetCacheMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.watcherProvider.GetWatcher().ResetCacheMetrics()
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // handleNamespaces returns list of namespaces in the cluster
 func (s *Server) handleNamespaces(w http.ResponseWriter, r *http.Request) {
 	namespaces := s.watcherProvider.GetWatcher().GetNamespaces()
+	if scoped, ok := scopedNamespace(r); ok {
+		namespaces = []string{scoped}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"namespaces": namespaces,
 	})
 }
 
-// handleStats returns resource counts by type
+// handleStats returns resource counts by type and health state
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	namespace := r.URL.Query().Get("namespace")
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
 
 	counts := s.watcherProvider.GetWatcher().GetResourceCounts(namespace)
 
@@ -72,6 +115,22 @@ func (s *Server) handleContexts(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleContextDetails returns one kubeconfig context with its cluster's TLS settings
+func (s *Server) handleContextDetails(w http.ResponseWriter, r *http.Request) {
+	details, found, err := k8s.GetContextDetails(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to load context: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "context not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
 // handleCurrentContext returns the current Kubernetes context
 func (s *Server) handleCurrentContext(w http.ResponseWriter, r *http.Request) {
 	context := s.watcherProvider.GetCurrentContext()
@@ -82,6 +141,28 @@ func (s *Server) handleCurrentContext(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleCurrentContextInfo returns the current context's cluster, API server URL and
+// default namespace
+func (s *Server) handleCurrentContextInfo(w http.ResponseWriter, r *http.Request) {
+	info, err := s.watcherProvider.GetCurrentContextInfo()
+	if errors.Is(err, ErrContextNotFound) {
+		http.Error(w, "current context not found in kubeconfig", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to load context: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"name":      info.Name,
+		"cluster":   info.Cluster,
+		"server":    info.ClusterServer,
+		"namespace": info.Namespace,
+	})
+}
+
 // handleSwitchContext switches to a different Kubernetes context
 func (s *Server) handleSwitchContext(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -89,6 +170,11 @@ func (s *Server) handleSwitchContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := scopedNamespace(r); ok {
+		http.Error(w, "context switching is not allowed for namespace-scoped tokens", http.StatusForbidden)
+		return
+	}
+
 	context := r.URL.Query().Get("context")
 	if context == "" {
 		http.Error(w, "context parameter is required", http.StatusBadRequest)
@@ -98,6 +184,13 @@ func (s *Server) handleSwitchContext(w http.ResponseWriter, r *http.Request) {
 	s.logger.Printf("[API] Switching to context: %s", context)
 
 	err := s.watcherProvider.SwitchContext(context)
+	if errors.Is(err, ErrContextSwitchInProgress) {
+		s.logger.Printf("[API] Context switch to %s rejected: another switch is in progress", context)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		s.logger.Printf("[API] Context switch failed: %v", err)
 		http.Error(w, fmt.Sprintf("failed to switch context: %v", err), http.StatusInternalServerError)
@@ -130,11 +223,105 @@ func (s *Server) handleGetResource(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resource, found := s.watcherProvider.GetWatcher().GetResource(resourceID)
+	// Cluster-scoped resources are visible to scoped tokens, matching the snapshot filter
+	if found && resource.Namespace != "" && !namespaceAllowed(r, resource.Namespace) {
+		found = false
+	}
 	if !found {
 		http.Error(w, "resource not found", http.StatusNotFound)
 		return
 	}
 
+	if r.URL.Query().Get("format") == "yaml" {
+		w.Header().Set("Content-Type", "application/x-yaml")
+		w.Write([]byte(resource.YAML))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resource)
+	if r.URL.Query().Get("includeRelated") != "true" {
+		json.NewEncoder(w).Encode(resource)
+		return
+	}
+	json.NewEncoder(w).Encode(struct {
+		*types.Resource
+		Related []*types.Resource `json:"related"`
+	}{resource, s.relatedResources(r, resource)})
+}
+
+// relatedResources returns the cached resources one relationship away from resource that
+// the request may see, each once
+func (s *Server) relatedResources(r *http.Request, resource *types.Resource) []*types.Resource {
+	watcher := s.watcherProvider.GetWatcher()
+	seen := map[string]bool{resource.ID: true}
+	related := []*types.Resource{}
+	for _, ref := range resource.Relationships.All() {
+		if seen[ref.ID] {
+			continue
+		}
+		seen[ref.ID] = true
+		other, ok := watcher.GetResource(ref.ID)
+		if ok && (other.Namespace == "" || namespaceAllowed(r, other.Namespace)) {
+			related = append(related, other)
+		}
+	}
+	return related
+}
+
+// handleResourcesByAnnotation returns the resources annotated with key=value
+func (s *Server) handleResourcesByAnnotation(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "key parameter is required", http.StatusBadRequest)
+		return
+	}
+	value := r.URL.Query().Get("value")
+
+	resources := []*types.Resource{}
+	for _, resource := range s.watcherProvider.GetWatcher().GetResourcesByAnnotation(key, value) {
+		// Cluster-scoped resources are visible to scoped tokens, matching the snapshot filter
+		if resource.Namespace == "" || namespaceAllowed(r, resource.Namespace) {
+			resources = append(resources, resource)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"resources": resources,
+		"count":     len(resources),
+	})
+}
+
+// handleAuditEvents returns the most recent audit records
+func (s *Server) handleAuditEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events": s.audit.Recent(),
+	})
+}
+
+// handleTemplate returns an annotated YAML starter for a kind, using the API version
+// preferred by the connected cluster
+func (s *Server) handleTemplate(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	template, ok := templateRegistry.Get(kind)
+	if !ok {
+		http.Error(w, fmt.Sprintf("no template for kind %q (available: %s)", kind, strings.Join(templateRegistry.Kinds(), ", ")), http.StatusNotFound)
+		return
+	}
+
+	// Fall back to the template's own version when discovery fails
+	apiVersion := ""
+	if watcher := s.watcherProvider.GetWatcher(); watcher != nil {
+		gvk := template.GroupVersionKind()
+		version, err := watcher.GetClient().PreferredAPIVersion(gvk.Group, gvk.Kind)
+		if err != nil {
+			s.logger.Printf("[API] Using default API version for %s template: %v", gvk.Kind, err)
+		} else {
+			apiVersion = version
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	fmt.Fprint(w, template.YAML(apiVersion))
 }
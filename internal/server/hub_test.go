@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/k8v/internal/k8s"
+	"github.com/user/k8v/internal/types"
+)
+
+// newTestLogger returns a Logger that discards its output instead of writing logs/k8v.log
+func newTestLogger() *Logger {
+	return &Logger{logger: log.New(io.Discard, "", 0)}
+}
+
+// newTestClient returns a hub client without a connection, for tests reading its channels
+func newTestClient(hub *Hub, since int64) *Client {
+	return &Client{
+		send:     make(chan k8s.ResourceEvent, 256),
+		sendSync: make(chan k8s.SyncStatusEvent, 10),
+		hub:      hub,
+		logger:   hub.logger,
+		since:    since,
+		ready:    make(chan struct{}),
+	}
+}
+
+// registerTestClient registers a client and waits for the hub to process it
+func registerTestClient(t *testing.T, hub *Hub, since int64) *Client {
+	t.Helper()
+	client := newTestClient(hub, since)
+	hub.register <- client
+	select {
+	case <-client.ready:
+	case <-time.After(time.Second):
+		t.Fatal("hub didn't process the registration")
+	}
+	return client
+}
+
+func podEvent(namespace, name string) k8s.ResourceEvent {
+	return k8s.ResourceEvent{
+		Type:     k8s.EventAdded,
+		Resource: &types.Resource{ID: types.BuildID("Pod", namespace, name), Type: "Pod", Namespace: namespace, Name: name},
+	}
+}
+
+// receiveIDs reads n events from a client, failing if they don't arrive in time
+func receiveIDs(t *testing.T, client *Client, n int) []string {
+	t.Helper()
+	ids := make([]string, 0, n)
+	for len(ids) < n {
+		select {
+		case event := <-client.send:
+			ids = append(ids, event.Resource.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("received %v, want %d events", ids, n)
+		}
+	}
+	return ids
+}
+
+func TestHubExcludedNamespaces(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHubWithOptions(newTestLogger(), HubOptions{ExcludeNamespaces: []string{"kube-system"}})
+	go hub.Run()
+
+	live := registerTestClient(t, hub, 0)
+	start := live.generation
+
+	ctx := context.Background()
+	hub.Broadcast(ctx, podEvent("kube-system", "coredns"))
+	hub.Broadcast(ctx, podEvent("default", "web"))
+
+	if got := receiveIDs(t, live, 1); got[0] != "Pod:default:web" {
+		t.Errorf("live client received %v, want [Pod:default:web]", got)
+	}
+
+	// A client resuming from before both broadcasts is only replayed the visible one
+	resumed := registerTestClient(t, hub, start)
+	if !resumed.replayed {
+		t.Fatal("resuming client got a snapshot instead of a replay")
+	}
+	if got := receiveIDs(t, resumed, 1); got[0] != "Pod:default:web" {
+		t.Errorf("replay = %v, want [Pod:default:web]", got)
+	}
+	select {
+	case event := <-resumed.send:
+		t.Errorf("replay included %s", event.Resource.ID)
+	default:
+	}
+}
+
+func TestHubResetHistoryRequiresSnapshot(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(newTestLogger())
+	go hub.Run()
+
+	before := registerTestClient(t, hub, 0)
+	ctx := context.Background()
+	hub.Broadcast(ctx, podEvent("default", "old-cluster"))
+	receiveIDs(t, before, 1)
+	last := before.generation + 1 // The generation of the event it just received
+
+	// A socket dropped across a context switch resumes from the old cluster's generation
+	hub.ResetHistory()
+	resumed := registerTestClient(t, hub, last)
+	if resumed.replayed {
+		t.Error("client resuming from before ResetHistory was replayed instead of getting a snapshot")
+	}
+
+	// Clients connected after the reset still resume normally
+	after := registerTestClient(t, hub, 0)
+	hub.Broadcast(ctx, podEvent("default", "new-cluster"))
+	receiveIDs(t, after, 1)
+	for _, since := range []int64{after.generation, after.generation + 1} {
+		client := registerTestClient(t, hub, since)
+		if !client.replayed {
+			t.Errorf("client resuming from generation %d after the reset got a snapshot, want a replay", since)
+		}
+	}
+}
+
+// drainClient reads a client's channels until the hub closes them, and reports whether
+// it did within a few seconds
+func drainClient(client *Client) bool {
+	timeout := time.After(5 * time.Second)
+	send, sendSync := client.send, client.sendSync
+	for send != nil || sendSync != nil {
+		select {
+		case _, ok := <-send:
+			if !ok {
+				send = nil
+			}
+		case _, ok := <-sendSync:
+			if !ok {
+				sendSync = nil
+			}
+		case <-timeout:
+			return false
+		}
+	}
+	return true
+}
+
+func TestHubBroadcastUnderConcurrentDisconnect(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(newTestLogger())
+	go hub.Run()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var broadcasters sync.WaitGroup
+	broadcasters.Add(1)
+	go func() {
+		defer broadcasters.Done()
+		for i := 0; ctx.Err() == nil; i++ {
+			hub.Broadcast(ctx, podEvent("default", "web"))
+			if i%10 == 0 {
+				hub.BroadcastSyncStatus(k8s.SyncStatusEvent{Type: k8s.EventSyncStatus, Synced: true})
+			}
+		}
+	}()
+
+	// Clients leave through Run, through DisconnectAll, or by being too slow, in any order
+	const clients = 100
+	var wg sync.WaitGroup
+	closed := make(chan bool, clients)
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client := newTestClient(hub, 0)
+			hub.register <- client
+			<-client.ready
+			if i%10 == 0 {
+				hub.DisconnectAll()
+			}
+			// Ready closes before Run adds the client, so DisconnectAll may have missed it
+			hub.unregister <- client
+			closed <- drainClient(client)
+		}(i)
+	}
+	wg.Wait()
+	cancel()
+	broadcasters.Wait()
+
+	close(closed)
+	for ok := range closed {
+		if !ok {
+			t.Fatal("a client's channels were never closed after it left")
+		}
+	}
+	if got := hub.ClientCount(); got != 0 {
+		t.Errorf("ClientCount() = %d after every client left, want 0", got)
+	}
+}
+
+func TestHubSlowClientEviction(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub(newTestLogger())
+	go hub.Run()
+
+	fast := registerTestClient(t, hub, 0)
+	slow := newTestClient(hub, 0)
+	slow.send = make(chan k8s.ResourceEvent, 1)
+	hub.register <- slow
+	<-slow.ready
+
+	// Once the slow client's buffer is full, broadcasts evict it instead of blocking
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, name := range []string{"first", "second", "third"} {
+		if err := hub.Broadcast(ctx, podEvent("default", name)); err != nil {
+			t.Fatalf("Broadcast() error = %v, want the hub to keep accepting events", err)
+		}
+	}
+
+	got := receiveIDs(t, fast, 3)
+	if got[0] != "Pod:default:first" || got[2] != "Pod:default:third" {
+		t.Errorf("fast client received %v, want every event", got)
+	}
+	if event, ok := <-slow.send; !ok || event.Resource.ID != "Pod:default:first" {
+		t.Errorf("slow client's buffered event = %+v, want Pod:default:first", event)
+	}
+	if _, ok := <-slow.send; ok {
+		t.Error("slow client's send channel is still open after its eviction")
+	}
+	if _, ok := <-slow.sendSync; ok {
+		t.Error("slow client's sync channel is still open after its eviction")
+	}
+	if got := hub.ClientCount(); got != 1 {
+		t.Errorf("ClientCount() = %d, want only the fast client", got)
+	}
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jwksCacheTTL        = 10 * time.Minute // refetch keys at most this often for known key IDs
+	jwksMinRefetchDelay = 30 * time.Second // minimum delay between refetches for unknown key IDs
+)
+
+// scopedNamespaceKey is the request context key holding the namespace from the JWT claim
+type scopedNamespaceKey struct{}
+
+// JWTNamespaceScoper validates JWT bearer tokens against a JWKS endpoint and extracts
+// the namespace a caller is restricted to from a configurable claim
+type JWTNamespaceScoper struct {
+	jwksURL    string
+	claim      string
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey // kid -> key
+	fetchedAt time.Time
+}
+
+// NewJWTNamespaceScoper creates a scoper for the given JWKS URL and namespace claim name
+func NewJWTNamespaceScoper(jwksURL, claim string) *JWTNamespaceScoper {
+	return &JWTNamespaceScoper{
+		jwksURL:    jwksURL,
+		claim:      claim,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		keys:       make(map[string]*rsa.PublicKey),
+	}
+}
+
+// SetNamespaceScoper enables JWT-based namespace scoping for data endpoints
+// A nil scoper (the default) passes all requests through unchanged
+func (s *Server) SetNamespaceScoper(scoper *JWTNamespaceScoper) {
+	s.namespaceScoper = scoper
+}
+
+// scopeNamespace returns a middleware that validates the caller's JWT and stores the
+// namespace claim (if any) in the request context for handlers to enforce
+func (s *Server) scopeNamespace(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.namespaceScoper == nil {
+			next(w, r)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := s.namespaceScoper.validate(r.Context(), token)
+		if err != nil {
+			s.logger.Printf("[Auth] Invalid JWT from %s: %v", r.RemoteAddr, err)
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		if namespace, ok := claims[s.namespaceScoper.claim].(string); ok && namespace != "" {
+			r = r.WithContext(context.WithValue(r.Context(), scopedNamespaceKey{}, namespace))
+		}
+		next(w, r)
+	}
+}
+
+// scopedNamespace returns the namespace the request is restricted to, if any
+func scopedNamespace(r *http.Request) (string, bool) {
+	namespace, ok := r.Context().Value(scopedNamespaceKey{}).(string)
+	return namespace, ok
+}
+
+// namespaceAllowed reports whether a scoped request may access the given namespace
+// Unscoped requests may access every namespace
+func namespaceAllowed(r *http.Request, namespace string) bool {
+	scoped, ok := scopedNamespace(r)
+	return !ok || scoped == namespace
+}
+
+// bearerToken extracts a token from the Authorization header, falling back to the
+// access_token query parameter since browsers can't set headers on WebSocket upgrades
+func bearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+// validate verifies the token signature and time-based claims and returns its claims
+func (j *JWTNamespaceScoper) validate(ctx context.Context, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	var hash crypto.Hash
+	switch header.Alg {
+	case "RS256":
+		hash = crypto.SHA256
+	case "RS384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %q", header.Alg)
+	}
+
+	key, err := j.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, hash, hasher.Sum(nil), signature); err != nil {
+		return nil, errors.New("signature verification failed")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+
+	now := float64(time.Now().Unix())
+	if exp, ok := claims["exp"].(float64); ok && now >= exp {
+		return nil, errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < nbf {
+		return nil, errors.New("token not yet valid")
+	}
+
+	return claims, nil
+}
+
+// key returns the public key for a key ID, refreshing the JWKS when needed
+func (j *JWTNamespaceScoper) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	age := time.Since(j.fetchedAt)
+	j.mu.RUnlock()
+
+	if ok && age < jwksCacheTTL {
+		return key, nil
+	}
+	if !ok && age < jwksMinRefetchDelay {
+		return nil, fmt.Errorf("unknown key ID: %q", kid)
+	}
+
+	if err := j.refresh(ctx); err != nil {
+		if ok {
+			// Keep using the known key if the JWKS endpoint is temporarily unavailable
+			return key, nil
+		}
+		return nil, err
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key ID: %q", kid)
+}
+
+// refresh fetches the JWKS document and replaces the cached RSA keys
+func (j *JWTNamespaceScoper) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.jwksURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build JWKS request: %w", err)
+	}
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	j.mu.Unlock()
+	return nil
+}
+
+// decodeSegment decodes a base64url-encoded JWT segment into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
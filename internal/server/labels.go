@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/user/k8v/internal/audit"
+	"github.com/user/k8v/internal/k8s"
+)
+
+// maxConcurrentPatches bounds the number of in-flight PATCH calls for a batch edit
+const maxConcurrentPatches = 10
+
+// BatchLabelsRequest is the body of PATCH /api/resources/labels
+type BatchLabelsRequest struct {
+	IDs               []string          `json:"ids"` // Resource IDs, e.g. "Pod:prod:web-abc"
+	AddLabels         map[string]string `json:"addLabels"`
+	RemoveLabels      []string          `json:"removeLabels"`
+	AddAnnotations    map[string]string `json:"addAnnotations"`
+	RemoveAnnotations []string          `json:"removeAnnotations"`
+	// AllowClusterScope must be set to edit cluster-scoped resources such as Nodes
+	AllowClusterScope bool `json:"allowClusterScope"`
+}
+
+// BatchPatchResult is the outcome of patching a single resource
+type BatchPatchResult struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// handleBatchLabels adds and removes labels/annotations on many resources at once
+func (s *Server) handleBatchLabels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BatchLabelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		http.Error(w, "missing required field: ids", http.StatusBadRequest)
+		return
+	}
+	if len(req.AddLabels) == 0 && len(req.RemoveLabels) == 0 && len(req.AddAnnotations) == 0 && len(req.RemoveAnnotations) == 0 {
+		http.Error(w, "no label or annotation changes requested", http.StatusBadRequest)
+		return
+	}
+
+	patch := k8s.MetadataPatch{
+		AddLabels:         req.AddLabels,
+		RemoveLabels:      req.RemoveLabels,
+		AddAnnotations:    req.AddAnnotations,
+		RemoveAnnotations: req.RemoveAnnotations,
+	}
+	client := s.watcherProvider.GetWatcher().GetClient()
+	actor := audit.ActorFromRequest(r)
+	_, scoped := scopedNamespace(r)
+
+	var mu sync.Mutex
+	results := make(map[string]BatchPatchResult, len(req.IDs))
+	setResult := func(id string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			results[id] = BatchPatchResult{Success: false, Error: err.Error()}
+		} else {
+			results[id] = BatchPatchResult{Success: true}
+		}
+	}
+
+	g, ctx := errgroup.WithContext(r.Context())
+	g.SetLimit(maxConcurrentPatches)
+	for _, id := range req.IDs {
+		resourceType, namespace, name, err := parseResourceID(id)
+		if err != nil {
+			setResult(id, err)
+			continue
+		}
+		if k8s.IsClusterScopedType(resourceType) {
+			if scoped {
+				setResult(id, fmt.Errorf("cluster-scoped resources are not allowed for namespace-scoped tokens"))
+				continue
+			}
+			if !req.AllowClusterScope {
+				setResult(id, fmt.Errorf("cluster-scoped resource requires allowClusterScope: true"))
+				continue
+			}
+		} else if !namespaceAllowed(r, namespace) {
+			setResult(id, fmt.Errorf("namespace not allowed"))
+			continue
+		}
+
+		// Patch failures are reported per resource, so never return an error to the group
+		g.Go(func() error {
+			err := client.PatchMetadata(ctx, resourceType, namespace, name, patch, s.dryRunOptions())
+			setResult(id, err)
+
+			s.audit.Log(audit.Record{
+				Operation: "patch.metadata",
+				Actor:     actor,
+				Resource:  audit.ResourceInfo{Type: resourceType, Namespace: namespace, Name: name},
+				Result:    audit.ResultFor(err),
+			})
+			return nil
+		})
+	}
+	g.Wait()
+
+	description := fmt.Sprintf("patch labels/annotations on %d resources", len(req.IDs))
+	s.writeMutationResponse(w, description, results)
+}
+
+// parseResourceID splits a resource ID ("Type:namespace:name", or "Type::name" for
+// cluster-scoped resources) into its components
+func parseResourceID(id string) (resourceType, namespace, name string, err error) {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid resource ID: %q", id)
+	}
+	if k8s.IsClusterScopedType(parts[0]) != (parts[1] == "") {
+		return "", "", "", fmt.Errorf("invalid namespace in resource ID: %q", id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
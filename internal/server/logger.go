@@ -46,6 +46,11 @@ func NewLogger() (*Logger, error) {
 	}, nil
 }
 
+// NewLoggerTo creates a logger that writes only to w, without a log file
+func NewLoggerTo(w io.Writer) *Logger {
+	return &Logger{logger: log.New(w, "", log.LstdFlags)}
+}
+
 // Close closes the log file
 func (l *Logger) Close() error {
 	if l.file != nil {
@@ -95,6 +100,13 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush implements http.Flusher for server-sent event streams
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // Hijack implements http.Hijacker interface for WebSocket support
 func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
@@ -2,9 +2,12 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/gorilla/websocket"
@@ -14,31 +17,79 @@ import (
 
 // LogClient represents a WebSocket client for log streaming
 type LogClient struct {
-	conn   *websocket.Conn
-	send   chan k8s.LogMessage
-	hub    *LogHub
-	podKey string // "namespace/pod/container"
-	logger *Logger
+	conn      *websocket.Conn
+	send      chan k8s.LogMessage
+	hub       *LogHub
+	podKey    string // "namespace/pod/container"
+	streamKey string // podKey and the log options; clients with the same key share a stream
+	logger    *Logger
+
+	// tailLines caps the history replayed when joining a running stream, so a client
+	// asking for ?tailLines=N never starts with more than N lines (nil = whole buffer)
+	tailLines *int64
+
+	// open streams the container's logs to messages until ctx is done; the hub calls it
+	// when the client is the first to follow streamKey
+	open   func(ctx context.Context, messages chan<- k8s.LogMessage)
+	stream *logStream // set by the hub on register
+}
+
+// logStream is one upstream log stream, shared by every client following a container
+// with the same options, so each line is fetched once and delivered once per client
+type logStream struct {
+	key     string
+	podKey  string
+	clients []*LogClient
+	buffer  *k8s.LogRingBuffer // recent lines, replayed to clients joining the stream
+	cancel  context.CancelFunc
+}
+
+// logBroadcast is a log message from one stream, for the clients following it
+type logBroadcast struct {
+	stream  *logStream
+	message k8s.LogMessage
+}
+
+// LogHubOptions configures optional log hub behavior
+type LogHubOptions struct {
+	// BufferLines is the number of recent lines replayed to clients joining a container's
+	// stream (default k8s.DefaultLogBufferLines)
+	BufferLines int
 }
 
 // LogHub manages all active log streaming WebSocket connections
 type LogHub struct {
-	clients    map[*LogClient]bool
-	broadcast  chan k8s.LogMessage
-	register   chan *LogClient
-	unregister chan *LogClient
-	mu         sync.RWMutex
-	logger     *Logger
+	clients     map[*LogClient]bool
+	streams     map[string]*logStream // stream key -> running stream new clients join
+	bufferLines int
+	broadcast   chan logBroadcast
+	ended       chan *logStream
+	register    chan *LogClient
+	unregister  chan *LogClient
+	mu          sync.RWMutex
+	logger      *Logger
 }
 
 // NewLogHub creates a new LogHub
 func NewLogHub(logger *Logger) *LogHub {
+	return NewLogHubWithOptions(logger, LogHubOptions{})
+}
+
+// NewLogHubWithOptions creates a new LogHub with the given options
+func NewLogHubWithOptions(logger *Logger, options LogHubOptions) *LogHub {
+	bufferLines := options.BufferLines
+	if bufferLines <= 0 {
+		bufferLines = k8s.DefaultLogBufferLines
+	}
 	return &LogHub{
-		clients:    make(map[*LogClient]bool),
-		broadcast:  make(chan k8s.LogMessage, 256),
-		register:   make(chan *LogClient),
-		unregister: make(chan *LogClient),
-		logger:     logger,
+		clients:     make(map[*LogClient]bool),
+		streams:     make(map[string]*logStream),
+		bufferLines: bufferLines,
+		broadcast:   make(chan logBroadcast, 256),
+		ended:       make(chan *logStream),
+		register:    make(chan *LogClient),
+		unregister:  make(chan *LogClient),
+		logger:      logger,
 	}
 }
 
@@ -49,43 +100,185 @@ func (h *LogHub) Run() {
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
+			stream, ok := h.streams[client.streamKey]
+			if ok {
+				// Replay recent history before any live line, dropping what doesn't fit.
+				// The buffer belongs to the stream, so it holds only lines sent with the
+				// client's own options (previous, tailLines, grep...).
+				history := stream.buffer.Messages()
+				if tail := client.tailLines; tail != nil && *tail >= 0 && int64(len(history)) > *tail {
+					history = history[int64(len(history))-*tail:]
+				}
+				for _, message := range history {
+					select {
+					case client.send <- message:
+					default:
+					}
+				}
+			} else {
+				stream = h.startLocked(client)
+			}
+			stream.clients = append(stream.clients, client)
+			client.stream = stream
+			count := len(stream.clients)
 			h.mu.Unlock()
-			h.logger.Printf("[LogHub] Client connected: %s (total: %d)", client.podKey, len(h.clients))
+			h.logger.Printf("[LogHub] Client connected: %s (clients for stream: %d)", client.podKey, count)
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
+			if h.removeLocked(client) {
 				close(client.send)
 			}
 			h.mu.Unlock()
-			h.logger.Printf("[LogHub] Client disconnected: %s (total: %d)", client.podKey, len(h.clients))
+			h.logger.Printf("[LogHub] Client disconnected: %s", client.podKey)
 
-		case message := <-h.broadcast:
-			h.mu.RLock()
-			for client := range h.clients {
+		case broadcast := <-h.broadcast:
+			// Removing slow clients mutates the stream, so a write lock is needed
+			h.mu.Lock()
+			stream := broadcast.stream
+			if broadcast.message.Type == "LOG_LINE" {
+				stream.buffer.Add(broadcast.message)
+			}
+			for _, client := range stream.clients {
 				select {
-				case client.send <- message:
+				case client.send <- broadcast.message:
 					// Sent successfully
 				default:
 					// Client is slow, close it
+					h.removeLocked(client)
 					close(client.send)
-					delete(h.clients, client)
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
+
+		case stream := <-h.ended:
+			// Its clients keep their connection; new clients start a fresh stream
+			h.mu.Lock()
+			if h.streams[stream.key] == stream {
+				delete(h.streams, stream.key)
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// startLocked starts the stream a client is the first to follow
+// Callers must hold the write lock
+func (h *LogHub) startLocked(client *LogClient) *logStream {
+	// Background context: the stream outlives the request of the client that opened it
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &logStream{
+		key:    client.streamKey,
+		podKey: client.podKey,
+		buffer: k8s.NewLogRingBuffer(h.bufferLines),
+		cancel: cancel,
+	}
+	h.streams[stream.key] = stream
+
+	open := client.open
+	go func() {
+		defer cancel()
+		messages := make(chan k8s.LogMessage, 256)
+		go func() {
+			open(ctx, messages)
+			close(messages)
+		}()
+		for message := range messages {
+			h.broadcast <- logBroadcast{stream: stream, message: message}
+		}
+		h.ended <- stream
+	}()
+	return stream
+}
+
+// removeLocked removes a client from the hub and its stream, stopping the stream once
+// no client follows it, and reports whether the client was registered
+// Callers must hold the write lock
+func (h *LogHub) removeLocked(client *LogClient) bool {
+	if !h.clients[client] {
+		return false
+	}
+	delete(h.clients, client)
+
+	stream := client.stream
+	for i, c := range stream.clients {
+		if c != client {
+			continue
+		}
+		// Copy rather than reslice in place: Run may be ranging over the old slice
+		remaining := make([]*LogClient, 0, len(stream.clients)-1)
+		remaining = append(remaining, stream.clients[:i]...)
+		stream.clients = append(remaining, stream.clients[i+1:]...)
+		break
+	}
+	if len(stream.clients) == 0 {
+		stream.cancel()
+		if h.streams[stream.key] == stream {
+			delete(h.streams, stream.key)
+		}
+	}
+	return true
+}
+
+// ClientsForPod returns the number of clients following a "namespace/pod/container" key,
+// whatever their log options
+func (h *LogHub) ClientsForPod(podKey string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	count := 0
+	for client := range h.clients {
+		if client.podKey == podKey {
+			count++
+		}
+	}
+	return count
+}
+
+// StreamsForPod returns the number of running upstream streams of a
+// "namespace/pod/container" key, one per distinct set of log options
+func (h *LogHub) StreamsForPod(podKey string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	count := 0
+	for _, stream := range h.streams {
+		if stream.podKey == podKey {
+			count++
+		}
+	}
+	return count
+}
+
+// DiscardPodBuffers drops the buffered history of every container of a Pod
+func (h *LogHub) DiscardPodBuffers(namespace, pod string) {
+	prefix := namespace + "/" + pod + "/"
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, stream := range h.streams {
+		if strings.HasPrefix(stream.podKey, prefix) {
+			stream.buffer = k8s.NewLogRingBuffer(h.bufferLines)
 		}
 	}
 }
 
-// DisconnectAll forcefully disconnects all log streaming clients
+// DisconnectAll forcefully disconnects all log streaming clients, stopping their streams
+// and dropping buffered history
 func (h *LogHub) DisconnectAll() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	for _, stream := range h.streams {
+		stream.cancel()
+	}
+	h.streams = make(map[string]*logStream)
+
 	for client := range h.clients {
 		close(client.send)
 		client.conn.Close()
+		// Ended streams are no longer in h.streams, and a stopping stream may still
+		// deliver buffered lines, which must find no client
+		client.stream.cancel()
+		client.stream.clients = nil
 		delete(h.clients, client)
 	}
 	h.logger.Printf("[LogHub] All clients disconnected")
@@ -102,6 +295,10 @@ func (s *Server) handleLogsWebSocket(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "missing required parameters: namespace, pod, container", http.StatusBadRequest)
 		return
 	}
+	if !namespaceAllowed(r, namespace) {
+		http.Error(w, "namespace not allowed", http.StatusForbidden)
+		return
+	}
 
 	// Parse optional log options
 	var opts k8s.LogOptions
@@ -130,6 +327,13 @@ func (s *Server) handleLogsWebSocket(w http.ResponseWriter, r *http.Request) {
 	followStr := r.URL.Query().Get("follow")
 	opts.Follow = followStr != "false" // Default to true
 
+	opts.Previous = r.URL.Query().Get("previous") == "true"
+
+	if err := parseGrepOptions(r, &opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Upgrade connection
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -142,42 +346,90 @@ func (s *Server) handleLogsWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	// Create client
 	client := &LogClient{
-		conn:   conn,
-		send:   make(chan k8s.LogMessage, 1000),
-		hub:    s.logHub,
-		podKey: podKey,
-		logger: s.logger,
+		conn:      conn,
+		send:      make(chan k8s.LogMessage, 1000),
+		hub:       s.logHub,
+		podKey:    podKey,
+		streamKey: podKey + "?" + logStreamQuery(opts),
+		logger:    s.logger,
+		tailLines: opts.TailLines,
+		open: func(ctx context.Context, messages chan<- k8s.LogMessage) {
+			watcher := s.watcherProvider.GetWatcher()
+			var err error
+			if opts.Previous {
+				// Report why the previous container exited, or a clear error if it never restarted
+				var exitInfo *k8s.ContainerExitInfo
+				exitInfo, err = watcher.GetClient().GetPreviousContainerExitInfo(ctx, namespace, pod, container)
+				if err == nil {
+					messages <- k8s.LogMessage{Type: "LOG_PREVIOUS_INFO", Previous: exitInfo}
+				}
+			}
+			if err == nil {
+				err = watcher.StreamPodLogs(ctx, namespace, pod, container, opts, messages)
+			}
+			if err != nil && ctx.Err() == nil {
+				s.logger.Printf("[LogStream] Streaming error for %s: %v", podKey, err)
+				// Send error message to the stream's clients
+				messages <- k8s.LogMessage{
+					Type:  "LOG_ERROR",
+					Error: err.Error(),
+				}
+			}
+		},
 	}
 
+	// The hub starts the stream unless another client already follows it
 	s.logHub.register <- client
 
-	// Start log streaming in background
-	// Use background context instead of r.Context() to avoid cancellation after WebSocket upgrade
-	ctx, cancel := context.WithCancel(context.Background())
-
-	go func() {
-		err := s.watcherProvider.GetWatcher().StreamPodLogs(ctx, namespace, pod, container, opts, s.logHub.broadcast)
-		if err != nil {
-			s.logger.Printf("[LogStream] Streaming error for %s: %v", podKey, err)
-			// Send error message to client
-			s.logHub.broadcast <- k8s.LogMessage{
-				Type:  "LOG_ERROR",
-				Error: err.Error(),
-			}
-		}
-		cancel()
-	}()
-
 	// Start pumps
 	go client.writePump()
-	go client.readPump(cancel) // Pass cancel to stop streaming on disconnect
+	go client.readPump()
+}
+
+// parseGrepOptions reads ?grep, ?grepInvert and ?grepRegex into opts, rejecting invalid patterns
+func parseGrepOptions(r *http.Request, opts *k8s.LogOptions) error {
+	opts.Grep = r.URL.Query().Get("grep")
+	opts.GrepInvert = r.URL.Query().Get("grepInvert") == "true"
+	opts.GrepRegex = r.URL.Query().Get("grepRegex") == "true"
+	_, err := opts.LineMatcher()
+	return err
+}
+
+// logStreamQuery encodes the options that change what a stream sends, so only clients
+// asking for the same lines share a stream (and its replayed history)
+func logStreamQuery(opts k8s.LogOptions) string {
+	query := url.Values{}
+	if opts.TailLines != nil {
+		query.Set("tailLines", strconv.FormatInt(*opts.TailLines, 10))
+	}
+	if opts.HeadLines != nil {
+		query.Set("headLines", strconv.FormatInt(*opts.HeadLines, 10))
+	}
+	if opts.SinceSeconds != nil {
+		query.Set("sinceSeconds", strconv.FormatInt(*opts.SinceSeconds, 10))
+	}
+	if !opts.Follow {
+		query.Set("follow", "false")
+	}
+	if opts.Previous {
+		query.Set("previous", "true")
+	}
+	if opts.Grep != "" {
+		query.Set("grep", opts.Grep)
+	}
+	if opts.GrepInvert {
+		query.Set("grepInvert", "true")
+	}
+	if opts.GrepRegex {
+		query.Set("grepRegex", "true")
+	}
+	return query.Encode()
 }
 
 // readPump pumps messages from the WebSocket connection
-func (c *LogClient) readPump(cancel context.CancelFunc) {
+func (c *LogClient) readPump() {
 	defer func() {
-		cancel() // Stop log streaming
-		c.hub.unregister <- c
+		c.hub.unregister <- c // Stops the stream if no other client follows it
 		c.conn.Close()
 	}()
 
@@ -202,3 +454,103 @@ func (c *LogClient) writePump() {
 		}
 	}
 }
+
+// SseLogClient streams one container's logs to an HTTP client as server-sent events,
+// for clients behind proxies that block WebSocket upgrades
+type SseLogClient struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	podKey  string // "namespace/pod/container"
+	logger  *Logger
+}
+
+// send writes a message as a "data: <LogMessage JSON>" event and flushes it
+func (c *SseLogClient) send(message k8s.LogMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.w, "data: %s\n\n", payload); err != nil {
+		return err
+	}
+	c.flusher.Flush()
+	return nil
+}
+
+// handleLogsSSE streams container logs as server-sent events until the logs end or the
+// client disconnects. ?tail limits the initial lines and ?since (e.g. 10m, 1d) their age;
+// ?grep filters them like the WebSocket endpoint.
+func (s *Server) handleLogsSSE(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	pod := r.URL.Query().Get("pod")
+	container := r.URL.Query().Get("container")
+
+	if namespace == "" || pod == "" || container == "" {
+		http.Error(w, "missing required parameters: namespace, pod, container", http.StatusBadRequest)
+		return
+	}
+	if !namespaceAllowed(r, namespace) {
+		http.Error(w, "namespace not allowed", http.StatusForbidden)
+		return
+	}
+
+	opts := k8s.LogOptions{Follow: r.URL.Query().Get("follow") != "false"}
+	if tail := r.URL.Query().Get("tail"); tail != "" {
+		val, err := strconv.ParseInt(tail, 10, 64)
+		if err != nil || val < 0 {
+			http.Error(w, "invalid tail", http.StatusBadRequest)
+			return
+		}
+		opts.TailLines = &val
+	}
+	if since := r.URL.Query().Get("since"); since != "" {
+		d, err := k8s.ParseDurationWithDays(since)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		seconds := int64(d.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+	if err := parseGrepOptions(r, &opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	podKey := fmt.Sprintf("%s/%s/%s", namespace, pod, container)
+	client := &SseLogClient{w: w, flusher: flusher, podKey: podKey, logger: s.logger}
+	s.logger.Printf("[LogSSE] New connection: %s", podKey)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	flusher.Flush()
+
+	// The request context ends the stream when the client disconnects
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	messages := make(chan k8s.LogMessage, 256)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.watcherProvider.GetWatcher().StreamPodLogs(ctx, namespace, pod, container, opts, messages)
+		close(messages)
+	}()
+
+	for message := range messages {
+		if err := client.send(message); err != nil {
+			cancel() // Client went away; drain until the stream stops
+		}
+	}
+	if err := <-done; err != nil && ctx.Err() == nil {
+		s.logger.Printf("[LogSSE] Streaming error for %s: %v", podKey, err)
+		client.send(k8s.LogMessage{Type: "LOG_ERROR", Error: err.Error()})
+	}
+	s.logger.Printf("[LogSSE] Connection closed: %s", podKey)
+}
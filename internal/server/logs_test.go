@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+// fakeLogSource counts the streams opened on it; each stream sends lines, then waits
+// until it's cancelled (a followed stream) or returns (follow=false)
+type fakeLogSource struct {
+	opened    atomic.Int32
+	cancelled atomic.Int32
+	lines     []string
+	follow    bool
+}
+
+func (f *fakeLogSource) open(ctx context.Context, messages chan<- k8s.LogMessage) {
+	f.opened.Add(1)
+	for _, line := range f.lines {
+		messages <- k8s.LogMessage{Type: "LOG_LINE", Line: line}
+	}
+	if !f.follow {
+		messages <- k8s.LogMessage{Type: "LOG_END"}
+		return
+	}
+	<-ctx.Done()
+	f.cancelled.Add(1)
+}
+
+func newTestLogClient(hub *LogHub, streamKey string, source *fakeLogSource) *LogClient {
+	return &LogClient{
+		send:      make(chan k8s.LogMessage, 100),
+		hub:       hub,
+		podKey:    "default/web/app",
+		streamKey: streamKey,
+		logger:    hub.logger,
+		open:      source.open,
+	}
+}
+
+// receiveLogs reads n messages from a log client, failing if they don't arrive in time
+func receiveLogs(t *testing.T, client *LogClient, n int) []k8s.LogMessage {
+	t.Helper()
+	messages := make([]k8s.LogMessage, 0, n)
+	for len(messages) < n {
+		select {
+		case message := <-client.send:
+			messages = append(messages, message)
+		case <-time.After(time.Second):
+			t.Fatalf("received %v, want %d messages", messages, n)
+		}
+	}
+	return messages
+}
+
+// assertNoLogs fails if a client receives another message soon
+func assertNoLogs(t *testing.T, client *LogClient) {
+	t.Helper()
+	select {
+	case message := <-client.send:
+		t.Errorf("unexpected message %+v", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLogHubSharesStreams(t *testing.T) {
+	t.Parallel()
+
+	hub := NewLogHub(newTestLogger())
+	go hub.Run()
+
+	source := &fakeLogSource{lines: []string{"one", "two"}, follow: true}
+	first := newTestLogClient(hub, "default/web/app?", source)
+	hub.register <- first
+	if got := receiveLogs(t, first, 2); got[0].Line != "one" || got[1].Line != "two" {
+		t.Fatalf("first client received %+v, want lines one, two", got)
+	}
+
+	// The second client joins the running stream: history is replayed, nothing is fetched again
+	second := newTestLogClient(hub, "default/web/app?", source)
+	hub.register <- second
+	if got := receiveLogs(t, second, 2); got[0].Line != "one" || got[1].Line != "two" {
+		t.Errorf("joining client replayed %+v, want lines one, two", got)
+	}
+	assertNoLogs(t, first)
+	if opened := source.opened.Load(); opened != 1 {
+		t.Errorf("opened %d streams for one key, want 1", opened)
+	}
+	if got := hub.ClientsForPod("default/web/app"); got != 2 {
+		t.Errorf("ClientsForPod() = %d, want 2", got)
+	}
+	if got := hub.StreamsForPod("default/web/app"); got != 1 {
+		t.Errorf("StreamsForPod() = %d, want 1", got)
+	}
+
+	// The stream stops once its last client leaves, not before
+	hub.unregister <- first
+	assertNoLogs(t, second)
+	if cancelled := source.cancelled.Load(); cancelled != 0 {
+		t.Fatal("stream stopped while a client still follows it")
+	}
+	hub.unregister <- second
+	deadline := time.Now().Add(time.Second)
+	for source.cancelled.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stream kept running after its last client left")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLogHubSeparatesStreamOptions(t *testing.T) {
+	t.Parallel()
+
+	hub := NewLogHub(newTestLogger())
+	go hub.Run()
+
+	live := &fakeLogSource{lines: []string{"current"}, follow: true}
+	previous := &fakeLogSource{lines: []string{"crashed"}}
+	liveClient := newTestLogClient(hub, "default/web/app?", live)
+	previousClient := newTestLogClient(hub, "default/web/app?previous=true", previous)
+	hub.register <- liveClient
+	hub.register <- previousClient
+
+	if got := receiveLogs(t, previousClient, 2); got[0].Line != "crashed" || got[1].Type != "LOG_END" {
+		t.Errorf("previous client received %+v, want the crashed line then LOG_END", got)
+	}
+	// One stream ending doesn't end the other
+	if got := receiveLogs(t, liveClient, 1); got[0].Line != "current" {
+		t.Errorf("live client received %+v, want the current line", got)
+	}
+	assertNoLogs(t, liveClient)
+}
+
+func TestLogStreamQuery(t *testing.T) {
+	t.Parallel()
+
+	tail := int64(100)
+	tests := []struct {
+		name string
+		opts k8s.LogOptions
+		want string
+	}{
+		{"follow", k8s.LogOptions{Follow: true}, ""},
+		{"no follow", k8s.LogOptions{}, "follow=false"},
+		{"previous", k8s.LogOptions{Follow: true, Previous: true}, "previous=true"},
+		{"tail", k8s.LogOptions{Follow: true, TailLines: &tail}, "tailLines=100"},
+		{"grep", k8s.LogOptions{Follow: true, Grep: "error", GrepInvert: true}, "grep=error&grepInvert=true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := logStreamQuery(tt.opts); got != tt.want {
+				t.Errorf("logStreamQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogHubReplayHonorsTailLines(t *testing.T) {
+	t.Parallel()
+
+	hub := NewLogHub(newTestLogger())
+	go hub.Run()
+
+	source := &fakeLogSource{lines: []string{"one", "two", "three"}, follow: true}
+	first := newTestLogClient(hub, "default/web/app?tailLines=2", source)
+	hub.register <- first
+	receiveLogs(t, first, 3)
+
+	tail := int64(2)
+	joining := newTestLogClient(hub, "default/web/app?tailLines=2", source)
+	joining.tailLines = &tail
+	hub.register <- joining
+	if got := receiveLogs(t, joining, 2); got[0].Line != "two" || got[1].Line != "three" {
+		t.Errorf("replay = %+v, want the last 2 lines", got)
+	}
+	assertNoLogs(t, joining)
+
+	// A client with other options never sees this stream's history
+	other := newTestLogClient(hub, "default/web/app?previous=true", &fakeLogSource{})
+	hub.register <- other
+	if got := receiveLogs(t, other, 1); got[0].Type != "LOG_END" {
+		t.Errorf("client with other options received %+v, want only its own LOG_END", got)
+	}
+}
@@ -2,15 +2,18 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
+	"github.com/user/k8v/internal/audit"
 	"github.com/user/k8v/internal/k8s"
 )
 
@@ -23,10 +26,17 @@ type NodeExecClient struct {
 	nodeName          string // target node name
 	debugPodName      string // created debug pod name
 	debugPodNamespace string // namespace where debug pod is created
+	sessionID         string // unique ID for audit records
+	actor             string // client IP address for audit records
 	logger            *Logger
 	cancelFunc        context.CancelFunc
 	sizeQueue         *k8s.TerminalSizeQueue
-	stdinPipe         io.WriteCloser
+	stdinPipe         *countingWriter // counts input bytes for ActiveSessions
+	startedAt         time.Time
+	remoteAddr        string
+	recorder          *AsciinemaRecorder // nil unless sessions are recorded
+	output            *AuditRingBuffer   // tail of the session output
+	binary            bool               // ?protocol=binary: exchange binary frames instead of JSON
 }
 
 // NodeExecHub manages all active node exec WebSocket connections
@@ -105,6 +115,11 @@ func (h *NodeExecHub) DisconnectAll() {
 	h.logger.Printf("[NodeExecHub] All clients disconnected")
 }
 
+// SetNodeDebugPodOptions sets the options for debug pods created for node shells
+func (s *Server) SetNodeDebugPodOptions(opts k8s.NodeDebugPodOptions) {
+	s.nodeDebugPodOptions = opts
+}
+
 // handleNodeExecWebSocket handles WebSocket upgrade and node exec streaming
 func (s *Server) handleNodeExecWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Parse required query parameters
@@ -115,6 +130,27 @@ func (s *Server) handleNodeExecWebSocket(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// As for pod exec, the audit record is written once the outcome is known
+	actor := audit.ActorFromRequest(r)
+	record := audit.Record{
+		Operation: "exec.node",
+		Actor:     actor,
+		Resource:  audit.ResourceInfo{Type: "Node", Name: nodeName},
+	}
+	var recorded atomic.Bool
+	logAudit := func(result string) {
+		if recorded.CompareAndSwap(false, true) {
+			record.Result = result
+			s.audit.Log(record)
+		}
+	}
+
+	if _, ok := scopedNamespace(r); ok {
+		logAudit(audit.ResultDenied)
+		http.Error(w, "node shell is not allowed for namespace-scoped tokens", http.StatusForbidden)
+		return
+	}
+
 	// Upgrade connection
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -122,7 +158,9 @@ func (s *Server) handleNodeExecWebSocket(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	s.logger.Printf("[NodeExecStream] New connection for node: %s", nodeName)
+	sessionID := uuid.NewString()
+	record.SessionID = sessionID
+	s.logger.Printf("[NodeExecStream] New connection for node: %s (session: %s)", nodeName, sessionID)
 
 	// Create context for this exec session
 	ctx, cancel := context.WithCancel(context.Background())
@@ -134,7 +172,7 @@ func (s *Server) handleNodeExecWebSocket(w http.ResponseWriter, r *http.Request)
 	stdinReader, stdinWriter := io.Pipe()
 
 	// Get debug pod options
-	opts := k8s.DefaultNodeDebugPodOptions()
+	opts := s.nodeDebugPodOptions
 
 	// Create client
 	client := &NodeExecClient{
@@ -144,17 +182,50 @@ func (s *Server) handleNodeExecWebSocket(w http.ResponseWriter, r *http.Request)
 		hub:               s.nodeExecHub,
 		nodeName:          nodeName,
 		debugPodNamespace: opts.Namespace,
+		sessionID:         sessionID,
+		actor:             actor,
 		logger:            s.logger,
 		cancelFunc:        cancel,
 		sizeQueue:         sizeQueue,
-		stdinPipe:         stdinWriter,
+		stdinPipe:         newCountingWriter(stdinWriter),
+		startedAt:         time.Now(),
+		remoteAddr:        r.RemoteAddr,
+		output:            NewAuditRingBuffer(auditRingBufferSize),
+		binary:            binaryExecProtocol(r),
 	}
 
+	// Create stdout writer that sends to WebSocket, recording it when enabled
+	var stdoutWriter io.Writer = &nodeExecOutputWriter{
+		client:     client,
+		outputType: k8s.ExecMessageOutput,
+	}
+	recorder, err := s.newSessionRecorder(stdoutWriter, sessionID)
+	if err != nil {
+		// Sessions must not run unrecorded when recording is required
+		s.logger.Printf("[NodeExecStream] %v", err)
+		logAudit(audit.ResultDenied)
+		writeExecMessage(conn, k8s.ExecMessage{Type: k8s.ExecMessageError, Data: err.Error()}, client.binary)
+		conn.Close()
+		cancel()
+		return
+	}
+	if recorder != nil {
+		client.recorder = recorder
+		stdoutWriter = recorder
+	}
+	// Keep the output tail for audit records; the buffer never fails, so it goes first
+	stdoutWriter = io.MultiWriter(client.output, stdoutWriter)
+
 	s.nodeExecHub.register <- client
 
 	// Start debug pod lifecycle management
 	go func() {
 		defer cancel() // Always cancel context when this goroutine exits
+		if recorder != nil {
+			defer recorder.Close()
+		}
+		// A session that ends before the node shell started never ran a command
+		defer logAudit(audit.ResultDenied)
 
 		watcher := s.watcherProvider.GetWatcher()
 		if watcher == nil {
@@ -190,7 +261,7 @@ func (s *Server) handleNodeExecWebSocket(w http.ResponseWriter, r *http.Request)
 
 		// Ensure cleanup on exit
 		defer func() {
-			s.cleanupDebugPod(k8sClient, opts.Namespace, podName)
+			s.cleanupDebugPod(k8sClient, client, opts.Namespace, podName)
 		}()
 
 		// Send WAITING status
@@ -211,29 +282,26 @@ func (s *Server) handleNodeExecWebSocket(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		// Notify client that we're connected
-		if !client.safeSend(k8s.ExecMessage{
-			Type: k8s.ExecMessageConnected,
-			Data: "bash (node)",
-		}) {
-			return // Client disconnected
-		}
-
-		// Create stdout writer that sends to WebSocket
-		stdoutWriter := &nodeExecOutputWriter{
-			client:     client,
-			outputType: k8s.ExecMessageOutput,
-		}
-
-		// Start exec session with chroot
+		// Start exec session with chroot, notifying the client once a shell runs
 		err = k8sClient.ExecNodeDebugShell(
 			ctx,
 			opts.Namespace,
 			podName,
+			opts.Shell,
 			stdinReader,
 			stdoutWriter,
 			stdoutWriter, // stderr goes to same output
 			sizeQueue,
+			func(shell []string, protocol string) {
+				s.logger.Printf("[NodeExecStream] Started %v on node %s", shell, nodeName)
+				logAudit(audit.ResultAllowed)
+				client.safeSend(k8s.ExecMessage{
+					Type:      k8s.ExecMessageConnected,
+					Data:      strings.Join(shell, " ") + " (node)",
+					SessionID: sessionID,
+					Protocol:  protocol,
+				})
+			},
 		)
 
 		if err != nil {
@@ -257,12 +325,19 @@ func (s *Server) handleNodeExecWebSocket(w http.ResponseWriter, r *http.Request)
 }
 
 // cleanupDebugPod deletes the debug pod with a timeout
-func (s *Server) cleanupDebugPod(k8sClient *k8s.Client, namespace, podName string) {
+func (s *Server) cleanupDebugPod(k8sClient *k8s.Client, client *NodeExecClient, namespace, podName string) {
 	// Use a separate context with timeout for cleanup
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	err := k8sClient.DeleteNodeDebugPod(ctx, namespace, podName)
+	s.audit.Log(audit.Record{
+		Operation: "delete.pod",
+		Actor:     client.actor,
+		Resource:  audit.ResourceInfo{Type: "Pod", Namespace: namespace, Name: podName},
+		Result:    audit.ResultFor(err),
+		SessionID: client.sessionID,
+	})
 	if err != nil {
 		s.logger.Printf("[NodeExecStream] Failed to cleanup debug pod %s/%s: %v", namespace, podName, err)
 	} else {
@@ -298,6 +373,11 @@ func (w *nodeExecOutputWriter) Write(p []byte) (n int, err error) {
 	}
 }
 
+// GetOutputBuffer returns the last output bytes of the session
+func (c *NodeExecClient) GetOutputBuffer() []byte {
+	return c.output.Bytes()
+}
+
 // safeSend sends a message to the client, returns false if client is shutting down
 func (c *NodeExecClient) safeSend(msg k8s.ExecMessage) (sent bool) {
 	defer func() {
@@ -323,7 +403,7 @@ func (c *NodeExecClient) readPump() {
 	}()
 
 	for {
-		_, message, err := c.conn.ReadMessage()
+		messageType, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
 				c.logger.Printf("[NodeExecStream] Read error for node %s: %v", c.nodeName, err)
@@ -332,14 +412,14 @@ func (c *NodeExecClient) readPump() {
 		}
 
 		// Parse the message
-		var msg k8s.ExecMessage
-		if err := json.Unmarshal(message, &msg); err != nil {
+		msg, err := parseExecMessage(messageType, message)
+		if err != nil {
 			c.logger.Printf("[NodeExecStream] Invalid message for node %s: %v", c.nodeName, err)
 			continue
 		}
 
 		switch msg.Type {
-		case k8s.ExecMessageInput:
+		case k8s.ExecMessageInput, k8s.ExecMessagePaste:
 			// Write to stdin pipe
 			if c.stdinPipe != nil {
 				c.stdinPipe.Write([]byte(msg.Data))
@@ -350,6 +430,9 @@ func (c *NodeExecClient) readPump() {
 			if c.sizeQueue != nil {
 				c.sizeQueue.Send(msg.Cols, msg.Rows)
 			}
+			if c.recorder != nil {
+				c.recorder.Resize(msg.Cols, msg.Rows)
+			}
 		}
 	}
 }
@@ -359,7 +442,7 @@ func (c *NodeExecClient) writePump() {
 	defer c.conn.Close()
 
 	for message := range c.send {
-		if err := c.conn.WriteJSON(message); err != nil {
+		if err := writeExecMessage(c.conn, message, c.binary); err != nil {
 			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
 				c.logger.Printf("[NodeExecStream] Write error for node %s: %v", c.nodeName, err)
 			}
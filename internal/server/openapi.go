@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// openAPISpec builds an OpenAPI 3.0 document from the route table, so it can't drift
+// from the registered handlers. Responses are stubs: handler payloads aren't typed.
+func (s *Server) openAPISpec() map[string]interface{} {
+	paths := make(map[string]interface{})
+	for _, rt := range s.routes() {
+		parameters := []map[string]interface{}{}
+		for _, p := range rt.Params {
+			in := "query"
+			if p.InPath {
+				in = "path"
+			}
+			parameters = append(parameters, map[string]interface{}{
+				"name":        p.Name,
+				"in":          in,
+				"description": p.Description,
+				"required":    p.Required,
+				"schema":      map[string]string{"type": "string"},
+			})
+		}
+
+		responses := map[string]interface{}{
+			"200": map[string]string{"description": "OK"},
+		}
+		if rt.WebSocket {
+			responses = map[string]interface{}{
+				"101": map[string]string{"description": "Switching to the WebSocket protocol"},
+			}
+		}
+		if len(parameters) > 0 {
+			responses["400"] = map[string]string{"description": "Missing or invalid parameters"}
+		}
+
+		operation := map[string]interface{}{
+			"summary":    rt.Summary,
+			"parameters": parameters,
+			"responses":  responses,
+		}
+		switch rt.Access {
+		case accessScoped:
+			operation["security"] = []map[string][]string{{"namespaceJWT": {}}}
+			responses["401"] = map[string]string{"description": "Missing or invalid JWT (when -jwks-url is set)"}
+			responses["403"] = map[string]string{"description": "Not allowed for the token's namespace"}
+		case accessAdmin:
+			operation["security"] = []map[string][]string{{"adminToken": {}}}
+			responses["401"] = map[string]string{"description": "Missing or invalid token (when -auth-token is set)"}
+		case accessKey:
+			operation["security"] = []map[string][]string{{"adminKey": {}}}
+			responses["401"] = map[string]string{"description": "Missing or invalid X-Admin-Key"}
+			responses["403"] = map[string]string{"description": "-admin-key is not set"}
+		}
+
+		path := rt.Path
+		if legacy := legacyPath(rt.Path); legacy != "" && s.apiVersion == APIVersionLegacy {
+			path = legacy
+		}
+		paths[path] = map[string]interface{}{
+			strings.ToLower(rt.Method): operation,
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   "k8v API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"adminToken": map[string]string{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Token configured with -auth-token",
+				},
+				"adminKey": map[string]string{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-Admin-Key",
+					"description": "Key configured with -admin-key",
+				},
+				"namespaceJWT": map[string]string{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+					"description":  "JWT validated against -jwks-url, optionally scoped to a namespace",
+				},
+			},
+		},
+	}
+}
+
+// handleOpenAPI returns the OpenAPI specification of all endpoints
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.openAPISpec())
+}
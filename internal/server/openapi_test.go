@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestOpenAPISpecValidates(t *testing.T) {
+	t.Parallel()
+
+	for _, version := range []string{APIVersionV1, APIVersionLegacy} {
+		t.Run(version, func(t *testing.T) {
+			t.Parallel()
+			s := &Server{apiVersion: version}
+			data, err := json.Marshal(s.openAPISpec())
+			if err != nil {
+				t.Fatalf("marshal spec: %v", err)
+			}
+
+			doc, err := openapi3.NewLoader().LoadFromData(data)
+			if err != nil {
+				t.Fatalf("LoadFromData() error = %v", err)
+			}
+			if err := doc.Validate(context.Background()); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			// Every route is documented under the method its handler serves
+			for _, rt := range s.routes() {
+				path := rt.Path
+				if legacy := legacyPath(rt.Path); legacy != "" && version == APIVersionLegacy {
+					path = legacy
+				}
+				item := doc.Paths.Find(path)
+				if item == nil || item.GetOperation(rt.Method) == nil {
+					t.Errorf("%s %s is not documented", rt.Method, path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// ExecSessionOptions configures pod and node exec sessions
+type ExecSessionOptions struct {
+	RecordingEnabled bool   // Record every session as an asciinema v2 cast
+	RecordingDir     string // Directory holding <session ID>.cast files
+}
+
+// recordingExt is the file extension of asciinema casts
+const recordingExt = ".cast"
+
+// Default terminal size in the cast header when output arrives before the first resize
+const (
+	defaultRecordingCols = 80
+	defaultRecordingRows = 24
+)
+
+// AsciinemaRecorder wraps a session's output writer and records everything written
+// through it, plus terminal resizes, as an asciinema v2 cast
+// (https://docs.asciinema.org/manual/asciicast/v2/)
+type AsciinemaRecorder struct {
+	out io.Writer
+
+	mu      sync.Mutex
+	file    *os.File // nil once closed
+	started time.Time
+	header  bool   // whether the header line has been written
+	partial []byte // trailing bytes of an incomplete UTF-8 sequence
+}
+
+// NewAsciinemaRecorder creates the cast file at path and returns a recorder writing to out
+func NewAsciinemaRecorder(out io.Writer, path string) (*AsciinemaRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	return &AsciinemaRecorder{out: out, file: file, started: time.Now()}, nil
+}
+
+// Write records p as an output event and passes it to the wrapped writer
+func (r *AsciinemaRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	data := append(r.partial, p...)
+	// Hold back a multi-byte character split across writes, so it isn't recorded as U+FFFD
+	cut := len(data)
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				cut = len(data) - i
+			}
+			break
+		}
+	}
+	r.partial = append([]byte(nil), data[cut:]...)
+	r.recordLocked("o", string(data[:cut]), defaultRecordingCols, defaultRecordingRows)
+	r.mu.Unlock()
+
+	return r.out.Write(p)
+}
+
+// Resize records a terminal resize
+// The first resize before any output sets the size in the header instead
+func (r *AsciinemaRecorder) Resize(cols, rows uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.header {
+		r.writeHeaderLocked(int(cols), int(rows))
+		return
+	}
+	r.recordLocked("r", fmt.Sprintf("%dx%d", cols, rows), int(cols), int(rows))
+}
+
+// Close flushes any held-back bytes and closes the cast file
+func (r *AsciinemaRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	if len(r.partial) > 0 {
+		r.recordLocked("o", string(r.partial), defaultRecordingCols, defaultRecordingRows)
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// recordLocked appends an [elapsed, code, data] event line, writing the header first if
+// needed. Write errors are dropped: a full disk must not break the live session.
+// Callers must hold mu
+func (r *AsciinemaRecorder) recordLocked(code, data string, cols, rows int) {
+	if r.file == nil || data == "" {
+		return
+	}
+	if !r.header {
+		r.writeHeaderLocked(cols, rows)
+	}
+	line, _ := json.Marshal([]interface{}{time.Since(r.started).Seconds(), code, data})
+	r.file.Write(append(line, '\n'))
+}
+
+// writeHeaderLocked writes the cast header line
+// Callers must hold mu
+func (r *AsciinemaRecorder) writeHeaderLocked(cols, rows int) {
+	r.header = true
+	if r.file == nil {
+		return
+	}
+	line, _ := json.Marshal(map[string]interface{}{
+		"version":   2,
+		"width":     cols,
+		"height":    rows,
+		"timestamp": r.started.Unix(),
+		"env":       map[string]string{"TERM": "xterm-256color"},
+	})
+	r.file.Write(append(line, '\n'))
+}
+
+// SetExecSessionOptions sets the options applied to new pod and node exec sessions
+func (s *Server) SetExecSessionOptions(opts ExecSessionOptions) {
+	s.execSessionOptions = opts
+}
+
+// newSessionRecorder returns a recorder wrapping out for the session, or nil when
+// recording is disabled
+func (s *Server) newSessionRecorder(out io.Writer, sessionID string) (*AsciinemaRecorder, error) {
+	if !s.execSessionOptions.RecordingEnabled {
+		return nil, nil
+	}
+	return NewAsciinemaRecorder(out, filepath.Join(s.execSessionOptions.RecordingDir, sessionID+recordingExt))
+}
+
+// RecordingInfo describes a recorded exec session
+type RecordingInfo struct {
+	ID      string    `json:"id"` // Session ID, as sent in the CONNECTED message and audit records
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// handleExecRecordings lists the recorded exec sessions, most recent first
+func (s *Server) handleExecRecordings(w http.ResponseWriter, r *http.Request) {
+	if !s.execSessionOptions.RecordingEnabled {
+		http.Error(w, "exec session recording is disabled", http.StatusNotFound)
+		return
+	}
+
+	entries, err := os.ReadDir(s.execSessionOptions.RecordingDir)
+	if err != nil && !os.IsNotExist(err) {
+		http.Error(w, fmt.Sprintf("failed to list recordings: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	recordings := []RecordingInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), recordingExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed while listing
+		}
+		recordings = append(recordings, RecordingInfo{
+			ID:      strings.TrimSuffix(entry.Name(), recordingExt),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].ModTime.After(recordings[j].ModTime)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"recordings": recordings,
+		"count":      len(recordings),
+	})
+}
+
+// handleExecRecording streams the cast file of one recorded session
+func (s *Server) handleExecRecording(w http.ResponseWriter, r *http.Request) {
+	if !s.execSessionOptions.RecordingEnabled {
+		http.Error(w, "exec session recording is disabled", http.StatusNotFound)
+		return
+	}
+
+	// Session IDs are UUIDs; anything else could escape the recording directory
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		http.Error(w, "invalid recording id", http.StatusBadRequest)
+		return
+	}
+
+	path := filepath.Join(s.execSessionOptions.RecordingDir, id+recordingExt)
+	if _, err := os.Stat(path); err != nil {
+		http.Error(w, "recording not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	http.ServeFile(w, r, path)
+}
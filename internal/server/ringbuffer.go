@@ -0,0 +1,41 @@
+package server
+
+import "sync"
+
+// auditRingBufferSize is how many trailing output bytes of an exec session are kept
+const auditRingBufferSize = 1000
+
+// AuditRingBuffer keeps the last bytes written to it, e.g. the tail of a session's
+// output for audit records. It is safe for concurrent use and never fails a write.
+type AuditRingBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+// NewAuditRingBuffer creates a buffer holding the last size bytes written
+func NewAuditRingBuffer(size int) *AuditRingBuffer {
+	return &AuditRingBuffer{buf: make([]byte, 0, size), size: size}
+}
+
+// Write appends p, dropping the oldest bytes beyond the buffer size
+func (b *AuditRingBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(p) >= b.size {
+		b.buf = append(b.buf[:0], p[len(p)-b.size:]...)
+		return len(p), nil
+	}
+	if overflow := len(b.buf) + len(p) - b.size; overflow > 0 {
+		b.buf = append(b.buf[:0], b.buf[overflow:]...)
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// Bytes returns a copy of the buffered bytes, oldest first
+func (b *AuditRingBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf...)
+}
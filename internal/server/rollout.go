@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+// defaultRolloutStatusTimeout is how long a rollout status stream lasts without ?timeout
+const defaultRolloutStatusTimeout = 10 * time.Minute
+
+// handleRolloutStatus streams the progress of a Deployment rollout as server-sent events,
+// one "data: <RolloutStatus JSON>" event per change, until it completes or ?timeout
+// elapses (then a final "timeout" event is sent)
+func (s *Server) handleRolloutStatus(w http.ResponseWriter, r *http.Request) {
+	resourceType, namespace, name, err := parseResourceID(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if resourceType != "Deployment" {
+		http.Error(w, "rollout status is only available for Deployments", http.StatusBadRequest)
+		return
+	}
+	if !namespaceAllowed(r, namespace) {
+		http.Error(w, "namespace not allowed", http.StatusForbidden)
+		return
+	}
+
+	timeout := defaultRolloutStatusTimeout
+	if param := r.URL.Query().Get("timeout"); param != "" {
+		if timeout, err = time.ParseDuration(param); err != nil || timeout <= 0 {
+			http.Error(w, "invalid timeout", http.StatusBadRequest)
+			return
+		}
+	}
+
+	watcher := s.watcherProvider.GetWatcher()
+	status, err := watcher.GetRolloutStatus(namespace, name)
+	if errors.Is(err, k8s.ErrDeploymentsNotWatched) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("failed to get rollout status: %v", err), status)
+		return
+	}
+	if status.Complete {
+		http.Error(w, "no rollout in progress", http.StatusConflict)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	updates, err := watcher.WatchRollout(ctx, namespace, name)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to watch rollout: %v", err), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+	send := func(event string, data interface{}) {
+		if event != "" {
+			fmt.Fprintf(w, "event: %s\n", event)
+		}
+		payload, _ := json.Marshal(data)
+		fmt.Fprintf(w, "data: %s\n\n", payload)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	// Re-read once subscribed, in case the rollout finished in between
+	if current, err := watcher.GetRolloutStatus(namespace, name); err == nil {
+		status = current
+	}
+	send("", status)
+	if status.Complete {
+		return
+	}
+	for update := range updates {
+		send("", update)
+		if update.Complete {
+			return
+		}
+	}
+	// Closed by the timeout, the client leaving, or the Deployment being deleted
+	if ctx.Err() == context.DeadlineExceeded {
+		send("timeout", map[string]string{"error": fmt.Sprintf("rollout not complete after %s", timeout)})
+	}
+}
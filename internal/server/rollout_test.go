@@ -0,0 +1,28 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+func TestHandleRolloutStatusWithoutDeployments(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t, k8s.WatcherOptions{WatchResourceTypes: []string{"Pod"}})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/resource/Deployment:default:web/rollout/status", nil)
+	req.SetPathValue("id", "Deployment:default:web")
+	rec := httptest.NewRecorder()
+
+	s.handleRolloutStatus(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Deployment not watched") {
+		t.Errorf("body = %q, want it to say Deployments aren't watched", body)
+	}
+}
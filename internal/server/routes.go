@@ -0,0 +1,213 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/user/k8v/internal/metrics"
+)
+
+// routeAccess identifies the middleware protecting a route
+type routeAccess int
+
+const (
+	accessPublic routeAccess = iota // Logging only
+	accessScoped                    // Logging and JWT namespace scoping
+	accessAdmin                     // Logging and the -auth-token bearer token
+	accessKey                       // Logging and the -admin-key X-Admin-Key header
+	accessRaw                       // No middleware (scraped endpoints)
+)
+
+// routeParam documents a query parameter of a route, or a {name} path segment
+type routeParam struct {
+	Name        string
+	Description string
+	Required    bool
+	InPath      bool
+}
+
+// route is an HTTP endpoint registered by Start and documented by /api/v1/openapi.json
+type route struct {
+	Path      string
+	Method    string // Documented method; handlers enforce it themselves
+	Summary   string
+	Params    []routeParam
+	Access    routeAccess
+	WebSocket bool
+	Mutating  bool // Disabled by SetReadOnly
+	Handler   http.HandlerFunc
+}
+
+// namespaceParam is the optional namespace filter shared by most analysis endpoints
+var namespaceParam = routeParam{Name: "namespace", Description: `Namespace to query ("" or "all" for every namespace)`}
+
+// apiPrefix is the path prefix of the current API version
+const apiPrefix = "/api/v1"
+
+// API versions selectable with SetAPIVersion
+const (
+	APIVersionV1     = "v1"     // Document /api/v1 paths; the legacy paths log deprecation warnings
+	APIVersionLegacy = "legacy" // Document the legacy paths (/api/..., /ws...) without warnings
+)
+
+// legacyPath returns the unversioned path a route was served at before /api/v1, or ""
+// for routes that were never versioned (/health, /metrics)
+func legacyPath(path string) string {
+	rest, ok := strings.CutPrefix(path, apiPrefix)
+	if !ok {
+		return ""
+	}
+	if strings.HasPrefix(rest, "/ws") {
+		return rest
+	}
+	return "/api" + rest
+}
+
+// routes returns every endpoint served under /, except the static UI
+// Paths are the /api/v1 ones; Start also serves each at its legacyPath.
+func (s *Server) routes() []route {
+	return []route{
+		{Path: "/health", Method: http.MethodGet, Summary: "Server health, connected clients and resource counts", Access: accessPublic, Handler: s.handleHealth},
+		{Path: "/metrics", Method: http.MethodGet, Summary: "Prometheus metrics", Access: accessRaw, Handler: metrics.Handler},
+		{Path: "/api/v1/openapi.json", Method: http.MethodGet, Summary: "OpenAPI 3.0 specification of this API", Access: accessPublic, Handler: s.handleOpenAPI},
+		{Path: "/api/v1/namespaces", Method: http.MethodGet, Summary: "Namespaces with cached resources", Access: accessScoped, Handler: s.handleNamespaces},
+		{Path: "/api/v1/stats", Method: http.MethodGet, Summary: "Resource counts by type and health", Params: []routeParam{namespaceParam}, Access: accessScoped, Handler: s.handleStats},
+		{Path: "/api/v1/contexts", Method: http.MethodGet, Summary: "Kubeconfig contexts", Access: accessPublic, Handler: s.handleContexts},
+		{Path: "/api/v1/contexts/{name}", Method: http.MethodGet, Summary: "Kubeconfig context with its cluster's TLS settings (never credentials)", Params: []routeParam{
+			{Name: "name", Description: "Context name", Required: true, InPath: true},
+		}, Access: accessPublic, Handler: s.handleContextDetails},
+		{Path: "/api/v1/context/current", Method: http.MethodGet, Summary: "Current Kubernetes context", Access: accessPublic, Handler: s.handleCurrentContext},
+		{Path: "/api/v1/context/info", Method: http.MethodGet, Summary: "Cluster, API server URL and namespace of the current context (404 outside the kubeconfig)", Access: accessPublic, Handler: s.handleCurrentContextInfo},
+		{Path: "/api/v1/context/switch", Method: http.MethodPost, Summary: "Switch to another Kubernetes context", Params: []routeParam{
+			{Name: "context", Description: "Context name", Required: true},
+		}, Access: accessScoped, Mutating: true, Handler: s.handleSwitchContext},
+		{Path: "/api/v1/templates/{kind}", Method: http.MethodGet, Summary: "Annotated YAML starter for a kind, in the cluster's preferred API version", Params: []routeParam{
+			{Name: "kind", Description: "Resource kind, e.g. Deployment (case-insensitive)", Required: true, InPath: true},
+		}, Access: accessPublic, Handler: s.handleTemplate},
+		{Path: "/api/v1/sync/status", Method: http.MethodGet, Summary: "Informer cache sync status", Access: accessPublic, Handler: s.handleSyncStatus},
+		{Path: "/api/v1/resource", Method: http.MethodGet, Summary: "A single resource by ID", Params: []routeParam{
+			{Name: "id", Description: `Resource ID, "Type:namespace:name"`, Required: true},
+			{Name: "includeRelated", Description: `"true" to embed the directly related resources under "related"`},
+			{Name: "format", Description: `"yaml" for the resource's YAML only`},
+		}, Access: accessScoped, Handler: s.handleGetResource},
+		{Path: "/api/v1/resource/{id}/rollout/status", Method: http.MethodGet, Summary: "Server-sent events with a Deployment's rollout progress until it completes (409 if none is in progress, 503 if Deployments aren't watched)", Params: []routeParam{
+			{Name: "id", Description: `Deployment ID, "Deployment:namespace:name"`, Required: true, InPath: true},
+			{Name: "timeout", Description: `Stop streaming after this duration, e.g. "5m" (default 10m)`},
+		}, Access: accessScoped, Handler: s.handleRolloutStatus},
+		{Path: "/api/v1/resource/serviceaccount-permissions", Method: http.MethodGet, Summary: "RBAC rules granted to a ServiceAccount", Params: []routeParam{
+			{Name: "namespace", Description: "ServiceAccount namespace", Required: true},
+			{Name: "name", Description: "ServiceAccount name", Required: true},
+		}, Access: accessScoped, Handler: s.handleServiceAccountPermissions},
+		{Path: "/api/v1/resource/cluster-admin-bindings", Method: http.MethodGet, Summary: "Bindings granting the cluster-admin ClusterRole", Access: accessScoped, Handler: s.handleClusterAdminBindings},
+		{Path: "/api/v1/resource/wildcard-rbac", Method: http.MethodGet, Summary: "Roles with wildcard verbs, resources or API groups", Params: []routeParam{namespaceParam}, Access: accessScoped, Handler: s.handleWildcardRBAC},
+		{Path: "/api/v1/resource/pod-security-standards", Method: http.MethodGet, Summary: "Pods violating the baseline or restricted Pod Security Standards", Params: []routeParam{namespaceParam}, Access: accessScoped, Handler: s.handlePodSecurityStandards},
+		{Path: "/api/v1/resource/network-policy-coverage", Method: http.MethodGet, Summary: "Pods not selected by any NetworkPolicy", Params: []routeParam{namespaceParam}, Access: accessScoped, Handler: s.handleNetworkPolicyCoverage},
+		{Path: "/api/v1/resource/multi-zone-distribution", Method: http.MethodGet, Summary: "Spread of a Deployment's Pods across zones", Params: []routeParam{
+			{Name: "namespace", Description: "Deployment namespace", Required: true},
+			{Name: "deployment", Description: "Deployment name", Required: true},
+		}, Access: accessScoped, Handler: s.handleMultiZoneDistribution},
+		{Path: "/api/v1/resource/service-mesh-sidecar", Method: http.MethodGet, Summary: "Pods missing their service mesh sidecar", Params: []routeParam{namespaceParam}, Access: accessScoped, Handler: s.handleServiceMeshSidecar},
+		{Path: "/api/v1/resource/pod-labels-compliance", Method: http.MethodGet, Summary: "Workloads missing required labels", Params: []routeParam{
+			namespaceParam,
+			{Name: "required-labels", Description: "Comma-separated label keys (default: -required-labels)"},
+		}, Access: accessScoped, Handler: s.handlePodLabelsCompliance},
+		{Path: "/api/v1/resource/spot-instance-pods", Method: http.MethodGet, Summary: "Pods running on spot or preemptible Nodes", Params: []routeParam{namespaceParam}, Access: accessScoped, Handler: s.handleSpotInstancePods},
+		{Path: "/api/v1/resource/node-pool-distribution", Method: http.MethodGet, Summary: "Pods and workloads per node pool", Params: []routeParam{namespaceParam}, Access: accessScoped, Handler: s.handleNodePoolDistribution},
+		{Path: "/api/v1/resource/stale-secrets", Method: http.MethodGet, Summary: "Secrets not updated within a rotation window", Params: []routeParam{
+			namespaceParam,
+			{Name: "olderThan", Description: `Rotation window, e.g. "90d" or "720h" (default 90d)`},
+		}, Access: accessScoped, Handler: s.handleStaleSecrets},
+		{Path: "/api/v1/resource/pods-per-node", Method: http.MethodGet, Summary: "Pod count and requested capacity per Node", Params: []routeParam{
+			{Name: "node", Description: "Only report this Node"},
+		}, Access: accessScoped, Handler: s.handlePodsPerNode},
+		{Path: "/api/v1/resource/unmanaged-pods", Method: http.MethodGet, Summary: "Pods without ownerReferences", Params: []routeParam{namespaceParam}, Access: accessScoped, Handler: s.handleUnmanagedPods},
+		{Path: "/api/v1/certs/expiring", Method: http.MethodGet, Summary: "cert-manager Certificates expiring soon (requires -watch-all-apis)", Params: []routeParam{
+			namespaceParam,
+			{Name: "within", Description: `Expiry window, e.g. "30d" or "72h" (default 30d)`},
+		}, Access: accessScoped, Handler: s.handleExpiringCertificates},
+		{Path: "/api/v1/quota/pressure", Method: http.MethodGet, Summary: "ResourceQuota usage near its hard limits (requires -watch-resource-quotas)", Params: []routeParam{
+			namespaceParam,
+			{Name: "threshold", Description: "Minimum percentage of the hard limit used (default 75)"},
+		}, Access: accessScoped, Handler: s.handleQuotaPressure},
+		{Path: "/api/v1/snapshot/export", Method: http.MethodPost, Summary: "Download the cached resources as JSON, optionally with container logs", Params: []routeParam{
+			namespaceParam,
+			{Name: "includeLogs", Description: `"true" to add the logs of every container of the exported Pods`},
+			{Name: "tail", Description: "Log lines per container with includeLogs (default 100, 0 for all)"},
+		}, Access: accessScoped, Handler: s.handleSnapshotExport},
+		{Path: "/api/v1/resources/annotation", Method: http.MethodGet, Summary: "Resources carrying an annotation with a given value", Params: []routeParam{
+			{Name: "key", Description: "Annotation key, e.g. prometheus.io/scrape", Required: true},
+			{Name: "value", Description: `Annotation value, e.g. "true" (default: empty value)`},
+		}, Access: accessScoped, Handler: s.handleResourcesByAnnotation},
+		{Path: "/api/v1/resources/labels", Method: http.MethodPatch, Summary: "Add or remove labels and annotations on several resources", Access: accessScoped, Mutating: true, Handler: s.handleBatchLabels},
+		{Path: "/api/v1/audit/events", Method: http.MethodGet, Summary: "Most recent audit records", Access: accessAdmin, Handler: s.handleAuditEvents},
+		{Path: "/api/v1/cache/metrics", Method: http.MethodGet, Summary: "Resource cache size per type and Get/Set/Delete counters", Access: accessAdmin, Handler: s.handleCacheMetrics},
+		{Path: "/api/v1/cache/metrics/reset", Method: http.MethodPost, Summary: "Reset the resource cache counters", Access: accessAdmin, Handler: s.handleResetCacheMetrics},
+		{Path: "/api/v1/debug/relationships/validate", Method: http.MethodGet, Summary: "Relationships whose target resource isn't cached", Access: accessAdmin, Handler: s.handleValidateRelationships},
+		{Path: "/api/v1/exec/sessions", Method: http.MethodGet, Summary: "Open pod and node exec sessions", Access: accessAdmin, Handler: s.handleExecSessions},
+		{Path: "/api/v1/exec/sessions/count", Method: http.MethodGet, Summary: "Number of open exec sessions", Access: accessAdmin, Handler: s.handleExecSessionCount},
+		{Path: "/api/v1/exec/broadcast", Method: http.MethodPost, Summary: "Show a message (JSON body {\"message\": ...}) in every open pod and node shell", Access: accessKey, Handler: s.handleExecBroadcast},
+		{Path: "/api/v1/exec/recordings", Method: http.MethodGet, Summary: "Recorded exec sessions (when -record-exec-sessions is set)", Access: accessAdmin, Handler: s.handleExecRecordings},
+		{Path: "/api/v1/exec/recordings/{id}", Method: http.MethodGet, Summary: "Asciinema v2 cast of a recorded exec session", Params: []routeParam{
+			{Name: "id", Description: "Session ID from the CONNECTED message or audit records", Required: true, InPath: true},
+		}, Access: accessAdmin, Handler: s.handleExecRecording},
+		{Path: "/api/v1/ws", Method: http.MethodGet, Summary: "Resource snapshot and live resource events", Params: []routeParam{
+			namespaceParam,
+			{Name: "type", Description: "Only stream resources of this type"},
+			{Name: "since", Description: "Resume from this event generation, replaying only the missed events when still buffered"},
+		}, Access: accessScoped, WebSocket: true, Handler: s.handleWebSocket},
+		{Path: "/api/v1/ws/logs", Method: http.MethodGet, Summary: "Stream container logs", Params: []routeParam{
+			{Name: "namespace", Description: "Pod namespace", Required: true},
+			{Name: "pod", Description: "Pod name", Required: true},
+			{Name: "container", Description: "Container name", Required: true},
+			{Name: "tailLines", Description: "Start with the last N lines"},
+			{Name: "headLines", Description: "Stop after the first N lines"},
+			{Name: "sinceSeconds", Description: "Only lines newer than N seconds"},
+			{Name: "follow", Description: `"false" to stop at the end of the current logs`},
+			{Name: "previous", Description: `"true" for the previous container instance`},
+			{Name: "grep", Description: "Only lines containing this text"},
+			{Name: "grepInvert", Description: `"true" for only the lines not matching grep`},
+			{Name: "grepRegex", Description: `"true" to match grep as a regular expression`},
+		}, Access: accessScoped, WebSocket: true, Handler: s.handleLogsWebSocket},
+		{Path: "/api/v1/logs/stream", Method: http.MethodGet, Summary: "Stream container logs as server-sent events, one LogMessage per event", Params: []routeParam{
+			{Name: "namespace", Description: "Pod namespace", Required: true},
+			{Name: "pod", Description: "Pod name", Required: true},
+			{Name: "container", Description: "Container name", Required: true},
+			{Name: "tail", Description: "Start with the last N lines"},
+			{Name: "since", Description: "Only lines newer than this duration, e.g. 10m or 1d"},
+			{Name: "follow", Description: `"false" to stop at the end of the current logs`},
+			{Name: "grep", Description: "Only lines containing this text"},
+			{Name: "grepInvert", Description: `"true" for only the lines not matching grep`},
+			{Name: "grepRegex", Description: `"true" to match grep as a regular expression`},
+		}, Access: accessScoped, Handler: s.handleLogsSSE},
+		{Path: "/api/v1/ws/exec", Method: http.MethodGet, Summary: "Interactive shell in a container", Params: []routeParam{
+			{Name: "namespace", Description: "Pod namespace", Required: true},
+			{Name: "pod", Description: "Pod name", Required: true},
+			{Name: "container", Description: "Container name", Required: true},
+			{Name: "cmd", Description: "Command to run instead of the first available shell (repeat for arguments)"},
+			{Name: "protocol", Description: `"binary" for binary frames: a message type byte followed by the raw data, instead of JSON`},
+		}, Access: accessScoped, WebSocket: true, Mutating: true, Handler: s.handleExecWebSocket},
+		{Path: "/api/v1/ws/node-exec", Method: http.MethodGet, Summary: "Interactive shell on a Node through a debug Pod", Params: []routeParam{
+			{Name: "node", Description: "Node name", Required: true},
+			{Name: "protocol", Description: `"binary" for binary frames: a message type byte followed by the raw data, instead of JSON`},
+		}, Access: accessScoped, WebSocket: true, Mutating: true, Handler: s.handleNodeExecWebSocket},
+	}
+}
+
+// wrap applies the middleware for a route's access level
+func (s *Server) wrap(rt route) http.HandlerFunc {
+	if s.readOnly && rt.Mutating {
+		rt.Handler = readOnlyHandler(rt)
+	}
+	switch rt.Access {
+	case accessScoped:
+		return s.logger.LoggingMiddleware(s.scopeNamespace(rt.Handler))
+	case accessAdmin:
+		return s.logger.LoggingMiddleware(s.requireAuth(rt.Handler))
+	case accessKey:
+		return s.logger.LoggingMiddleware(s.requireAdminKey(rt.Handler))
+	case accessRaw:
+		return rt.Handler
+	default:
+		return s.logger.LoggingMiddleware(rt.Handler)
+	}
+}
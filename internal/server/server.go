@@ -2,21 +2,37 @@ package server
 
 import (
 	"embed"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 
+	"github.com/user/k8v/internal/audit"
 	"github.com/user/k8v/internal/k8s"
 )
 
 //go:embed static/*
 var staticFiles embed.FS
 
+// ErrContextSwitchInProgress is returned by WatcherProvider.SwitchContext while another
+// switch hasn't finished
+var ErrContextSwitchInProgress = errors.New("context switch in progress")
+
+// ErrContextNotFound is returned by GetCurrentContextInfo when the current context isn't
+// in the kubeconfig (e.g. with in-cluster credentials)
+var ErrContextNotFound = errors.New("context not found in kubeconfig")
+
 // WatcherProvider provides access to the current watcher
 type WatcherProvider interface {
 	GetWatcher() *k8s.Watcher
 	GetCurrentContext() string
-	SwitchContext(context string) error
-	GetSyncStatus() interface{} // Returns app.SyncStatus or compatible struct
+	// GetCurrentContextInfo returns ErrContextNotFound outside the kubeconfig
+	GetCurrentContextInfo() (k8s.Context, error)
+	SwitchContext(context string) error    // ErrContextSwitchInProgress during another switch
+	GetSyncStatus() interface{}            // Returns app.SyncStatus or compatible struct
+	AddEventFilter(filter k8s.EventFilter) // Applies to the current and future watchers
 }
 
 // Server represents the HTTP server
@@ -28,6 +44,24 @@ type Server struct {
 	execHub         *ExecHub
 	nodeExecHub     *NodeExecHub
 	logger          *Logger
+	audit           *audit.AuditLogger  // nil disables audit logging
+	authToken       string              // bearer token for admin endpoints ("" = no auth)
+	adminKey        string              // X-Admin-Key for admin-key endpoints ("" = disabled)
+	namespaceScoper *JWTNamespaceScoper // nil disables JWT namespace scoping
+	dryRun          bool                // run mutating API calls with DryRun=All
+	readOnly        bool                // reject mutating endpoints, see SetReadOnly
+	staticDir       string              // serves the UI from this directory ("" = embedded assets)
+	apiVersion      string              // APIVersionV1 or APIVersionLegacy
+	deprecatedSeen  sync.Map            // legacy paths already warned about
+	mux             *http.ServeMux      // serves every route, instead of http.DefaultServeMux
+	registerOnce    sync.Once           // registers the routes on mux, see Handler
+	customRoutes    []customRoute       // added with Handle, registered by Handler
+	listener        net.Listener        // bound by Listen
+	portFirst       int                 // SetPortRange bounds, 0 to use port
+	portLast        int
+
+	nodeDebugPodOptions k8s.NodeDebugPodOptions // debug pods created for node shells
+	execSessionOptions  ExecSessionOptions      // recording of pod and node shells
 }
 
 // For backward compatibility - direct watcher wrapper
@@ -43,6 +77,14 @@ func (d *directWatcherProvider) GetCurrentContext() string {
 	return "unknown"
 }
 
+func (d *directWatcherProvider) GetCurrentContextInfo() (k8s.Context, error) {
+	return k8s.Context{}, ErrContextNotFound
+}
+
+func (d *directWatcherProvider) AddEventFilter(filter k8s.EventFilter) {
+	d.watcher.AddEventFilter(filter)
+}
+
 func (d *directWatcherProvider) SwitchContext(context string) error {
 	return fmt.Errorf("context switching not supported with direct watcher")
 }
@@ -76,9 +118,36 @@ func NewServerWithProvider(port int, provider WatcherProvider, hub *Hub, logHub
 		execHub:         execHub,
 		nodeExecHub:     nodeExecHub,
 		logger:          logger,
+		apiVersion:      APIVersionV1,
+		mux:             http.NewServeMux(),
+
+		nodeDebugPodOptions: k8s.DefaultNodeDebugPodOptions(),
 	}, nil
 }
 
+// SetStaticDir serves the UI from a local directory instead of the embedded assets
+// ("" restores the embedded ones), e.g. a frontend build during development
+func (s *Server) SetStaticDir(dir string) {
+	s.staticDir = dir
+}
+
+// SetAPIVersion selects the API version documented by the OpenAPI spec
+// APIVersionLegacy documents the unversioned paths and stops their deprecation warnings;
+// /api/v1 stays registered either way, as the bundled UI uses it
+func (s *Server) SetAPIVersion(version string) error {
+	switch version {
+	case APIVersionV1, APIVersionLegacy:
+		s.apiVersion = version
+		return nil
+	}
+	return fmt.Errorf("unknown API version %q (want %q or %q)", version, APIVersionV1, APIVersionLegacy)
+}
+
+// SetAuditLogger sets the audit logger used for exec and delete operations
+func (s *Server) SetAuditLogger(auditLogger *audit.AuditLogger) {
+	s.audit = auditLogger
+}
+
 // Close gracefully shuts down the server
 func (s *Server) Close() error {
 	if s.logger != nil {
@@ -87,33 +156,132 @@ func (s *Server) Close() error {
 	return nil
 }
 
+// CustomRouteOptions sets the access of a route added with Handle. The zero value is the
+// most restrictive: the route is an admin endpoint, disabled in read-only mode.
+type CustomRouteOptions struct {
+	// Public serves the route without the -auth-token bearer token. When -jwks-url is set
+	// a valid JWT is still required, but the handler must enforce its namespace claim
+	// itself: nothing restricts what a custom route returns.
+	Public bool
+	// ReadOnly marks a route that never modifies the cluster, so it keeps being served
+	// in read-only mode
+	ReadOnly bool
+}
+
+// customRoute is a route added with Handle
+type customRoute struct {
+	pattern string
+	handler http.HandlerFunc
+	options CustomRouteOptions
+}
+
+// Handle adds a route served next to the built-in ones, with the access set by options
+// Must be called before Handler or Start; a pattern conflicting with a built-in route
+// panics there.
+func (s *Server) Handle(pattern string, handler http.HandlerFunc, options CustomRouteOptions) {
+	s.customRoutes = append(s.customRoutes, customRoute{pattern: pattern, handler: handler, options: options})
+}
+
+// Handler returns the handler serving every route, registering them on first use
+// Start serves it; tests serve it with httptest.NewServer.
+func (s *Server) Handler() http.Handler {
+	s.registerOnce.Do(func() {
+		// The static UI is served for every unmatched path
+		s.mux.HandleFunc("/", s.logger.LoggingMiddleware(s.handleIndex))
+		for _, rt := range s.routes() {
+			s.mux.HandleFunc(rt.Path, s.wrap(rt))
+		}
+		for _, rt := range s.deprecatedRoutes() {
+			handler := s.wrap(rt)
+			if s.apiVersion != APIVersionLegacy {
+				handler = s.warnDeprecated(rt.Path, handler)
+			}
+			s.mux.HandleFunc(rt.Path, handler)
+		}
+		s.registerCustomRoutes(s.mux)
+	})
+	return s.mux
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
-	// Set up HTTP routes with logging middleware
-	http.HandleFunc("/", s.logger.LoggingMiddleware(s.handleIndex))
-	http.HandleFunc("/health", s.logger.LoggingMiddleware(s.handleHealth))
-	http.HandleFunc("/api/namespaces", s.logger.LoggingMiddleware(s.handleNamespaces))
-	http.HandleFunc("/api/stats", s.logger.LoggingMiddleware(s.handleStats))
-	http.HandleFunc("/api/contexts", s.logger.LoggingMiddleware(s.handleContexts))
-	http.HandleFunc("/api/context/current", s.logger.LoggingMiddleware(s.handleCurrentContext))
-	http.HandleFunc("/api/context/switch", s.logger.LoggingMiddleware(s.handleSwitchContext))
-	http.HandleFunc("/api/sync/status", s.logger.LoggingMiddleware(s.handleSyncStatus))
-	http.HandleFunc("/api/resource", s.logger.LoggingMiddleware(s.handleGetResource))
-	http.HandleFunc("/ws", s.logger.LoggingMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		s.handleWebSocket(w, r)
-	}))
-	http.HandleFunc("/ws/logs", s.logger.LoggingMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		s.handleLogsWebSocket(w, r)
-	}))
-	http.HandleFunc("/ws/exec", s.logger.LoggingMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		s.handleExecWebSocket(w, r)
-	}))
-	http.HandleFunc("/ws/node-exec", s.logger.LoggingMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		s.handleNodeExecWebSocket(w, r)
-	}))
-
-	addr := fmt.Sprintf(":%d", s.port)
-	s.logger.Printf("Starting server on http://localhost%s", addr)
-
-	return http.ListenAndServe(addr, nil)
+	handler := s.Handler()
+
+	port, err := s.Listen()
+	if err != nil {
+		return err
+	}
+	s.logger.Printf("Starting server on http://localhost:%d", port)
+
+	return http.Serve(s.listener, handler)
+}
+
+// SetPortRange makes Listen use the first free port from first to last instead of the
+// configured port, so several instances can share a host
+func (s *Server) SetPortRange(first, last int) {
+	s.portFirst, s.portLast = first, last
+}
+
+// Listen binds the server's port and returns the bound port, which differs from the
+// configured one for port 0 (any free port) or with SetPortRange. Start calls it if
+// needed; call it first to learn the port before serving.
+func (s *Server) Listen() (int, error) {
+	if s.listener == nil {
+		listener, err := s.listen()
+		if err != nil {
+			return 0, err
+		}
+		s.listener = listener
+	}
+	return s.listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// listen binds the configured port or the first free port of the port range
+func (s *Server) listen() (net.Listener, error) {
+	if s.portLast == 0 {
+		return net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	}
+	for port := s.portFirst; port <= s.portLast; port++ {
+		if listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port)); err == nil {
+			return listener, nil
+		}
+	}
+	return nil, fmt.Errorf("no free port in range %d-%d", s.portFirst, s.portLast)
+}
+
+// registerCustomRoutes registers the routes added with Handle, behind the middleware of
+// their access level like built-in routes
+func (s *Server) registerCustomRoutes(mux *http.ServeMux) {
+	for _, custom := range s.customRoutes {
+		rt := route{Path: custom.pattern, Access: accessAdmin, Mutating: !custom.options.ReadOnly, Handler: custom.handler}
+		if custom.options.Public {
+			rt.Access = accessScoped
+		}
+		mux.HandleFunc(rt.Path, s.wrap(rt))
+	}
+}
+
+// deprecatedRoutes returns the routes at their legacy unversioned paths (/api/..., /ws...),
+// still served while clients move to /api/v1
+func (s *Server) deprecatedRoutes() []route {
+	var legacy []route
+	for _, rt := range s.routes() {
+		path := legacyPath(rt.Path)
+		if path == "" {
+			continue
+		}
+		rt.Path = path
+		legacy = append(legacy, rt)
+	}
+	return legacy
+}
+
+// warnDeprecated logs a warning the first time a legacy path is requested
+func (s *Server) warnDeprecated(path string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, seen := s.deprecatedSeen.LoadOrStore(path, true); !seen {
+			s.logger.Printf("WARNING: %s is deprecated, use %s%s", path, apiPrefix, strings.TrimPrefix(path, "/api"))
+		}
+		next(w, r)
+	}
 }
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/user/k8v/internal/k8s"
+)
+
+// newTestServer returns a server over a watcher whose informers aren't started
+func newTestServer(t *testing.T, options k8s.WatcherOptions) *Server {
+	t.Helper()
+	return newTestServerWithClientset(t, fake.NewSimpleClientset(), options)
+}
+
+// newTestServerWithClientset returns a test server whose client uses clientset
+func newTestServerWithClientset(t *testing.T, clientset kubernetes.Interface, options k8s.WatcherOptions) *Server {
+	t.Helper()
+	cache := k8s.NewResourceCache()
+	t.Cleanup(cache.Close)
+	client := k8s.NewClientWithFake(clientset)
+	client.SetLogger(log.New(io.Discard, "", 0))
+	watcher := k8s.NewWatcherWithOptions(client, cache, nil, options)
+	return &Server{
+		watcherProvider: &directWatcherProvider{watcher: watcher},
+		logger:          newTestLogger(),
+		apiVersion:      APIVersionV1,
+		mux:             http.NewServeMux(),
+	}
+}
+
+func TestCustomRouteAccess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		options  CustomRouteOptions
+		readOnly bool
+		token    string
+		want     int
+	}{
+		{"admin route without a token", CustomRouteOptions{}, false, "", http.StatusUnauthorized},
+		{"admin route with the token", CustomRouteOptions{}, false, "secret", http.StatusOK},
+		{"public route without a token", CustomRouteOptions{Public: true}, false, "", http.StatusOK},
+		{"route in read-only mode", CustomRouteOptions{Public: true}, true, "", http.StatusMethodNotAllowed},
+		{"read-only route in read-only mode", CustomRouteOptions{Public: true, ReadOnly: true}, true, "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newTestServer(t, k8s.WatcherOptions{})
+			s.authToken = "secret"
+			s.SetReadOnly(tt.readOnly)
+			s.Handle("/api/v1/plugins/hello", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("hello"))
+			}, tt.options)
+			server := httptest.NewServer(s.Handler())
+			defer server.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/plugins/hello", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/user/k8v/internal/k8s"
+	"github.com/user/k8v/internal/types"
+)
+
+const (
+	// defaultExportLogTail is the number of lines per container exported with ?includeLogs
+	defaultExportLogTail = 100
+
+	// exportLogConcurrency caps the Pods whose logs are fetched at once, so a large
+	// namespace doesn't open hundreds of API server requests together
+	exportLogConcurrency = 8
+
+	// exportLogTimeout bounds fetching every Pod's logs; Pods not read by then are
+	// reported in LogErrors and the export is returned without their logs
+	exportLogTimeout = 60 * time.Second
+)
+
+// SnapshotExport is the cluster state exported by /api/v1/snapshot/export, for offline analysis
+type SnapshotExport struct {
+	Context    string            `json:"context"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Resources  []*types.Resource `json:"resources"`
+
+	// Logs holds the container logs of every exported Pod with ?includeLogs=true
+	Logs      map[string]map[string]string `json:"logs,omitempty"`      // "namespace/pod" -> container -> lines
+	LogErrors map[string]string            `json:"logErrors,omitempty"` // "namespace/pod" -> why logs are missing
+}
+
+// handleSnapshotExport exports the cached resources of a namespace (all by default),
+// optionally with the last ?tail lines of every container's logs
+func (s *Server) handleSnapshotExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+	includeLogs := r.URL.Query().Get("includeLogs") == "true"
+	tail := int64(defaultExportLogTail)
+	if value := r.URL.Query().Get("tail"); value != "" {
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || val < 0 {
+			http.Error(w, "invalid tail", http.StatusBadRequest)
+			return
+		}
+		tail = val
+	}
+
+	watcher := s.watcherProvider.GetWatcher()
+	export := SnapshotExport{
+		Context:    s.watcherProvider.GetCurrentContext(),
+		ExportedAt: time.Now().UTC(),
+		Resources:  []*types.Resource{},
+	}
+	for _, event := range watcher.GetSnapshotFiltered(namespace) {
+		export.Resources = append(export.Resources, event.Resource)
+	}
+	// Exports leave the process, so Secrets go out without their data
+	export.Resources = k8s.RedactSecrets(export.Resources)
+
+	if includeLogs {
+		export.Logs, export.LogErrors = exportPodLogs(r.Context(), watcher.GetClient(), export.Resources, tail)
+		if r.Context().Err() != nil {
+			return // Client gave up
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="k8v-snapshot.json"`)
+	json.NewEncoder(w).Encode(export)
+}
+
+// exportPodLogs fetches the logs of every Pod among resources, exportLogConcurrency
+// Pods at a time and within exportLogTimeout overall. It returns the logs and the
+// reasons logs are missing, both keyed by "namespace/pod".
+func exportPodLogs(ctx context.Context, client *k8s.Client, resources []*types.Resource, tail int64) (map[string]map[string]string, map[string]string) {
+	ctx, cancel := context.WithTimeout(ctx, exportLogTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	logs := make(map[string]map[string]string)
+	logErrors := make(map[string]string)
+	slots := make(chan struct{}, exportLogConcurrency)
+	for _, resource := range resources {
+		if resource.Type != "Pod" {
+			continue
+		}
+		key := resource.Namespace + "/" + resource.Name
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			logErrors[key] = ctx.Err().Error()
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(namespace, name string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			podLogs, err := client.ListAllPodLogs(ctx, namespace, name, tail)
+			mu.Lock()
+			defer mu.Unlock()
+			if len(podLogs) > 0 {
+				logs[key] = podLogs
+			}
+			if err != nil {
+				logErrors[key] = err.Error()
+			}
+		}(resource.Namespace, resource.Name)
+	}
+	wg.Wait()
+	return logs, logErrors
+}
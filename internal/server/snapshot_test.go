@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/user/k8v/internal/k8s"
+	"github.com/user/k8v/internal/types"
+)
+
+func TestSnapshotExport(t *testing.T) {
+	t.Parallel()
+
+	const pods = 3 * exportLogConcurrency
+	var objects []runtime.Object
+	for i := 0; i < pods; i++ {
+		objects = append(objects, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("web-%d", i), Namespace: "default"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+		})
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+
+	// Track the Pods whose logs are being fetched at once
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	clientset.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return false, nil, nil
+	})
+
+	s := newTestServerWithClientset(t, clientset, k8s.WatcherOptions{})
+	cache := k8s.NewResourceCache()
+	defer cache.Close()
+	resources := []*types.Resource{k8s.TransformSecret(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "db",
+			Namespace:   "default",
+			Annotations: map[string]string{v1.LastAppliedConfigAnnotation: `{"data":{"password":"aHVudGVyMg=="}}`},
+		},
+		Data: map[string][]byte{"password": []byte("hunter2")},
+	}, cache)}
+	for _, object := range objects {
+		resources = append(resources, k8s.TransformPod(object.(*v1.Pod), cache))
+	}
+	s.watcherProvider.GetWatcher().LoadSnapshot(resources)
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/snapshot/export?includeLogs=true", "", nil)
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var export SnapshotExport
+	if err := json.NewDecoder(resp.Body).Decode(&export); err != nil {
+		t.Fatalf("decoding the export: %v", err)
+	}
+
+	if len(export.Resources) != pods+1 {
+		t.Errorf("exported %d resources, want %d", len(export.Resources), pods+1)
+	}
+	for _, resource := range export.Resources {
+		if resource.Type != "Secret" {
+			continue
+		}
+		if resource.YAML != "" || resource.Annotations[v1.LastAppliedConfigAnnotation] != "" {
+			t.Errorf("exported Secret keeps its data: YAML %q, annotations %v", resource.YAML, resource.Annotations)
+		}
+	}
+	if len(export.Logs) != pods || len(export.LogErrors) != 0 {
+		t.Errorf("exported logs of %d Pods with errors %v, want %d Pods without errors", len(export.Logs), export.LogErrors, pods)
+	}
+	if got := export.Logs["default/web-0"]["app"]; got != "fake logs" {
+		t.Errorf("logs of default/web-0 = %q, want the fake clientset's logs", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if maxInFlight > exportLogConcurrency {
+		t.Errorf("fetched logs of %d Pods at once, want at most %d", maxInFlight, exportLogConcurrency)
+	}
+}
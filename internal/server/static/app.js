@@ -76,7 +76,10 @@ class App {
     const loadingText = loadingState?.querySelector('.loading-text');
     const loadingSubtext = document.getElementById('loading-subtext');
 
-    if (this.state.sync.syncing) {
+    if (this.state.sync.syncing && this.state.sync.synced) {
+      // A watch failed after the initial sync: keep showing the cached resources
+      document.getElementById('connection-status').textContent = 'Reconnecting...';
+    } else if (this.state.sync.syncing) {
       if (loadingState) loadingState.style.display = 'flex';
       if (resourceTable) resourceTable.style.display = 'none';
       if (loadingText) loadingText.textContent = 'Syncing informer caches...';
@@ -84,6 +87,9 @@ class App {
     } else if (this.state.sync.synced) {
       if (loadingState) loadingState.style.display = 'none';
       if (resourceTable) resourceTable.style.display = 'block';
+      if (document.getElementById('connection-status').textContent === 'Reconnecting...') {
+        this.showConnectedStatus();
+      }
     } else if (this.state.sync.error) {
       if (loadingState) loadingState.style.display = 'flex';
       if (loadingText) loadingText.textContent = 'Sync failed';
@@ -102,9 +108,13 @@ class App {
   }
 
   onSocketOpen() {
+    this.showConnectedStatus();
+    this.state.ws.manual = false;
+  }
+
+  showConnectedStatus() {
     const nsLabel = this.state.filters.namespace === 'all' ? 'All Namespaces' : this.state.filters.namespace;
     document.getElementById('connection-status').textContent = `Connected (${nsLabel})`;
-    this.state.ws.manual = false;
   }
 
   onSocketError() {
@@ -649,14 +659,14 @@ class App {
       const counts = await response.json();
 
       document.getElementById('stat-total').textContent = counts.total || 0;
-      document.getElementById('stat-pod').textContent = counts['Pod'] || 0;
-      document.getElementById('stat-deployment').textContent = counts['Deployment'] || 0;
-      document.getElementById('stat-replicaset').textContent = counts['ReplicaSet'] || 0;
-      document.getElementById('stat-service').textContent = counts['Service'] || 0;
-      document.getElementById('stat-ingress').textContent = counts['Ingress'] || 0;
-      document.getElementById('stat-configmap').textContent = counts['ConfigMap'] || 0;
-      document.getElementById('stat-secret').textContent = counts['Secret'] || 0;
-      document.getElementById('stat-node').textContent = counts['Node'] || 0;
+      document.getElementById('stat-pod').textContent = counts.byType?.['Pod'] || 0;
+      document.getElementById('stat-deployment').textContent = counts.byType?.['Deployment'] || 0;
+      document.getElementById('stat-replicaset').textContent = counts.byType?.['ReplicaSet'] || 0;
+      document.getElementById('stat-service').textContent = counts.byType?.['Service'] || 0;
+      document.getElementById('stat-ingress').textContent = counts.byType?.['Ingress'] || 0;
+      document.getElementById('stat-configmap').textContent = counts.byType?.['ConfigMap'] || 0;
+      document.getElementById('stat-secret').textContent = counts.byType?.['Secret'] || 0;
+      document.getElementById('stat-node').textContent = counts.byType?.['Node'] || 0;
       document.getElementById('resource-count').textContent = `${counts.total || 0} resources`;
 
       console.log('[Stats] Loaded counts:', counts);
@@ -795,7 +805,7 @@ class App {
     el.innerHTML = '';
     for (const e of this.state.events) {
       const item = document.createElement('div');
-      item.className = 'event-item ' + (e.type === 'MODIFIED' ? 'warning' : e.type === 'DELETED' ? 'error' : '');
+      item.className = 'event-item ' + (e.type === 'MODIFIED' || e.type === 'QUOTA_PRESSURE' ? 'warning' : e.type === 'DELETED' ? 'error' : '');
 
       const header = document.createElement('div');
       header.className = 'event-header';
@@ -814,6 +824,7 @@ class App {
       const msg = document.createElement('div');
       msg.className = 'event-message';
       msg.textContent = `${e.resource.type} › ${e.resource.namespace || 'default'} › ${e.resource.name}`;
+      if (e.type === 'QUOTA_PRESSURE') msg.textContent += ` › ${e.resource.status.message}`;
 
       item.appendChild(header);
       item.appendChild(msg);
@@ -823,8 +834,12 @@ class App {
 
   handleResourceEvent(event) {
     const resourceId = event.resource.id;
+    // Quota pressure only describes a quota, it isn't a resource to show
+    const isResourceChange = event.type !== 'QUOTA_PRESSURE';
 
-    if (event.type === 'DELETED') {
+    if (!isResourceChange) {
+      // Listed in the events panel only
+    } else if (event.type === 'DELETED') {
       this.state.resources.delete(resourceId);
     } else {
       this.state.resources.set(resourceId, event.resource);
@@ -841,7 +856,7 @@ class App {
     // Only render if snapshot is complete (incremental updates)
     // During snapshot, we buffer resources without rendering for speed
     if (this.state.snapshotComplete) {
-      if (this.tableView) {
+      if (this.tableView && isResourceChange) {
         this.tableView.updateResource(resourceId, event.type);
       }
       this.renderEvents();
@@ -967,6 +982,10 @@ class App {
       params.append('sinceSeconds', modeOpts.sinceSeconds.toString());
     }
 
+    if (modeOpts.previous) {
+      params.append('previous', 'true');
+    }
+
     const wsUrl = `${wsProtocol}//${window.location.host}${API_PATHS.logsWs}?${params.toString()}`;
 
     this.state.log.socket = new WebSocket(wsUrl);
@@ -981,6 +1000,10 @@ class App {
       const message = JSON.parse(event.data);
       if (message.type === 'LOG_LINE') {
         this.appendLogLine(message.line);
+      } else if (message.type === 'LOG_PREVIOUS_INFO') {
+        const info = message.previous;
+        const reason = info.reason ? ` (${info.reason})` : '';
+        this.appendLogLine(`[Previous container exited with code ${info.exitCode}${reason} at ${info.finishedAt}, restarts: ${info.restartCount}]\n`);
       } else if (message.type === 'LOG_END') {
         this.appendLogLine('\n[End of logs]');
         console.log('[LogStream] Ended:', message.reason);
@@ -1169,7 +1192,7 @@ class App {
     switch (message.type) {
       case 'CONNECTED':
         this.state.exec.connected = true;
-        this.updateExecStatus('connected', `Shell: ${message.data}`);
+        this.updateExecStatus('connected', `Shell: ${message.data}${message.protocol ? ` (${message.protocol})` : ''}`);
         this.state.exec.terminalInstance?.focus();
         break;
       case 'OUTPUT':
@@ -1188,8 +1211,10 @@ class App {
 
   sendExecInput(data) {
     if (this.state.exec.socket && this.state.exec.socket.readyState === WebSocket.OPEN) {
+      // xterm delivers a paste as one chunk; keystrokes are a character or an escape sequence
+      const pasted = data.length > 1 && !data.startsWith('\x1b');
       this.state.exec.socket.send(JSON.stringify({
-        type: 'INPUT',
+        type: pasted ? 'PASTE' : 'INPUT',
         data: data,
       }));
     }
@@ -1384,7 +1409,7 @@ class App {
       case 'CONNECTED':
         this.state.nodeExec.connected = true;
         this.state.nodeExec.status = 'connected';
-        this.updateExecStatus('connected', `Shell: ${message.data}`);
+        this.updateExecStatus('connected', `Shell: ${message.data}${message.protocol ? ` (${message.protocol})` : ''}`);
         this.state.nodeExec.terminalInstance?.focus();
         break;
       case 'OUTPUT':
@@ -1753,7 +1778,7 @@ class App {
 
   async fetchCurrentContext() {
     try {
-      const response = await fetch('/api/context/current');
+      const response = await fetch(API_PATHS.currentContext);
       const data = await response.json();
       const currentContext = data.context;
 
@@ -1763,14 +1788,31 @@ class App {
       if (this.contextDropdown) {
         this.contextDropdown.setValue(currentContext);
       }
+      this.fetchContextInfo();
     } catch (err) {
       console.error('[App] Failed to fetch current context:', err);
     }
   }
 
+  // Show the cluster's API server URL on hover, to tell apart similarly named contexts
+  async fetchContextInfo() {
+    if (!this.contextDropdown) return;
+    try {
+      const response = await fetch(API_PATHS.contextInfo);
+      if (!response.ok) {
+        this.contextDropdown.title = ''; // In-cluster credentials have no kubeconfig entry
+        return;
+      }
+      const info = await response.json();
+      this.contextDropdown.title = `${info.cluster} (${info.server})`;
+    } catch (err) {
+      console.error('[App] Failed to fetch context info:', err);
+    }
+  }
+
   async fetchAndDisplayContexts() {
     try {
-      const response = await fetch('/api/contexts');
+      const response = await fetch(API_PATHS.contexts);
       const data = await response.json();
       const contexts = data.contexts || [];
 
@@ -1797,10 +1839,13 @@ class App {
     console.log(`[App] Switching to context: ${newContext}`);
 
     try {
-      const response = await fetch(`/api/context/switch?context=${encodeURIComponent(newContext)}`, {
+      const response = await fetch(`${API_PATHS.switchContext}?context=${encodeURIComponent(newContext)}`, {
         method: 'POST'
       });
 
+      if (response.status === 409) {
+        throw new Error('Failed to switch context: another context switch is in progress');
+      }
       if (!response.ok) {
         throw new Error(`Failed to switch context: ${response.statusText}`);
       }
@@ -1816,6 +1861,7 @@ class App {
       if (this.contextDropdown) {
         this.contextDropdown.setValue(newContext);
       }
+      this.fetchContextInfo();
 
       // Reset namespace filter to "all" for new context
       this.state.filters.namespace = 'all';
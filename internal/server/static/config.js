@@ -1,4 +1,4 @@
-export const RESOURCE_TYPES = ['Pod', 'Deployment', 'ReplicaSet', 'Service', 'Ingress', 'ConfigMap', 'Secret', 'Node'];
+export const RESOURCE_TYPES = ['Pod', 'Deployment', 'ReplicaSet', 'Service', 'Ingress', 'ConfigMap', 'Secret', 'Node', 'StorageClass', 'PersistentVolumeClaim', 'PodDisruptionBudget', 'HelmRelease'];
 
 export const LOCAL_STORAGE_KEYS = {
   namespace: 'k8v-namespace',
@@ -17,16 +17,30 @@ export const RELATIONSHIP_TYPES = [
   { key: 'routedBy', label: 'Routed By' },
   { key: 'scheduledOn', label: 'Scheduled On' },
   { key: 'schedules', label: 'Schedules' },
+  { key: 'protects', label: 'Protects' },
+  { key: 'protectedBy', label: 'Protected By' },
+  { key: 'binds', label: 'Binds' },
+  { key: 'boundBy', label: 'Bound By' },
+  { key: 'mountsVolume', label: 'Mounts Volume' },
+  { key: 'mountedBy', label: 'Mounted By' },
+  { key: 'usesServiceAccount', label: 'Uses Service Account' },
+  { key: 'serviceAccountUsedBy', label: 'Service Account Used By' },
+  { key: 'restrictedBy', label: 'Restricted By' },
+  { key: 'restricts', label: 'Restricts' },
 ];
 
 export const API_PATHS = {
-  namespaces: '/api/namespaces',
-  stats: '/api/stats',
-  resource: '/api/resource',
-  resourcesWs: '/ws',
-  logsWs: '/ws/logs',
-  execWs: '/ws/exec',
-  nodeExecWs: '/ws/node-exec',
+  namespaces: '/api/v1/namespaces',
+  stats: '/api/v1/stats',
+  resource: '/api/v1/resource',
+  currentContext: '/api/v1/context/current',
+  contextInfo: '/api/v1/context/info',
+  contexts: '/api/v1/contexts',
+  switchContext: '/api/v1/context/switch',
+  resourcesWs: '/api/v1/ws',
+  logsWs: '/api/v1/ws/logs',
+  execWs: '/api/v1/ws/exec',
+  nodeExecWs: '/api/v1/ws/node-exec',
 };
 
 // Single source of truth for all keyboard shortcuts
@@ -55,6 +69,7 @@ export const HOTKEYS = {
   logLast15m:  { key: '4', description: 'Last 15 minutes', category: 'Logs' },
   logLast500:  { key: '5', description: 'Last 500 lines', category: 'Logs' },
   logLast1000: { key: '6', description: 'Last 1000 lines', category: 'Logs' },
+  logPrevious: { key: '7', description: 'Previous container (last 500 lines)', category: 'Logs' },
 };
 
 // Helper to check if a key event matches a hotkey ID
@@ -77,6 +92,7 @@ export const LOG_MODES = [
   { id: 'last-15m', label: '-15m', hotkeyId: 'logLast15m', headLines: null, tailLines: null, sinceSeconds: 900, follow: true },
   { id: 'last-500', label: '-500', hotkeyId: 'logLast500', headLines: null, tailLines: 500, sinceSeconds: null, follow: true },
   { id: 'last-1000', label: '-1000', hotkeyId: 'logLast1000', headLines: null, tailLines: 1000, sinceSeconds: null, follow: true },
+  { id: 'previous', label: 'Prev', hotkeyId: 'logPrevious', headLines: null, tailLines: 500, sinceSeconds: null, follow: false, previous: true },
 ];
 
 export const COMMANDS = [
@@ -89,6 +105,10 @@ export const COMMANDS = [
   { id: 'configmap', type: 'resource', label: 'ConfigMap', aliases: ['configmaps', 'cm'], target: 'ConfigMap', description: 'Switch to ConfigMaps view' },
   { id: 'secret', type: 'resource', label: 'Secret', aliases: ['secrets'], target: 'Secret', description: 'Switch to Secrets view' },
   { id: 'node', type: 'resource', label: 'Node', aliases: ['nodes', 'no'], target: 'Node', description: 'Switch to Nodes view' },
+  { id: 'storageclass', type: 'resource', label: 'StorageClass', aliases: ['storageclasses', 'sc'], target: 'StorageClass', description: 'Switch to StorageClasses view' },
+  { id: 'persistentvolumeclaim', type: 'resource', label: 'PersistentVolumeClaim', aliases: ['persistentvolumeclaims', 'pvc'], target: 'PersistentVolumeClaim', description: 'Switch to PersistentVolumeClaims view' },
+  { id: 'poddisruptionbudget', type: 'resource', label: 'PodDisruptionBudget', aliases: ['poddisruptionbudgets', 'pdb'], target: 'PodDisruptionBudget', description: 'Switch to PodDisruptionBudgets view' },
+  { id: 'helmrelease', type: 'resource', label: 'HelmRelease', aliases: ['helmreleases', 'helm', 'hr'], target: 'HelmRelease', description: 'Switch to Helm releases view (-enable-helm-grouping)' },
 
   // Special commands
   { id: 'namespace', type: 'action', label: 'namespace', aliases: ['ns'], action: 'openNamespaceDropdown', description: 'Open namespace selector' },
@@ -179,6 +199,37 @@ export const TABLE_COLUMNS = {
     { id: 'internalIp', label: 'INTERNAL-IP', width: '120px', align: 'left', sortable: false },
     { id: 'externalIp', label: 'EXTERNAL-IP', width: '120px', align: 'left', sortable: false },
   ],
+  StorageClass: [
+    { id: 'name', label: 'NAME', width: '200px', align: 'left', sortable: true },
+    { id: 'provisioner', label: 'PROVISIONER', width: '250px', align: 'left', sortable: false },
+    { id: 'reclaimPolicy', label: 'RECLAIMPOLICY', width: '120px', align: 'left', sortable: false },
+    { id: 'volumeBindingMode', label: 'VOLUMEBINDINGMODE', width: '160px', align: 'left', sortable: false },
+    { id: 'age', label: 'AGE', width: '80px', align: 'right', sortable: false },
+  ],
+  PersistentVolumeClaim: [
+    { id: 'name', label: 'NAME', width: '200px', align: 'left', sortable: true },
+    { id: 'status', label: 'STATUS', width: '100px', align: 'left', sortable: false },
+    { id: 'volume', label: 'VOLUME', width: '250px', align: 'left', sortable: false },
+    { id: 'capacity', label: 'CAPACITY', width: '90px', align: 'left', sortable: false },
+    { id: 'storageClass', label: 'STORAGECLASS', width: '150px', align: 'left', sortable: false },
+    { id: 'age', label: 'AGE', width: '80px', align: 'right', sortable: false },
+    { id: 'namespace', label: 'NAMESPACE', width: '150px', align: 'left', sortable: false },
+  ],
+  PodDisruptionBudget: [
+    { id: 'name', label: 'NAME', width: '200px', align: 'left', sortable: true },
+    { id: 'minAvailable', label: 'MIN AVAILABLE', width: '120px', align: 'center', sortable: false },
+    { id: 'maxUnavailable', label: 'MAX UNAVAILABLE', width: '130px', align: 'center', sortable: false },
+    { id: 'allowedDisruptions', label: 'ALLOWED DISRUPTIONS', width: '160px', align: 'center', sortable: false },
+    { id: 'age', label: 'AGE', width: '80px', align: 'right', sortable: false },
+    { id: 'namespace', label: 'NAMESPACE', width: '150px', align: 'left', sortable: false },
+  ],
+  HelmRelease: [
+    { id: 'name', label: 'NAME', width: '200px', align: 'left', sortable: true },
+    { id: 'chart', label: 'CHART', width: '250px', align: 'left', sortable: false },
+    { id: 'ready', label: 'HEALTHY', width: '100px', align: 'center', sortable: false },
+    { id: 'age', label: 'AGE', width: '80px', align: 'right', sortable: false },
+    { id: 'namespace', label: 'NAMESPACE', width: '150px', align: 'left', sortable: false },
+  ],
 };
 
 export function getColumnsForType(resourceType) {
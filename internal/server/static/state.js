@@ -28,6 +28,7 @@ export function createInitialState() {
       connectionId: 0,
       reconnectTimeout: null,
       manual: false,
+      generation: 0, // last event generation received, sent as ?since on reconnect
     },
     log: {
       socket: null,
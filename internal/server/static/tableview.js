@@ -64,6 +64,34 @@ function extractCellValue(resource, columnId) {
     case 'externalIp':
       return getNodeExternalIp(resource);
 
+    // StorageClass-specific
+    case 'provisioner':
+      return resource.spec?.provisioner || '-';
+    case 'reclaimPolicy':
+      return resource.spec?.reclaimPolicy || '-';
+    case 'volumeBindingMode':
+      return resource.spec?.volumeBindingMode || '-';
+
+    // PersistentVolumeClaim-specific
+    case 'volume':
+      return resource.spec?.volumeName || '-';
+    case 'capacity':
+      return resource.spec?.capacity || '-';
+    case 'storageClass':
+      return resource.spec?.storageClassName || '-';
+
+    // PodDisruptionBudget-specific
+    case 'minAvailable':
+      return resource.spec?.minAvailable ?? 'N/A';
+    case 'maxUnavailable':
+      return resource.spec?.maxUnavailable ?? 'N/A';
+    case 'allowedDisruptions':
+      return resource.spec?.disruptionsAllowed ?? '-';
+
+    // HelmRelease-specific
+    case 'chart':
+      return resource.spec?.chart || '-';
+
     default:
       return '-';
   }
@@ -86,8 +114,8 @@ function formatAge(createdAt) {
 }
 
 function getPodRestartCount(resource) {
-  if (!resource.spec?.status?.containerStatuses) return '0';
-  const totalRestarts = resource.spec.status.containerStatuses.reduce((sum, container) => {
+  if (!resource.spec?.containers) return '0';
+  const totalRestarts = resource.spec.containers.reduce((sum, container) => {
     return sum + (container.restartCount || 0);
   }, 0);
   return totalRestarts.toString();
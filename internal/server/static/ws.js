@@ -1,9 +1,17 @@
 export function createResourceSocket(state, handlers) {
   let socket = null;
 
-  function connect() {
+  // resume is set for automatic reconnects: the server then replays only the events
+  // missed since the last generation seen, or answers SNAPSHOT_REQUIRED if it can't
+  function connect(resume = false) {
     const myConnectionId = ++state.ws.connectionId;
-    const url = handlers.buildUrl();
+    let url = handlers.buildUrl();
+    const resuming = resume && state.ws.generation > 0;
+    if (resuming) {
+      url += `${url.includes('?') ? '&' : '?'}since=${state.ws.generation}`;
+    } else {
+      state.ws.generation = 0;
+    }
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
     socket = new WebSocket(`${protocol}//${window.location.host}${url}`);
 
@@ -12,9 +20,12 @@ export function createResourceSocket(state, handlers) {
         socket.close();
         return;
       }
-      clearTimeout(window.snapshotTimer);
-      state.snapshotComplete = false;
-      state.snapshotCount = 0;
+      // A resumed connection keeps the resources it already has
+      if (!resuming) {
+        clearTimeout(window.snapshotTimer);
+        state.snapshotComplete = false;
+        state.snapshotCount = 0;
+      }
       state.ws.manual = false;
       handlers.onOpen?.();
     };
@@ -29,6 +40,20 @@ export function createResourceSocket(state, handlers) {
         return;
       }
 
+      if (msg.generation > state.ws.generation) {
+        state.ws.generation = msg.generation;
+      }
+
+      // The missed events are gone, a full snapshot follows
+      if (msg.type === 'SNAPSHOT_REQUIRED') {
+        console.log('[WS] Replay window exceeded, reloading full snapshot');
+        clearTimeout(window.snapshotTimer);
+        state.resources.clear();
+        state.snapshotComplete = false;
+        state.snapshotCount = 0;
+        return;
+      }
+
       // Existing resource event handling
       if (!state.snapshotComplete && msg.type === 'ADDED') {
         state.snapshotCount++;
@@ -54,7 +79,7 @@ export function createResourceSocket(state, handlers) {
       if (myConnectionId !== state.ws.connectionId) return;
       handlers.onClose?.();
       if (!state.ws.manual) {
-        state.ws.reconnectTimeout = setTimeout(connect, 2000);
+        state.ws.reconnectTimeout = setTimeout(() => connect(true), 2000);
       }
     };
   }
@@ -1,14 +1,20 @@
 package server
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 
 	"github.com/user/k8v/internal/k8s"
 )
 
+// eventHistorySize is the number of recent events the hub keeps for ?since replay
+const eventHistorySize = 1000
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins for now
@@ -24,6 +30,19 @@ type Client struct {
 	namespace    string // namespace filter ("" = all namespaces)
 	resourceType string // resource type filter ("" = all types)
 	logger       *Logger
+
+	// Replay state, set by the hub on register before ready is closed
+	since      int64         // generation requested with ?since (0 = none)
+	generation int64         // hub generation at registration, stamped on snapshot events
+	replayed   bool          // whether the delta since `since` was queued instead of a snapshot
+	ready      chan struct{} // closed once the hub has processed the registration
+}
+
+// HubOptions configures optional hub behavior
+type HubOptions struct {
+	// ExcludeNamespaces lists namespaces whose events are never broadcast or replayed,
+	// matching WatcherOptions.ExcludeNamespaces
+	ExcludeNamespaces []string
 }
 
 // Hub manages all active WebSocket connections
@@ -37,10 +56,27 @@ type Hub struct {
 	logger            *Logger
 	currentSyncStatus *k8s.SyncStatusEvent
 	syncMu            sync.RWMutex
+	excluded          map[string]bool // namespaces from HubOptions.ExcludeNamespaces
+
+	// Recent events for ?since replay. The history lives on the hub rather than on each
+	// Client because a client's state is gone by the time it reconnects
+	generation int64               // sequence number of the last broadcast event
+	resetFloor int64               // generations up to this one predate the last ResetHistory
+	history    []k8s.ResourceEvent // last eventHistorySize events, oldest first
+	historyMu  sync.Mutex
 }
 
 // NewHub creates a new Hub
 func NewHub(logger *Logger) *Hub {
+	return NewHubWithOptions(logger, HubOptions{})
+}
+
+// NewHubWithOptions creates a new Hub with the given options
+func NewHubWithOptions(logger *Logger, options HubOptions) *Hub {
+	excluded := make(map[string]bool, len(options.ExcludeNamespaces))
+	for _, namespace := range options.ExcludeNamespaces {
+		excluded[namespace] = true
+	}
 	return &Hub{
 		clients:           make(map[*Client]bool),
 		broadcast:         make(chan k8s.ResourceEvent, 256),
@@ -49,6 +85,10 @@ func NewHub(logger *Logger) *Hub {
 		unregister:        make(chan *Client),
 		logger:            logger,
 		currentSyncStatus: nil,
+		excluded:          excluded,
+		// Seeded from the clock so a generation remembered from before a restart is (in
+		// practice) outside the new process's history and gets a full snapshot
+		generation: time.Now().UnixMilli(),
 	}
 }
 
@@ -57,10 +97,21 @@ func (h *Hub) Run() {
 	for {
 		select {
 		case client := <-h.register:
-			h.mu.Lock()
-			h.clients[client] = true
-			h.mu.Unlock()
-			h.logger.Printf("[WebSocket] Client connected (total: %d)", len(h.clients))
+			// Queue the delta for resuming clients while no broadcast can interleave
+			h.historyMu.Lock()
+			client.generation = h.generation
+			if client.since > 0 {
+				if events, ok := h.eventsSinceLocked(client.since); ok {
+					for _, event := range events {
+						if client.wants(event) {
+							client.send <- event // Buffer holds far more than eventHistorySize
+						}
+					}
+					client.replayed = true
+				}
+			}
+			h.historyMu.Unlock()
+			close(client.ready)
 
 			// Send cached sync status to new client immediately
 			h.syncMu.RLock()
@@ -73,27 +124,42 @@ func (h *Hub) Run() {
 			}
 			h.syncMu.RUnlock()
 
+			// Added only once nothing else is queued, so DisconnectAll can't close the
+			// client's channels while they are being sent on
+			h.mu.Lock()
+			h.clients[client] = true
+			total := len(h.clients)
+			h.mu.Unlock()
+			h.logger.Printf("[WebSocket] Client connected (total: %d)", total)
+
 		case client := <-h.unregister:
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-				close(client.sendSync)
+				h.removeLocked(client)
 			}
+			total := len(h.clients)
 			h.mu.Unlock()
-			h.logger.Printf("[WebSocket] Client disconnected (total: %d)", len(h.clients))
+			h.logger.Printf("[WebSocket] Client disconnected (total: %d)", total)
 
 		case event := <-h.broadcast:
-			h.mu.RLock()
-			for client := range h.clients {
-				// Skip if client has namespace filter and resource doesn't match
-				// But always include cluster-scoped resources (empty namespace)
-				if client.namespace != "" && event.Resource.Namespace != "" && event.Resource.Namespace != client.namespace {
-					continue
-				}
+			// Kept out of the history too, so ?since replays never include them
+			if h.excluded[event.Resource.Namespace] {
+				continue
+			}
+
+			h.historyMu.Lock()
+			h.generation++
+			event.Generation = h.generation
+			h.history = append(h.history, event)
+			if len(h.history) > eventHistorySize {
+				h.history = h.history[1:]
+			}
+			h.historyMu.Unlock()
 
-				// Skip if client has resource type filter and resource doesn't match
-				if client.resourceType != "" && event.Resource.Type != client.resourceType {
+			// Slow clients are removed while iterating, so this needs the write lock
+			h.mu.Lock()
+			for client := range h.clients {
+				if !client.wants(event) {
 					continue
 				}
 
@@ -101,11 +167,10 @@ func (h *Hub) Run() {
 				case client.send <- event:
 				default:
 					// Client is slow, close it
-					close(client.send)
-					delete(h.clients, client)
+					h.removeLocked(client)
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
 
 		case syncEvent := <-h.broadcastSync:
 			// Cache the latest sync status
@@ -114,26 +179,90 @@ func (h *Hub) Run() {
 			h.syncMu.Unlock()
 
 			// Broadcast to all clients
-			h.mu.RLock()
+			h.mu.Lock()
 			for client := range h.clients {
 				select {
 				case client.sendSync <- syncEvent:
 				default:
 					// Client is slow, close it
 					h.logger.Printf("[WebSocket] Client slow during sync broadcast, closing")
-					close(client.send)
-					close(client.sendSync)
-					delete(h.clients, client)
+					h.removeLocked(client)
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
 		}
 	}
 }
 
+// removeLocked forgets a client and closes its channels, which makes writePump close
+// the connection. Run and DisconnectAll call it with the write lock held; Run only sends
+// on the channels of clients in h.clients, so nothing can send on them afterwards.
+// Deleting while ranging over clients is safe.
+func (h *Hub) removeLocked(client *Client) {
+	delete(h.clients, client)
+	close(client.send)
+	close(client.sendSync)
+}
+
+// ClientCount returns the number of connected resource WebSocket clients
+func (h *Hub) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// wants reports whether an event passes the client's namespace and type filters
+func (c *Client) wants(event k8s.ResourceEvent) bool {
+	// Skip if client has namespace filter and resource doesn't match
+	// But always include cluster-scoped resources (empty namespace)
+	if c.namespace != "" && event.Resource.Namespace != "" && event.Resource.Namespace != c.namespace {
+		return false
+	}
+
+	// Skip if client has resource type filter and resource doesn't match
+	if c.resourceType != "" && event.Resource.Type != c.resourceType {
+		return false
+	}
+	return true
+}
+
+// eventsSinceLocked returns the events broadcast after the given generation, and false
+// when some of them have already been dropped from the history (or since is in the future,
+// or from before the last ResetHistory)
+// Callers must hold historyMu
+func (h *Hub) eventsSinceLocked(since int64) ([]k8s.ResourceEvent, bool) {
+	if since > h.generation || since <= h.resetFloor {
+		return nil, false
+	}
+	if len(h.history) == 0 {
+		return nil, since == h.generation
+	}
+	if since < h.history[0].Generation-1 {
+		return nil, false
+	}
+	return h.history[since-(h.history[0].Generation-1):], true
+}
+
+// ResetHistory drops the replay history, forcing reconnecting clients to take a full
+// snapshot. Called on context switch, when earlier events describe a different cluster.
+func (h *Hub) ResetHistory() {
+	h.historyMu.Lock()
+	defer h.historyMu.Unlock()
+	h.history = nil
+	// Clients registering from now on start past the floor, so they can still resume
+	h.resetFloor = h.generation
+	h.generation++
+}
+
 // Broadcast sends an event to all connected clients
-func (h *Hub) Broadcast(event k8s.ResourceEvent) {
-	h.broadcast <- event
+// It returns ctx.Err() if the hub doesn't accept the event before ctx is done.
+func (h *Hub) Broadcast(ctx context.Context, event k8s.ResourceEvent) error {
+	select {
+	case h.broadcast <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // BroadcastSyncStatus sends sync status update to all clients
@@ -147,9 +276,7 @@ func (h *Hub) DisconnectAll() {
 	defer h.mu.Unlock()
 
 	for client := range h.clients {
-		close(client.send)
-		client.conn.Close()
-		delete(h.clients, client)
+		h.removeLocked(client)
 	}
 	h.logger.Printf("[WebSocket] All clients disconnected")
 }
@@ -168,12 +295,20 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		namespace = "" // Empty string = all namespaces
 	}
 
+	// Namespace-scoped tokens always override the requested filter
+	if scoped, ok := scopedNamespace(r); ok {
+		namespace = scoped
+	}
+
 	// Parse resource type filter from query params
 	resourceType := r.URL.Query().Get("type")
 	if resourceType == "" || resourceType == "all" {
 		resourceType = "" // Empty string = all types
 	}
 
+	// Parse the generation to resume from (sent by reconnecting clients)
+	since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+
 	s.logger.Printf("[WebSocket] New connection with filters - namespace: '%s', type: '%s'", namespace, resourceType)
 
 	client := &Client{
@@ -184,9 +319,29 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		namespace:    namespace,
 		resourceType: resourceType,
 		logger:       s.logger,
+		since:        since,
+		ready:        make(chan struct{}),
 	}
 
 	s.hub.register <- client
+	<-client.ready
+
+	if client.replayed {
+		s.logger.Printf("[WebSocket] Resuming client from generation %d, replaying %d events", since, len(client.send))
+		go client.writePump()
+		go client.readPump()
+		return
+	}
+
+	// The requested generation is no longer in the history, so the client must start over
+	if since > 0 {
+		s.logger.Printf("[WebSocket] Generation %d is outside the replay window, sending full snapshot", since)
+		if err := conn.WriteJSON(k8s.ResourceEvent{Type: k8s.EventSnapshotRequired, Generation: client.generation}); err != nil {
+			conn.Close()
+			s.hub.unregister <- client
+			return
+		}
+	}
 
 	// Send initial snapshot of resources (filtered by namespace and type) synchronously before starting pumps
 	snapshot := s.watcherProvider.GetWatcher().GetSnapshotFilteredByType(namespace, resourceType)
@@ -203,6 +358,7 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Send snapshot directly without using the channel to avoid race condition
 	batchSize := 1000
 	for i, event := range snapshot {
+		event.Generation = client.generation
 		err := conn.WriteJSON(event)
 		if err != nil {
 			s.logger.Printf("[WebSocket] Failed to send snapshot event %d/%d: %v", i+1, len(snapshot), err)
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/user/k8v/internal/k8s"
+	"github.com/user/k8v/internal/types"
+)
+
+// newTestWebSocketServer returns an HTTP server for a test server with running hubs
+func newTestWebSocketServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	s := newTestServer(t, k8s.WatcherOptions{})
+	s.hub = NewHub(s.logger)
+	s.logHub = NewLogHub(s.logger)
+	s.execHub = NewExecHub(s.logger)
+	s.nodeExecHub = NewNodeExecHub(s.logger)
+	go s.hub.Run()
+	go s.logHub.Run()
+	go s.execHub.Run()
+	go s.nodeExecHub.Run()
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+// dialTestWebSocket opens a WebSocket to path, failing unless the server switched protocols
+func dialTestWebSocket(t *testing.T, server *httptest.Server, path string) *websocket.Conn {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+path, nil)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("Dial(%s) error = %v (status %d)", path, err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Dial(%s) status = %d, want %d", path, resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestWebSocketEndpointsUpgrade(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		path     string
+		wantType string // Type of the first message, from the fake clientset's empty cluster
+	}{
+		{"resource events", "/api/v1/ws", string(k8s.EventAdded)},
+		{"logs", "/api/v1/ws/logs?namespace=default&pod=missing&container=app", "LOG_ERROR"},
+		{"pod exec", "/api/v1/ws/exec?namespace=default&pod=missing&container=app", k8s.ExecMessageError},
+		{"node exec", "/api/v1/ws/node-exec?node=node-1", k8s.ExecMessageCreating},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s, server := newTestWebSocketServer(t)
+			s.watcherProvider.GetWatcher().LoadSnapshot([]*types.Resource{podEvent("default", "web").Resource})
+
+			conn := dialTestWebSocket(t, server, tt.path)
+			var message struct {
+				Type     string          `json:"type"`
+				Resource *types.Resource `json:"resource"`
+			}
+			if err := conn.ReadJSON(&message); err != nil {
+				t.Fatalf("ReadJSON() error = %v", err)
+			}
+			if message.Type != tt.wantType {
+				t.Errorf("first message type = %q, want %q", message.Type, tt.wantType)
+			}
+			if tt.wantType == string(k8s.EventAdded) && (message.Resource == nil || message.Resource.ID != "Pod:default:web") {
+				t.Errorf("snapshot resource = %+v, want Pod:default:web", message.Resource)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// Template is a YAML starter for one resource kind
+type Template struct {
+	// Object is the template without comments, e.g. for server-side dry-run validation
+	Object *unstructured.Unstructured
+
+	yaml string // annotated source, comments included
+}
+
+// apiVersionLine matches the top-level apiVersion of a template
+var apiVersionLine = regexp.MustCompile(`(?m)^apiVersion: .*$`)
+
+// GroupVersionKind returns the kind and default API version of the template
+func (t *Template) GroupVersionKind() schema.GroupVersionKind {
+	return t.Object.GroupVersionKind()
+}
+
+// YAML returns the annotated template for the given API version ("" keeps the default)
+func (t *Template) YAML(apiVersion string) string {
+	if apiVersion == "" {
+		return t.yaml
+	}
+	return apiVersionLine.ReplaceAllLiteralString(t.yaml, "apiVersion: "+apiVersion)
+}
+
+// TemplateRegistry maps kinds to templates
+type TemplateRegistry struct {
+	templates map[string]*Template // lowercase kind -> template
+}
+
+// NewTemplateRegistry creates a registry holding the built-in templates
+func NewTemplateRegistry() *TemplateRegistry {
+	r := &TemplateRegistry{templates: make(map[string]*Template)}
+	for _, source := range builtinTemplates {
+		t, err := parseTemplate(source)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in template: %v", err)) // Caught by any run
+		}
+		r.templates[strings.ToLower(t.Object.GetKind())] = t
+	}
+	return r
+}
+
+// Get returns the template for a kind, matched case-insensitively
+func (r *TemplateRegistry) Get(kind string) (*Template, bool) {
+	t, ok := r.templates[strings.ToLower(kind)]
+	return t, ok
+}
+
+// Kinds returns the kinds with a template, sorted
+func (r *TemplateRegistry) Kinds() []string {
+	kinds := make([]string, 0, len(r.templates))
+	for _, t := range r.templates {
+		kinds = append(kinds, t.Object.GetKind())
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// parseTemplate builds a template from annotated YAML
+func parseTemplate(source string) (*Template, error) {
+	data, err := yaml.YAMLToJSON([]byte(source))
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return &Template{Object: obj, yaml: source}, nil
+}
+
+// builtinTemplates are the annotated YAML starters, one per kind
+var builtinTemplates = []string{
+	`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  labels:
+    app: my-app # Labels identify the workload; keep them in sync with the selector
+spec:
+  replicas: 2 # Run at least two replicas so a single Pod failure causes no downtime
+  selector:
+    matchLabels:
+      app: my-app # Must match spec.template.metadata.labels; immutable after creation
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxUnavailable: 0 # Never drop below the desired replica count during a rollout
+      maxSurge: 1
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+        - name: my-app
+          image: registry.example.com/my-app:1.0.0 # Pin a version; avoid :latest
+          ports:
+            - name: http
+              containerPort: 8080
+          resources:
+            requests: # What the scheduler reserves for the container
+              cpu: 100m
+              memory: 128Mi
+            limits: # The container is throttled (CPU) or OOM-killed (memory) above these
+              memory: 256Mi
+          readinessProbe: # Gates traffic from Services until the app is ready
+            httpGet:
+              path: /healthz
+              port: http
+            periodSeconds: 10
+          livenessProbe: # Restarts the container when it stops responding
+            httpGet:
+              path: /healthz
+              port: http
+            initialDelaySeconds: 10
+            periodSeconds: 20
+          securityContext:
+            runAsNonRoot: true
+            allowPrivilegeEscalation: false
+`,
+	`apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-db
+spec:
+  serviceName: my-db # Headless Service giving each Pod a stable DNS name
+  replicas: 1
+  selector:
+    matchLabels:
+      app: my-db
+  template:
+    metadata:
+      labels:
+        app: my-db
+    spec:
+      containers:
+        - name: my-db
+          image: registry.example.com/my-db:1.0.0
+          ports:
+            - name: db
+              containerPort: 5432
+          resources:
+            requests:
+              cpu: 250m
+              memory: 512Mi
+            limits:
+              memory: 1Gi
+          volumeMounts:
+            - name: data
+              mountPath: /var/lib/data
+  volumeClaimTemplates: # One PersistentVolumeClaim per Pod, kept across restarts
+    - metadata:
+        name: data
+      spec:
+        accessModes:
+          - ReadWriteOnce
+        resources:
+          requests:
+            storage: 10Gi
+`,
+	`apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: my-agent
+spec:
+  selector:
+    matchLabels:
+      app: my-agent
+  template:
+    metadata:
+      labels:
+        app: my-agent
+    spec:
+      tolerations: # Also run on control-plane Nodes
+        - key: node-role.kubernetes.io/control-plane
+          operator: Exists
+          effect: NoSchedule
+      containers:
+        - name: my-agent
+          image: registry.example.com/my-agent:1.0.0
+          resources:
+            requests: # Reserved on every Node, keep them small
+              cpu: 50m
+              memory: 64Mi
+            limits:
+              memory: 128Mi
+`,
+	`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: my-job
+spec:
+  backoffLimit: 3 # Retries before the Job is marked failed
+  activeDeadlineSeconds: 600 # Fail the Job if it runs longer than this
+  ttlSecondsAfterFinished: 3600 # Delete the finished Job and its Pods after an hour
+  template:
+    spec:
+      restartPolicy: Never # Jobs require Never or OnFailure
+      containers:
+        - name: my-job
+          image: registry.example.com/my-job:1.0.0
+          resources:
+            requests:
+              cpu: 100m
+              memory: 128Mi
+            limits:
+              memory: 256Mi
+`,
+	`apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: my-cronjob
+spec:
+  schedule: "0 3 * * *" # Cron syntax, in the controller manager's time zone unless timeZone is set
+  concurrencyPolicy: Forbid # Skip a run while the previous one is still active
+  successfulJobsHistoryLimit: 3
+  failedJobsHistoryLimit: 1
+  jobTemplate:
+    spec:
+      backoffLimit: 2
+      template:
+        spec:
+          restartPolicy: OnFailure
+          containers:
+            - name: my-cronjob
+              image: registry.example.com/my-cronjob:1.0.0
+              resources:
+                requests:
+                  cpu: 100m
+                  memory: 128Mi
+                limits:
+                  memory: 256Mi
+`,
+	`apiVersion: v1
+kind: Service
+metadata:
+  name: my-app
+spec:
+  type: ClusterIP # ClusterIP (internal), NodePort or LoadBalancer
+  selector:
+    app: my-app # Pods receiving traffic; must match the workload's Pod labels
+  ports:
+    - name: http
+      port: 80 # Port exposed by the Service
+      targetPort: http # Container port name or number
+`,
+	`apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app
+spec:
+  ingressClassName: nginx # Ingress controller handling this Ingress
+  tls:
+    - hosts:
+        - my-app.example.com
+      secretName: my-app-tls # Secret of type kubernetes.io/tls
+  rules:
+    - host: my-app.example.com
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: my-app
+                port:
+                  name: http
+`,
+	`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data: # Non-sensitive configuration only; use a Secret for credentials
+  LOG_LEVEL: info
+  app.properties: |
+    feature.enabled=true
+`,
+	`apiVersion: v1
+kind: Secret
+metadata:
+  name: my-secret
+type: Opaque
+stringData: # Written as plain text, stored base64-encoded in data
+  username: admin
+  password: change-me # Don't commit real values; prefer an external secret manager
+`,
+	`apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: my-data
+spec:
+  accessModes:
+    - ReadWriteOnce # Mountable read-write by a single Node
+  storageClassName: standard # Omit to use the cluster's default StorageClass
+  resources:
+    requests:
+      storage: 10Gi
+`,
+	`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: my-app
+automountServiceAccountToken: false # Only mount an API token into Pods that call the API
+`,
+	`apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: my-app
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: my-app
+  minReplicas: 2
+  maxReplicas: 10
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: 70 # Percent of the containers' CPU requests
+`,
+	`apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: my-app
+spec:
+  minAvailable: 1 # Or maxUnavailable; limits voluntary evictions such as Node drains
+  selector:
+    matchLabels:
+      app: my-app
+`,
+	`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: my-app
+spec:
+  podSelector:
+    matchLabels:
+      app: my-app # Pods this policy applies to
+  policyTypes:
+    - Ingress
+  ingress: # Only traffic matching a rule is allowed once a Pod is selected
+    - from:
+        - podSelector:
+            matchLabels:
+              role: frontend
+      ports:
+        - protocol: TCP
+          port: 8080
+`,
+}
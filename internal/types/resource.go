@@ -1,36 +1,69 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // RelationshipType represents a type of relationship between resources
 type RelationshipType string
 
 const (
-	RelOwnedBy   RelationshipType = "OwnedBy"
-	RelOwns      RelationshipType = "Owns"
-	RelDependsOn RelationshipType = "DependsOn"
-	RelUsedBy    RelationshipType = "UsedBy"
-	RelExposes   RelationshipType = "Exposes"
-	RelExposedBy RelationshipType = "ExposedBy"
+	RelOwnedBy     RelationshipType = "OwnedBy"
+	RelOwns        RelationshipType = "Owns"
+	RelDependsOn   RelationshipType = "DependsOn"
+	RelUsedBy      RelationshipType = "UsedBy"
+	RelExposes     RelationshipType = "Exposes"
+	RelExposedBy   RelationshipType = "ExposedBy"
 	RelRoutesTo    RelationshipType = "RoutesTo"
 	RelRoutedBy    RelationshipType = "RoutedBy"
 	RelScheduledOn RelationshipType = "ScheduledOn" // Pod scheduled on Node
 	RelSchedules   RelationshipType = "Schedules"   // Node schedules Pods
+	RelProtects    RelationshipType = "Protects"    // PodDisruptionBudget protects Pods
+	RelProtectedBy RelationshipType = "ProtectedBy" // Pod protected by PodDisruptionBudget
+
+	RelBinds                RelationshipType = "Binds"                // PersistentVolumeClaim binds PersistentVolume
+	RelBoundBy              RelationshipType = "BoundBy"              // PersistentVolume bound by PersistentVolumeClaim
+	RelMountsVolume         RelationshipType = "MountsVolume"         // Pod mounts PersistentVolumeClaim
+	RelMountedBy            RelationshipType = "MountedBy"            // PersistentVolumeClaim mounted by Pods
+	RelUsesServiceAccount   RelationshipType = "UsesServiceAccount"   // Pod runs as ServiceAccount
+	RelServiceAccountUsedBy RelationshipType = "ServiceAccountUsedBy" // ServiceAccount used by Pods
+	RelRestrictedBy         RelationshipType = "RestrictedBy"         // Pod selected by NetworkPolicy
+	RelRestricts            RelationshipType = "Restricts"            // NetworkPolicy selects Pods
 )
 
+// RelationshipTypes lists every relationship type, in Relationships field order
+var RelationshipTypes = []RelationshipType{
+	RelOwnedBy, RelOwns, RelDependsOn, RelUsedBy, RelExposes, RelExposedBy,
+	RelRoutesTo, RelRoutedBy, RelScheduledOn, RelSchedules, RelProtects, RelProtectedBy,
+	RelBinds, RelBoundBy, RelMountsVolume, RelMountedBy,
+	RelUsesServiceAccount, RelServiceAccountUsedBy, RelRestrictedBy, RelRestricts,
+}
+
 // GetReverseRelationshipType returns the reverse of a relationship type
 func GetReverseRelationshipType(relType RelationshipType) RelationshipType {
 	pairs := map[RelationshipType]RelationshipType{
-		RelOwnedBy:   RelOwns,
-		RelOwns:      RelOwnedBy,
-		RelDependsOn: RelUsedBy,
-		RelUsedBy:    RelDependsOn,
+		RelOwnedBy:     RelOwns,
+		RelOwns:        RelOwnedBy,
+		RelDependsOn:   RelUsedBy,
+		RelUsedBy:      RelDependsOn,
 		RelExposes:     RelExposedBy,
 		RelExposedBy:   RelExposes,
 		RelRoutesTo:    RelRoutedBy,
 		RelRoutedBy:    RelRoutesTo,
 		RelScheduledOn: RelSchedules,
 		RelSchedules:   RelScheduledOn,
+		RelProtects:    RelProtectedBy,
+		RelProtectedBy: RelProtects,
+
+		RelBinds:                RelBoundBy,
+		RelBoundBy:              RelBinds,
+		RelMountsVolume:         RelMountedBy,
+		RelMountedBy:            RelMountsVolume,
+		RelUsesServiceAccount:   RelServiceAccountUsedBy,
+		RelServiceAccountUsedBy: RelUsesServiceAccount,
+		RelRestrictedBy:         RelRestricts,
+		RelRestricts:            RelRestrictedBy,
 	}
 	return pairs[relType]
 }
@@ -38,8 +71,8 @@ func GetReverseRelationshipType(relType RelationshipType) RelationshipType {
 // Resource represents any Kubernetes resource with computed relationships
 type Resource struct {
 	// Identity
-	ID        string `json:"id"`        // Unique: "type:namespace:name"
-	Type      string `json:"type"`      // "Pod", "Deployment", "Service", etc.
+	ID        string `json:"id"`   // Unique: "type:namespace:name"
+	Type      string `json:"type"` // "Pod", "Deployment", "Service", etc.
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 
@@ -55,9 +88,12 @@ type Resource struct {
 	Annotations map[string]string `json:"annotations"`
 	CreatedAt   time.Time         `json:"createdAt"`
 
+	// ResourceVersion of the object the resource was built from, used to reject stale updates
+	ResourceVersion string `json:"resourceVersion"`
+
 	// Raw data for detail views
-	Spec interface{} `json:"spec,omitempty"` // Type-specific data
-	YAML string      `json:"yaml"`           // Full YAML for viewing
+	Spec json.RawMessage `json:"spec,omitempty"` // Type-specific data, decoded by TypedSpec
+	YAML string          `json:"yaml"`           // Full YAML for viewing
 }
 
 // Relationships captures all connections between resources
@@ -79,12 +115,30 @@ type Relationships struct {
 	// Scheduling relationships
 	ScheduledOn []ResourceRef `json:"scheduledOn"` // e.g., Pod scheduled on Node
 	Schedules   []ResourceRef `json:"schedules"`   // e.g., Node schedules Pods
+
+	// Disruption relationships
+	Protects    []ResourceRef `json:"protects"`    // e.g., PodDisruptionBudget protects Pods
+	ProtectedBy []ResourceRef `json:"protectedBy"` // e.g., Pod protected by PodDisruptionBudget
+
+	// Storage relationships
+	Binds        []ResourceRef `json:"binds"`        // e.g., PersistentVolumeClaim binds PersistentVolume
+	BoundBy      []ResourceRef `json:"boundBy"`      // e.g., PersistentVolume bound by PersistentVolumeClaim
+	MountsVolume []ResourceRef `json:"mountsVolume"` // e.g., Pod mounts PersistentVolumeClaim
+	MountedBy    []ResourceRef `json:"mountedBy"`    // e.g., PersistentVolumeClaim mounted by Pods
+
+	// Identity relationships
+	UsesServiceAccount   []ResourceRef `json:"usesServiceAccount"`   // e.g., Pod runs as ServiceAccount
+	ServiceAccountUsedBy []ResourceRef `json:"serviceAccountUsedBy"` // e.g., ServiceAccount used by Pods
+
+	// Network policy relationships
+	RestrictedBy []ResourceRef `json:"restrictedBy"` // e.g., Pod selected by NetworkPolicy
+	Restricts    []ResourceRef `json:"restricts"`    // e.g., NetworkPolicy selects Pods
 }
 
 // ResourceRef is a lightweight reference to another resource
 type ResourceRef struct {
-	ID        string `json:"id"`        // "type:namespace:name"
-	Type      string `json:"type"`      // "Pod", "Service", etc.
+	ID        string `json:"id"`   // "type:namespace:name"
+	Type      string `json:"type"` // "Pod", "Service", etc.
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 }
@@ -148,7 +202,118 @@ func (r *Resource) GetRelationship(relType RelationshipType) []ResourceRef {
 		return r.Relationships.ScheduledOn
 	case RelSchedules:
 		return r.Relationships.Schedules
+	case RelProtects:
+		return r.Relationships.Protects
+	case RelProtectedBy:
+		return r.Relationships.ProtectedBy
+	case RelBinds:
+		return r.Relationships.Binds
+	case RelBoundBy:
+		return r.Relationships.BoundBy
+	case RelMountsVolume:
+		return r.Relationships.MountsVolume
+	case RelMountedBy:
+		return r.Relationships.MountedBy
+	case RelUsesServiceAccount:
+		return r.Relationships.UsesServiceAccount
+	case RelServiceAccountUsedBy:
+		return r.Relationships.ServiceAccountUsedBy
+	case RelRestrictedBy:
+		return r.Relationships.RestrictedBy
+	case RelRestricts:
+		return r.Relationships.Restricts
 	default:
 		return nil
 	}
 }
+
+// SetAnnotation sets an annotation, creating the map if the resource has none
+// Transformed resources share their annotation map with the informer's object, so copy
+// it (or Clone the resource) before annotating.
+func (r *Resource) SetAnnotation(key, value string) {
+	if r.Annotations == nil {
+		r.Annotations = make(map[string]string)
+	}
+	r.Annotations[key] = value
+}
+
+// RemoveAnnotation deletes an annotation; it does nothing for a resource without any
+func (r *Resource) RemoveAnnotation(key string) {
+	if r.Annotations != nil {
+		delete(r.Annotations, key)
+	}
+}
+
+// Clone returns a copy of the resource that can be modified without affecting the original
+// Labels, annotations, relationships and the encoded spec are copied.
+func (r *Resource) Clone() *Resource {
+	clone := *r
+	clone.Labels = cloneStringMap(r.Labels)
+	clone.Annotations = cloneStringMap(r.Annotations)
+	clone.Relationships = r.Relationships.clone()
+	if r.Spec != nil {
+		clone.Spec = append(json.RawMessage(nil), r.Spec...)
+	}
+	return &clone
+}
+
+// clone returns a copy of every relationship slice
+func (rel Relationships) clone() Relationships {
+	return Relationships{
+		OwnedBy:     cloneRefs(rel.OwnedBy),
+		Owns:        cloneRefs(rel.Owns),
+		DependsOn:   cloneRefs(rel.DependsOn),
+		UsedBy:      cloneRefs(rel.UsedBy),
+		Exposes:     cloneRefs(rel.Exposes),
+		ExposedBy:   cloneRefs(rel.ExposedBy),
+		RoutesTo:    cloneRefs(rel.RoutesTo),
+		RoutedBy:    cloneRefs(rel.RoutedBy),
+		ScheduledOn: cloneRefs(rel.ScheduledOn),
+		Schedules:   cloneRefs(rel.Schedules),
+		Protects:    cloneRefs(rel.Protects),
+		ProtectedBy: cloneRefs(rel.ProtectedBy),
+
+		Binds:                cloneRefs(rel.Binds),
+		BoundBy:              cloneRefs(rel.BoundBy),
+		MountsVolume:         cloneRefs(rel.MountsVolume),
+		MountedBy:            cloneRefs(rel.MountedBy),
+		UsesServiceAccount:   cloneRefs(rel.UsesServiceAccount),
+		ServiceAccountUsedBy: cloneRefs(rel.ServiceAccountUsedBy),
+		RestrictedBy:         cloneRefs(rel.RestrictedBy),
+		Restricts:            cloneRefs(rel.Restricts),
+	}
+}
+
+// All returns the references of every relationship, in field order. A resource related
+// in several ways appears once per relationship.
+func (rel Relationships) All() []ResourceRef {
+	var refs []ResourceRef
+	for _, group := range [][]ResourceRef{
+		rel.OwnedBy, rel.Owns, rel.DependsOn, rel.UsedBy, rel.Exposes, rel.ExposedBy,
+		rel.RoutesTo, rel.RoutedBy, rel.ScheduledOn, rel.Schedules, rel.Protects, rel.ProtectedBy,
+		rel.Binds, rel.BoundBy, rel.MountsVolume, rel.MountedBy,
+		rel.UsesServiceAccount, rel.ServiceAccountUsedBy, rel.RestrictedBy, rel.Restricts,
+	} {
+		refs = append(refs, group...)
+	}
+	return refs
+}
+
+// cloneRefs copies a relationship slice, keeping nil as nil (it's serialized as null)
+func cloneRefs(refs []ResourceRef) []ResourceRef {
+	if refs == nil {
+		return nil
+	}
+	return append(make([]ResourceRef, 0, len(refs)), refs...)
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
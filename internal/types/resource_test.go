@@ -0,0 +1,131 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// benchmarkPod returns a Pod resource with the metadata, relationships and spec of a typical workload
+func benchmarkPod() *Resource {
+	return &Resource{
+		ID:        BuildID("Pod", "default", "web-7d4b9c-abcde"),
+		Type:      "Pod",
+		Name:      "web-7d4b9c-abcde",
+		Namespace: "default",
+		Status:    ResourceStatus{Phase: "Running", Ready: "2/2"},
+		Health:    HealthHealthy,
+		Relationships: Relationships{
+			OwnedBy:   []ResourceRef{NewResourceRef("ReplicaSet", "default", "web-7d4b9c")},
+			DependsOn: []ResourceRef{NewResourceRef("ConfigMap", "default", "web-config"), NewResourceRef("Secret", "default", "web-tls")},
+			ExposedBy: []ResourceRef{NewResourceRef("Service", "default", "web")},
+		},
+		Labels:          map[string]string{"app": "web", "pod-template-hash": "7d4b9c", "team": "storefront"},
+		Annotations:     map[string]string{"prometheus.io/scrape": "true", "prometheus.io/port": "9090"},
+		CreatedAt:       time.Now(),
+		ResourceVersion: "123456",
+		Spec: NewSpec(PodSpec{
+			PodSpec:    v1.PodSpec{NodeName: "node-1", ServiceAccountName: "web"},
+			Containers: []ContainerInfo{{Name: "app", Image: "web:1.4.2", Ready: true}, {Name: "proxy", Image: "envoy:1.31", Ready: true}},
+		}),
+		YAML: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web-7d4b9c-abcde\n",
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	t.Parallel()
+
+	original := benchmarkPod()
+	clone := original.Clone()
+	clone.Labels["app"] = "changed"
+	clone.Annotations["prometheus.io/port"] = "0"
+	clone.Relationships.DependsOn[0].Name = "changed"
+	clone.Spec[0] = ' '
+
+	if original.Labels["app"] != "web" || original.Annotations["prometheus.io/port"] != "9090" {
+		t.Error("modifying the clone's metadata changed the original")
+	}
+	if original.Relationships.DependsOn[0].Name != "web-config" {
+		t.Error("modifying the clone's relationships changed the original")
+	}
+	if original.Spec[0] != '{' {
+		t.Error("modifying the clone's spec changed the original")
+	}
+}
+
+// BenchmarkClone compares Clone's manual copy with a JSON round-trip of the resource
+func BenchmarkClone(b *testing.B) {
+	resource := benchmarkPod()
+
+	b.Run("Manual", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = resource.Clone()
+		}
+	})
+	b.Run("JSONRoundTrip", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, err := json.Marshal(resource)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var clone Resource
+			if err := json.Unmarshal(data, &clone); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestNewPodSpecTotalsRequests(t *testing.T) {
+	t.Parallel()
+
+	requests := func(cpu, memory string) v1.ResourceRequirements {
+		list := v1.ResourceList{}
+		if cpu != "" {
+			list[v1.ResourceCPU] = resource.MustParse(cpu)
+		}
+		if memory != "" {
+			list[v1.ResourceMemory] = resource.MustParse(memory)
+		}
+		return v1.ResourceRequirements{Requests: list}
+	}
+	spec := NewPodSpec(v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "migrate", Resources: requests("2", "1Gi")}},
+		Containers: []v1.Container{
+			{Name: "app", Resources: requests("250m", "128Mi")},
+			{Name: "proxy", Resources: requests("100m", "")},
+			{Name: "unbounded"},
+		},
+	}, nil)
+
+	if got := spec.TotalCPURequests.String(); got != "350m" {
+		t.Errorf("TotalCPURequests = %s, want 350m", got)
+	}
+	if got := spec.TotalMemoryRequests.String(); got != "128Mi" {
+		t.Errorf("TotalMemoryRequests = %s, want 128Mi", got)
+	}
+
+	typed, err := (&Resource{Type: "Pod", Spec: NewSpec(spec)}).TypedSpec()
+	if err != nil {
+		t.Fatalf("TypedSpec() error = %v", err)
+	}
+	if got := typed.(*PodSpec).TotalCPURequests.String(); got != "350m" {
+		t.Errorf("decoded TotalCPURequests = %s, want 350m", got)
+	}
+}
+
+func TestNewSpecUnencodable(t *testing.T) {
+	t.Parallel()
+
+	if got := NewSpec(map[string]interface{}{"handler": func() {}}); got != nil {
+		t.Errorf("NewSpec() of an unencodable spec = %s, want nil", got)
+	}
+	if got := NewSpec(nil); got != nil {
+		t.Errorf("NewSpec(nil) = %s, want nil", got)
+	}
+}
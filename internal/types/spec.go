@@ -0,0 +1,192 @@
+package types
+
+import (
+	"encoding/json"
+	"log"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ContainerInfo summarizes a container for the UI's container table
+type ContainerInfo struct {
+	Name            string `json:"name"`
+	Image           string `json:"image"`
+	ImagePullPolicy string `json:"imagePullPolicy"`
+	RestartCount    int32  `json:"restartCount"`
+	Ready           bool   `json:"ready"`
+}
+
+// PodSpec is the Spec of Pods: the Pod spec with containers replaced by their summary
+type PodSpec struct {
+	v1.PodSpec
+	Containers []ContainerInfo `json:"containers"`
+
+	// Sums of the app containers' requests; init containers run before them and aren't counted
+	TotalCPURequests    resource.Quantity `json:"totalCpuRequests"`
+	TotalMemoryRequests resource.Quantity `json:"totalMemoryRequests"`
+}
+
+// NewPodSpec returns the Spec of a Pod, computing its request totals
+func NewPodSpec(spec v1.PodSpec, containers []ContainerInfo) PodSpec {
+	podSpec := PodSpec{PodSpec: spec, Containers: containers}
+	for _, container := range spec.Containers {
+		if cpu, ok := container.Resources.Requests[v1.ResourceCPU]; ok {
+			podSpec.TotalCPURequests.Add(cpu)
+		}
+		if memory, ok := container.Resources.Requests[v1.ResourceMemory]; ok {
+			podSpec.TotalMemoryRequests.Add(memory)
+		}
+	}
+	return podSpec
+}
+
+// DeploymentSpec is the Spec of Deployments
+type DeploymentSpec struct {
+	appsv1.DeploymentSpec
+}
+
+// ReplicaSetSpec is the Spec of ReplicaSets
+type ReplicaSetSpec struct {
+	appsv1.ReplicaSetSpec
+}
+
+// ServiceSpec is the Spec of Services
+type ServiceSpec struct {
+	v1.ServiceSpec
+}
+
+// IngressSpec is the Spec of Ingresses
+type IngressSpec struct {
+	networkingv1.IngressSpec
+}
+
+// ConfigMapSpec is the Spec of ConfigMaps: their data
+type ConfigMapSpec map[string]string
+
+// SecretSpec is the Spec of Secrets, which never includes their data
+type SecretSpec struct {
+	Type string `json:"type"`
+}
+
+// NodeResources are the quantities of a Node's capacity or allocatable resources
+type NodeResources struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+	Pods   string `json:"pods"`
+}
+
+// NodeInfo is the software a Node runs
+type NodeInfo struct {
+	OSImage          string `json:"osImage"`
+	KernelVersion    string `json:"kernelVersion"`
+	KubeletVersion   string `json:"kubeletVersion"`
+	ContainerRuntime string `json:"containerRuntime"`
+}
+
+// NodeSpec is the Spec of Nodes
+type NodeSpec struct {
+	Capacity      NodeResources `json:"capacity"`
+	Allocatable   NodeResources `json:"allocatable"`
+	NodeInfo      NodeInfo      `json:"nodeInfo"`
+	Unschedulable bool          `json:"unschedulable"`
+}
+
+// StorageClassSpec is the Spec of StorageClasses, with API server defaults filled in
+type StorageClassSpec struct {
+	Provisioner          string            `json:"provisioner"`
+	ReclaimPolicy        string            `json:"reclaimPolicy"`
+	VolumeBindingMode    string            `json:"volumeBindingMode"`
+	AllowVolumeExpansion bool              `json:"allowVolumeExpansion"`
+	IsDefault            bool              `json:"isDefault"`
+	Parameters           map[string]string `json:"parameters"`
+}
+
+// PersistentVolumeClaimSpec is the Spec of PersistentVolumeClaims
+type PersistentVolumeClaimSpec struct {
+	StorageClassName string   `json:"storageClassName"`
+	VolumeName       string   `json:"volumeName"`
+	AccessModes      []string `json:"accessModes"`
+	Capacity         string   `json:"capacity"` // Empty until bound
+}
+
+// PodDisruptionBudgetSpec is the Spec of PodDisruptionBudgets, with their status counts
+type PodDisruptionBudgetSpec struct {
+	MinAvailable       string `json:"minAvailable,omitempty"`
+	MaxUnavailable     string `json:"maxUnavailable,omitempty"`
+	Selector           string `json:"selector,omitempty"`
+	DisruptionsAllowed int32  `json:"disruptionsAllowed"`
+	CurrentHealthy     int32  `json:"currentHealthy"`
+	DesiredHealthy     int32  `json:"desiredHealthy"`
+	ExpectedPods       int32  `json:"expectedPods"`
+}
+
+// HelmReleaseSpec is the Spec of HelmRelease resources
+type HelmReleaseSpec struct {
+	Release   string `json:"release"`
+	Chart     string `json:"chart,omitempty"` // "<chart>-<version>", from the helm.sh/chart label
+	Resources int    `json:"resources"`
+}
+
+// NewSpec encodes a spec for Resource.Spec, returning nil for a nil spec.
+// A spec that fails to encode is logged and left out rather than failing the transform.
+func NewSpec(spec interface{}) json.RawMessage {
+	if spec == nil {
+		return nil
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		log.Printf("Warning: failed to encode %T spec, leaving it out: %v", spec, err)
+		return nil
+	}
+	return data
+}
+
+// TypedSpec decodes the Spec into the type matching the resource's Type, e.g. a *PodSpec
+// for Pods. Other types (dynamic and custom resources) decode to map[string]interface{}
+// or whatever JSON value the Spec holds; a resource without a Spec returns nil.
+func (r *Resource) TypedSpec() (interface{}, error) {
+	if len(r.Spec) == 0 {
+		return nil, nil
+	}
+
+	var spec interface{}
+	switch r.Type {
+	case "Pod":
+		spec = &PodSpec{}
+	case "Deployment":
+		spec = &DeploymentSpec{}
+	case "ReplicaSet":
+		spec = &ReplicaSetSpec{}
+	case "Service":
+		spec = &ServiceSpec{}
+	case "Ingress":
+		spec = &IngressSpec{}
+	case "ConfigMap":
+		spec = &ConfigMapSpec{}
+	case "Secret":
+		spec = &SecretSpec{}
+	case "Node":
+		spec = &NodeSpec{}
+	case "StorageClass":
+		spec = &StorageClassSpec{}
+	case "PersistentVolumeClaim":
+		spec = &PersistentVolumeClaimSpec{}
+	case "PodDisruptionBudget":
+		spec = &PodDisruptionBudgetSpec{}
+	case "HelmRelease":
+		spec = &HelmReleaseSpec{}
+	default:
+		var value interface{}
+		if err := json.Unmarshal(r.Spec, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	if err := json.Unmarshal(r.Spec, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
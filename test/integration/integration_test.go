@@ -0,0 +1,332 @@
+//go:build integration
+
+// Package integration runs k8v's client, watcher and app against a real API server and
+// etcd started by envtest. Run it with `make test-integration`, which downloads them.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	"github.com/user/k8v/internal/app"
+	"github.com/user/k8v/internal/k8s"
+	"github.com/user/k8v/internal/server"
+)
+
+// Both contexts of the test kubeconfig point at the envtest API server
+const (
+	firstContext  = "envtest-first"
+	secondContext = "envtest-second"
+)
+
+var clientset kubernetes.Interface
+
+func TestMain(m *testing.M) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		fmt.Println("KUBEBUILDER_ASSETS is not set, skipping integration tests (run make test-integration)")
+		os.Exit(0)
+	}
+	os.Exit(run(m))
+}
+
+// run starts the API server, points KUBECONFIG at it for the duration of the tests and
+// returns their exit code
+func run(m *testing.M) int {
+	env := &envtest.Environment{}
+	config, err := env.Start()
+	if err != nil {
+		log.Printf("starting envtest: %v", err)
+		return 1
+	}
+	defer env.Stop()
+
+	clientset, err = kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Printf("creating clientset: %v", err)
+		return 1
+	}
+
+	dir, err := os.MkdirTemp("", "k8v-integration-")
+	if err != nil {
+		log.Printf("creating kubeconfig directory: %v", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	kubeconfig, err := writeKubeconfig(env, filepath.Join(dir, "kubeconfig"))
+	if err != nil {
+		log.Printf("writing kubeconfig: %v", err)
+		return 1
+	}
+	os.Setenv("KUBECONFIG", kubeconfig)
+
+	return m.Run()
+}
+
+// writeKubeconfig writes a kubeconfig for an admin of env with two contexts, so context
+// switches can be tested against one API server
+func writeKubeconfig(env *envtest.Environment, path string) (string, error) {
+	user, err := env.AddUser(envtest.User{Name: "k8v", Groups: []string{"system:masters"}}, nil)
+	if err != nil {
+		return "", err
+	}
+	data, err := user.KubeConfig()
+	if err != nil {
+		return "", err
+	}
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return "", err
+	}
+	current := config.Contexts[config.CurrentContext]
+	config.Contexts = map[string]*clientcmdapi.Context{firstContext: current, secondContext: current.DeepCopy()}
+	config.CurrentContext = firstContext
+	return path, clientcmd.WriteToFile(*config, path)
+}
+
+// eventRecorder is a watcher EventHandler that waits for the events tests expect
+type eventRecorder struct {
+	mu       sync.Mutex
+	received map[string]bool // "TYPE id"
+	changed  chan struct{}
+}
+
+func newEventRecorder() *eventRecorder {
+	return &eventRecorder{received: make(map[string]bool), changed: make(chan struct{}, 1)}
+}
+
+func (r *eventRecorder) handle(ctx context.Context, event k8s.ResourceEvent) error {
+	r.mu.Lock()
+	r.received[string(event.Type)+" "+event.Resource.ID] = true
+	r.mu.Unlock()
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// waitFor fails unless every "TYPE id" event is received within timeout
+func (r *eventRecorder) waitFor(t *testing.T, timeout time.Duration, events ...string) {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		r.mu.Lock()
+		missing := []string{}
+		for _, event := range events {
+			if !r.received[event] {
+				missing = append(missing, event)
+			}
+		}
+		r.mu.Unlock()
+		if len(missing) == 0 {
+			return
+		}
+		select {
+		case <-r.changed:
+		case <-deadline:
+			t.Fatalf("events %v not received within %s", missing, timeout)
+		}
+	}
+}
+
+// createNamespace creates a namespace for one test, deleted once it ends
+func createNamespace(t *testing.T, name string) {
+	t.Helper()
+	ctx := context.Background()
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if _, err := clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating namespace %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		clientset.CoreV1().Namespaces().Delete(context.Background(), name, metav1.DeleteOptions{})
+	})
+}
+
+// startWatcher starts a synced watcher of namespace whose events go to a recorder
+func startWatcher(t *testing.T, namespace string, types ...string) (*k8s.Watcher, *eventRecorder) {
+	t.Helper()
+	client, err := k8s.NewClientWithOptions(firstContext, k8s.ClientOptions{Namespace: namespace})
+	if err != nil {
+		t.Fatalf("NewClientWithOptions() error = %v", err)
+	}
+	client.SetLogger(log.New(io.Discard, "", 0))
+	cache := k8s.NewResourceCache()
+	t.Cleanup(cache.Close)
+	recorder := newEventRecorder()
+	watcher := k8s.NewWatcherWithOptions(client, cache, recorder.handle, k8s.WatcherOptions{WatchResourceTypes: types})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	synced, err := watcher.StartAsync(ctx)
+	if err != nil {
+		t.Fatalf("StartAsync() error = %v", err)
+	}
+	select {
+	case err := <-synced:
+		if err != nil {
+			t.Fatalf("watcher sync error = %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("watcher didn't sync")
+	}
+	return watcher, recorder
+}
+
+func testPod(namespace, name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "nginx:1.27"}}},
+	}
+}
+
+func TestPodCreationBroadcastsAdded(t *testing.T) {
+	t.Parallel()
+
+	createNamespace(t, "pod-added")
+	_, recorder := startWatcher(t, "pod-added", "Pod")
+
+	ctx := context.Background()
+	if _, err := clientset.CoreV1().Pods("pod-added").Create(ctx, testPod("pod-added", "web"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating Pod: %v", err)
+	}
+	recorder.waitFor(t, 2*time.Second, "ADDED Pod:pod-added:web")
+}
+
+func TestDeploymentDeletionRemovesReplicaSets(t *testing.T) {
+	t.Parallel()
+
+	const namespace = "deployment-deleted"
+	createNamespace(t, namespace)
+	watcher, recorder := startWatcher(t, namespace, "Deployment", "ReplicaSet")
+
+	ctx := context.Background()
+	labels := map[string]string{"app": "web"}
+	replicas := int32(1)
+	deployment, err := clientset.AppsV1().Deployments(namespace).Create(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Spec: testPod(namespace, "").Spec},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("creating Deployment: %v", err)
+	}
+
+	// envtest runs no controllers, so ReplicaSets are created as the Deployment controller would
+	controller := true
+	for _, name := range []string{"web-1", "web-2"} {
+		_, err := clientset.AppsV1().ReplicaSets(namespace).Create(ctx, &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps/v1", Kind: "Deployment", Name: deployment.Name, UID: deployment.UID, Controller: &controller,
+				}},
+			},
+			Spec: appsv1.ReplicaSetSpec{Selector: deployment.Spec.Selector, Template: deployment.Spec.Template},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("creating ReplicaSet %s: %v", name, err)
+		}
+	}
+	recorder.waitFor(t, 5*time.Second, "ADDED ReplicaSet:"+namespace+":web-1", "ADDED ReplicaSet:"+namespace+":web-2")
+	if owned := watcher.GetResourcesByOwner("Deployment:" + namespace + ":web"); len(owned) != 2 {
+		t.Fatalf("Deployment owns %d ReplicaSets, want 2", len(owned))
+	}
+
+	// Nor is there a garbage collector: the cascade is deleted as it would delete it
+	background := metav1.DeletePropagationBackground
+	if err := clientset.AppsV1().Deployments(namespace).Delete(ctx, "web", metav1.DeleteOptions{PropagationPolicy: &background}); err != nil {
+		t.Fatalf("deleting Deployment: %v", err)
+	}
+	for _, name := range []string{"web-1", "web-2"} {
+		if err := clientset.AppsV1().ReplicaSets(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			t.Fatalf("deleting ReplicaSet %s: %v", name, err)
+		}
+	}
+	recorder.waitFor(t, 5*time.Second,
+		"DELETED Deployment:"+namespace+":web",
+		"DELETED ReplicaSet:"+namespace+":web-1",
+		"DELETED ReplicaSet:"+namespace+":web-2",
+	)
+	if count := watcher.GetResourceCount(); count != 0 {
+		t.Errorf("cache holds %d resources after the cascade, want 0", count)
+	}
+}
+
+func TestSwitchContextRebuildsWatcher(t *testing.T) {
+	t.Parallel()
+
+	const namespace = "context-switch"
+	createNamespace(t, namespace)
+	logger := server.NewLoggerTo(io.Discard)
+	hub, logHub := server.NewHub(logger), server.NewLogHub(logger)
+	go hub.Run()
+	go logHub.Run()
+
+	a := app.NewAppWithOptions(logger, hub, logHub, app.Options{
+		Watcher:   k8s.WatcherOptions{WatchResourceTypes: []string{"Pod"}},
+		Namespace: namespace,
+		ReadOnly:  true,
+	})
+	if err := a.Start(firstContext); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer a.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := a.WaitForSync(ctx); err != nil {
+		t.Fatalf("WaitForSync() error = %v", err)
+	}
+	first := a.GetWatcher()
+
+	if err := a.SwitchContext(secondContext); err != nil {
+		t.Fatalf("SwitchContext() error = %v", err)
+	}
+	if err := a.WaitForSync(ctx); err != nil {
+		t.Fatalf("WaitForSync() after the switch error = %v", err)
+	}
+	if got := a.GetCurrentContext(); got != secondContext {
+		t.Errorf("GetCurrentContext() = %q, want %q", got, secondContext)
+	}
+	second := a.GetWatcher()
+	if second == first {
+		t.Fatal("SwitchContext() kept the previous watcher")
+	}
+
+	// Only the new watcher follows the cluster
+	if _, err := clientset.CoreV1().Pods(namespace).Create(ctx, testPod(namespace, "web"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating Pod: %v", err)
+	}
+	id := "Pod:" + namespace + ":web"
+	for {
+		if _, ok := second.GetResource(id); ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("new watcher never received %s", id)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	if _, ok := first.GetResource(id); ok {
+		t.Errorf("stopped watcher received %s", id)
+	}
+}